@@ -145,12 +145,10 @@ func (kf *KitFile) MarshalToYAML() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func (kf *KitFile) ToModelPackConfig(diffIDs []digest.Digest) modelspecv1.Model {
+func (kf *KitFile) ToModelPackConfig(diffIDs []digest.Digest, createdAt time.Time) modelspecv1.Model {
 	// Fill fields as best as we can, depending on what's available in the Kitfile
-	now := time.Now()
-
 	modelDescriptor := modelspecv1.ModelDescriptor{
-		CreatedAt:   &now,
+		CreatedAt:   &createdAt,
 		Authors:     kf.Package.Authors,
 		Name:        kf.Package.Name,
 		Version:     kf.Package.Version,
@@ -158,6 +156,9 @@ func (kf *KitFile) ToModelPackConfig(diffIDs []digest.Digest) modelspecv1.Model
 		Title:       kf.Package.Name,
 		Description: kf.Package.Description,
 	}
+	if modelDescriptor.Version == "" && kf.Model != nil {
+		modelDescriptor.Version = kf.Model.Version
+	}
 
 	modelFS := modelspecv1.ModelFS{
 		Type:    "layers",
@@ -166,7 +167,8 @@ func (kf *KitFile) ToModelPackConfig(diffIDs []digest.Digest) modelspecv1.Model
 
 	modelConfig := modelspecv1.ModelConfig{}
 	if kf.Model != nil {
-		modelConfig.Format = kf.Model.Format
+		// Invalid parameters are reported when the Kitfile is validated; skip them here
+		modelConfig, _ = kf.Model.ModelPackConfig()
 	}
 
 	model := modelspecv1.Model{
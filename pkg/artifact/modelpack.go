@@ -0,0 +1,266 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package artifact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	modelspecv1 "github.com/modelpack/model-spec/specs-go/v1"
+)
+
+// Keys in a model's parameters that are used to populate the ModelPack model config. See kitfile.md
+// for descriptions of each key.
+const (
+	ParamArchitecture = "architecture"
+	ParamParamSize    = "paramSize"
+	ParamPrecision    = "precision"
+	ParamQuantization = "quantization"
+	ParamCapabilities = "capabilities"
+
+	CapabilityInputTypes      = "inputTypes"
+	CapabilityOutputTypes     = "outputTypes"
+	CapabilityKnowledgeCutoff = "knowledgeCutoff"
+	CapabilityReasoning       = "reasoning"
+	CapabilityToolUsage       = "toolUsage"
+	CapabilityReward          = "reward"
+	CapabilityLanguages       = "languages"
+)
+
+var (
+	validModalities = []modelspecv1.Modality{
+		modelspecv1.TextModality,
+		modelspecv1.ImageModality,
+		modelspecv1.AudioModality,
+		modelspecv1.VideoModality,
+		modelspecv1.EmbeddingModality,
+		modelspecv1.OtherModality,
+	}
+	languageRegexp = regexp.MustCompile(`^[a-z]{2}$`)
+)
+
+// ModelPackConfig returns the ModelPack model config for the model, using its format (or framework, if
+// format is not set) and the documented keys in its parameters. Invalid values in parameters are skipped
+// and returned as errors; other keys in parameters are ignored.
+func (m *Model) ModelPackConfig() (modelspecv1.ModelConfig, []error) {
+	config := modelspecv1.ModelConfig{
+		Format: m.Format,
+	}
+	if config.Format == "" {
+		config.Format = m.Framework
+	}
+	params, ok := m.Parameters.(map[string]any)
+	if !ok {
+		return config, nil
+	}
+
+	var errs []error
+	addErr := func(key string, format string, a ...any) {
+		errs = append(errs, fmt.Errorf("invalid value for parameter %s: %s", key, fmt.Sprintf(format, a...)))
+	}
+	stringParam := func(key string) string {
+		value, ok := params[key]
+		if !ok {
+			return ""
+		}
+		s, ok := value.(string)
+		if !ok || s == "" {
+			addErr(key, "must be a non-empty string")
+		}
+		return s
+	}
+
+	config.Architecture = stringParam(ParamArchitecture)
+	config.Precision = stringParam(ParamPrecision)
+	config.Quantization = stringParam(ParamQuantization)
+	// Parameter size is usually a string (e.g. "8b") but may also be a plain number
+	switch paramSize := params[ParamParamSize].(type) {
+	case nil:
+	case int, int64, uint64:
+		config.ParamSize = fmt.Sprint(paramSize)
+	case float64:
+		config.ParamSize = strconv.FormatFloat(paramSize, 'f', -1, 64)
+	default:
+		config.ParamSize = stringParam(ParamParamSize)
+	}
+
+	if rawCapabilities, ok := params[ParamCapabilities]; ok {
+		capabilities, capErrs := parseModelCapabilities(rawCapabilities)
+		config.Capabilities = capabilities
+		errs = append(errs, capErrs...)
+	}
+
+	return config, errs
+}
+
+func parseModelCapabilities(rawCapabilities any) (*modelspecv1.ModelCapabilities, []error) {
+	key := func(subkey string) string {
+		return ParamCapabilities + "." + subkey
+	}
+	params, ok := rawCapabilities.(map[string]any)
+	if !ok {
+		return nil, []error{fmt.Errorf("invalid value for parameter %s: must be a map", ParamCapabilities)}
+	}
+
+	var errs []error
+	addErr := func(key string, format string, a ...any) {
+		errs = append(errs, fmt.Errorf("invalid value for parameter %s: %s", key, fmt.Sprintf(format, a...)))
+	}
+	boolParam := func(subkey string) *bool {
+		value, ok := params[subkey]
+		if !ok {
+			return nil
+		}
+		b, ok := value.(bool)
+		if !ok {
+			addErr(key(subkey), "must be true or false")
+			return nil
+		}
+		return &b
+	}
+	listParam := func(subkey string, validate func(string) bool, expected string) []string {
+		value, ok := params[subkey]
+		if !ok {
+			return nil
+		}
+		list, ok := value.([]any)
+		if !ok {
+			addErr(key(subkey), "must be a list")
+			return nil
+		}
+		var result []string
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok || !validate(s) {
+				addErr(key(subkey), "%v is not %s", item, expected)
+				continue
+			}
+			result = append(result, s)
+		}
+		return result
+	}
+	isModality := func(s string) bool {
+		return slices.Contains(validModalities, modelspecv1.Modality(s))
+	}
+	modalities := func(subkey string) []modelspecv1.Modality {
+		var result []modelspecv1.Modality
+		for _, s := range listParam(subkey, isModality, fmt.Sprintf("a valid modality (one of %v)", validModalities)) {
+			result = append(result, modelspecv1.Modality(s))
+		}
+		return result
+	}
+
+	capabilities := &modelspecv1.ModelCapabilities{
+		InputTypes:  modalities(CapabilityInputTypes),
+		OutputTypes: modalities(CapabilityOutputTypes),
+		Reasoning:   boolParam(CapabilityReasoning),
+		ToolUsage:   boolParam(CapabilityToolUsage),
+		Reward:      boolParam(CapabilityReward),
+		Languages:   listParam(CapabilityLanguages, languageRegexp.MatchString, "a two-letter ISO 639 language code"),
+	}
+	if value, ok := params[CapabilityKnowledgeCutoff]; ok {
+		cutoff, err := parseKnowledgeCutoff(value)
+		if err != nil {
+			addErr(key(CapabilityKnowledgeCutoff), "%s", err)
+		} else {
+			capabilities.KnowledgeCutoff = &cutoff
+		}
+	}
+
+	// Unknown keys are likely typos, since capabilities are only used for the ModelPack config
+	var unknownKeys []string
+	for subkey := range params {
+		switch subkey {
+		case CapabilityInputTypes, CapabilityOutputTypes, CapabilityKnowledgeCutoff, CapabilityReasoning,
+			CapabilityToolUsage, CapabilityReward, CapabilityLanguages:
+		default:
+			unknownKeys = append(unknownKeys, subkey)
+		}
+	}
+	sort.Strings(unknownKeys)
+	for _, subkey := range unknownKeys {
+		errs = append(errs, fmt.Errorf("unknown parameter %s", key(subkey)))
+	}
+
+	return capabilities, errs
+}
+
+// parseKnowledgeCutoff parses an RFC 3339 timestamp or date. YAML timestamps are already parsed
+// when the Kitfile is loaded.
+func parseKnowledgeCutoff(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC(), nil
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t.UTC(), nil
+		}
+		if t, err := time.Parse(time.DateOnly, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("must be an RFC 3339 date or timestamp (e.g. 2024-06-01)")
+}
+
+// KitFileFromModelPackConfig returns a Kitfile describing the package and model metadata in a ModelPack
+// config. This is the inverse of ToModelPackConfig: model config fields are stored in the model's parameters
+// under the same keys used by ModelPackConfig. The returned Kitfile does not include paths for any layers.
+func KitFileFromModelPackConfig(config *modelspecv1.Model) (*KitFile, error) {
+	descriptor := config.Descriptor
+	kf := &KitFile{
+		ManifestVersion: "1.0.0",
+		Package: Package{
+			Name:        descriptor.Name,
+			Version:     descriptor.Version,
+			Description: descriptor.Description,
+			Authors:     descriptor.Authors,
+			// Licenses are SPDX license expressions, so multiple licenses all apply
+			License: strings.Join(descriptor.Licenses, " AND "),
+		},
+		Model: &Model{
+			Name:   descriptor.Name,
+			Format: config.Config.Format,
+		},
+	}
+	if kf.Package.Name == "" {
+		kf.Package.Name = descriptor.Title
+		kf.Model.Name = descriptor.Title
+	}
+
+	// Round-trip through JSON to get parameters in the same form as when parsed from a Kitfile
+	modelConfig := config.Config
+	modelConfig.Format = ""
+	configBytes, err := json.Marshal(modelConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal model config: %w", err)
+	}
+	params := map[string]any{}
+	if err := json.Unmarshal(configBytes, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse model config: %w", err)
+	}
+	if len(params) > 0 {
+		kf.Model.Parameters = params
+	}
+
+	return kf, nil
+}
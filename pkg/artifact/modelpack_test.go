@@ -0,0 +1,169 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package artifact
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelPackConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		model        string
+		expectConfig string
+		expectErrs   []string
+	}{
+		{
+			name: "all parameters",
+			model: `
+  format: safetensors
+  framework: pytorch
+  parameters:
+    architecture: transformer
+    paramSize: 8b
+    precision: bf16
+    quantization: awq
+    capabilities:
+      inputTypes: [text, image]
+      outputTypes: [text]
+      knowledgeCutoff: 2024-06-01
+      reasoning: true
+      toolUsage: false
+      languages: [en, fr]
+`,
+			expectConfig: `{"architecture":"transformer","format":"safetensors","paramSize":"8b","precision":"bf16","quantization":"awq","capabilities":{"inputTypes":["text","image"],"outputTypes":["text"],"knowledgeCutoff":"2024-06-01T00:00:00Z","reasoning":true,"toolUsage":false,"languages":["en","fr"]}}`,
+		},
+		{
+			name: "framework used as format",
+			model: `
+  framework: pytorch
+  parameters:
+    paramSize: 7000000000
+    other-parameter: ignored
+`,
+			expectConfig: `{"format":"pytorch","paramSize":"7000000000"}`,
+		},
+		{
+			name: "non-map parameters are ignored",
+			model: `
+  parameters: [architecture]
+`,
+			expectConfig: `{}`,
+		},
+		{
+			name: "invalid parameters",
+			model: `
+  parameters:
+    architecture: [transformer]
+    precision: ""
+    capabilities:
+      inputTypes: [text, smell]
+      reasoning: sometimes
+      knowledgeCutoff: last year
+      languages: english
+      reward-model: true
+`,
+			expectConfig: `{"capabilities":{"inputTypes":["text"]}}`,
+			expectErrs: []string{
+				"invalid value for parameter architecture: must be a non-empty string",
+				"invalid value for parameter precision: must be a non-empty string",
+				"invalid value for parameter capabilities.inputTypes: smell is not a valid modality (one of [text image audio video embedding other])",
+				"invalid value for parameter capabilities.reasoning: must be true or false",
+				"invalid value for parameter capabilities.languages: must be a list",
+				"invalid value for parameter capabilities.knowledgeCutoff: must be an RFC 3339 date or timestamp (e.g. 2024-06-01)",
+				"unknown parameter capabilities.reward-model",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kf := &KitFile{}
+			kitfileYaml := "manifestVersion: 1.0.0\nmodel:\n  path: model" + tt.model
+			if err := kf.LoadModel(io.NopCloser(strings.NewReader(kitfileYaml))); !assert.NoError(t, err) {
+				return
+			}
+			config, errs := kf.Model.ModelPackConfig()
+			var errStrings []string
+			for _, err := range errs {
+				errStrings = append(errStrings, err.Error())
+			}
+			assert.Equal(t, tt.expectErrs, errStrings)
+			configJson, err := json.Marshal(config)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.expectConfig, string(configJson))
+			}
+		})
+	}
+}
+
+func TestToModelPackConfigVersion(t *testing.T) {
+	kf := &KitFile{
+		Package: Package{Name: "test"},
+		Model:   &Model{Path: "model", Version: "1.2.3", Parameters: map[string]any{"architecture": "cnn"}},
+	}
+	config := kf.ToModelPackConfig(nil, time.Unix(0, 0))
+	assert.Equal(t, "1.2.3", config.Descriptor.Version, "Model version should be used if package version is not set")
+	assert.Equal(t, "cnn", config.Config.Architecture)
+
+	kf.Package.Version = "2.0.0"
+	config = kf.ToModelPackConfig(nil, time.Unix(0, 0))
+	assert.Equal(t, "2.0.0", config.Descriptor.Version, "Package version should take precedence over model version")
+}
+
+func TestKitFileFromModelPackConfig(t *testing.T) {
+	kitfileYaml := `manifestVersion: 1.0.0
+package:
+  name: test-model
+  version: 1.0.0
+  description: Test model
+  license: Apache-2.0
+  authors: [Test Author]
+model:
+  path: model
+  format: gguf
+  parameters:
+    architecture: transformer
+    quantization: q4_0
+    capabilities:
+      languages: [en]
+      toolUsage: true
+`
+	kf := &KitFile{}
+	if err := kf.LoadModel(io.NopCloser(strings.NewReader(kitfileYaml))); !assert.NoError(t, err) {
+		return
+	}
+	config := kf.ToModelPackConfig(nil, time.Unix(0, 0))
+	generated, err := KitFileFromModelPackConfig(&config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, kf.Package, generated.Package)
+	assert.Equal(t, kf.Model.Format, generated.Model.Format)
+	assert.Equal(t, "test-model", generated.Model.Name)
+	assert.Empty(t, generated.Model.Path, "Generated Kitfile should not include paths")
+
+	// Parameters should round-trip to the same ModelPack config
+	generatedConfig, errs := generated.Model.ModelPackConfig()
+	assert.Empty(t, errs)
+	assert.Equal(t, config.Config, generatedConfig)
+}
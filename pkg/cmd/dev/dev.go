@@ -188,6 +188,7 @@ func extractModelKitToCache(ctx context.Context, options *DevStartOptions) error
 		UnpackDir:      extractDir,
 		ConfigHome:     options.configHome,
 		Overwrite:      true, // Safe for extraction directory
+		Stream:         options.stream,
 		NetworkOptions: options.NetworkOptions,
 	}
 
@@ -55,6 +55,7 @@ type DevStartOptions struct {
 	modelFile  string
 	contextDir string
 	modelRef   *registry.Reference // For ModelKit references
+	stream     bool
 }
 
 func (opts *DevStartOptions) complete(ctx context.Context, args []string) error {
@@ -83,6 +84,14 @@ func (opts *DevStartOptions) complete(ctx context.Context, args []string) error
 	if opts.modelRef != nil && opts.modelFile != "" {
 		return fmt.Errorf("cannot specify both a ModelKit reference and a --file flag")
 	}
+	if opts.stream {
+		if opts.modelRef == nil {
+			return fmt.Errorf("--stream requires a ModelKit reference")
+		}
+		if opts.modelRef.Registry == util.DefaultRegistry {
+			return fmt.Errorf("--stream requires a reference that includes a registry")
+		}
+	}
 
 	// Using a directory, find Kitfile unless --file is provided
 	if opts.modelRef == nil && opts.modelFile == "" {
@@ -135,6 +135,7 @@ func TestDevStartOptions_Complete_ErrorCases(t *testing.T) {
 		name                string
 		args                []string
 		setupKitfile        bool
+		stream              bool
 		expectErrorContains string
 	}{
 		{
@@ -147,6 +148,18 @@ func TestDevStartOptions_Complete_ErrorCases(t *testing.T) {
 			args:                []string{""},
 			expectErrorContains: "no Kitfile found in directory",
 		},
+		{
+			name:                "stream with directory",
+			args:                []string{"."},
+			stream:              true,
+			expectErrorContains: "--stream requires a ModelKit reference",
+		},
+		{
+			name:                "stream without registry",
+			args:                []string{"my-model:latest"},
+			stream:              true,
+			expectErrorContains: "--stream requires a reference that includes a registry",
+		},
 	}
 
 	for _, tt := range tests {
@@ -161,7 +174,7 @@ func TestDevStartOptions_Complete_ErrorCases(t *testing.T) {
 			defer os.Chdir(origWd)
 			require.NoError(t, os.Chdir(tmpDir))
 
-			opts := &DevStartOptions{}
+			opts := &DevStartOptions{stream: tt.stream}
 			configHome := filepath.Join(tmpDir, ".kitops")
 
 			// Create context with config key as expected by the complete method
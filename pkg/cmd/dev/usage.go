@@ -31,7 +31,9 @@ Start a development server for a modelkit. You can provide either:
   to a temporary directory
 
 When using a ModelKit reference, only the model components are extracted
-to optimize startup time.`
+to optimize startup time. With the --stream flag, the modelkit is always read
+from its remote registry and extracted as it is downloaded, without storing it
+in local storage.`
 
 	devStartExample = `# Serve the model located in the current directory
 kit dev start
@@ -42,6 +44,9 @@ kit dev start ./my-model --port 8080
 # Serve a ModelKit reference from local storage or registry
 kit dev start myrepo/my-model:latest
 
+# Serve a ModelKit directly from a registry without storing it locally
+kit dev start registry.example.com/models/llama2:7b --stream
+
 # Serve a specific model with custom host and port
 kit dev start registry.example.com/models/llama2:7b --host 0.0.0.0 --port 8080`
 
@@ -67,6 +67,9 @@ func importUsingHF(ctx context.Context, opts *importOptions) error {
 		if err := kfutils.ValidateKitfile(kitfile); err != nil {
 			return err
 		}
+		if err := kfutils.ValidateModelPackConfig(kitfile); err != nil {
+			output.Logf(output.LogLevelWarn, "%s", err)
+		}
 	} else if opts.kitfilePath != "" {
 		kf, err := readExistingKitfile(opts.kitfilePath)
 		if err != nil {
@@ -82,6 +82,9 @@ func readExistingKitfile(kfPath string) (*artifact.KitFile, error) {
 	if err := kfutils.ValidateKitfile(kitfile); err != nil {
 		return nil, fmt.Errorf("kitfile (%s) is invalid: %w", kfPath, err)
 	}
+	if err := kfutils.ValidateModelPackConfig(kitfile); err != nil {
+		output.Logf(output.LogLevelWarn, "%s", err)
+	}
 	return kitfile, nil
 }
 
@@ -105,6 +108,11 @@ func packDirectory(ctx context.Context, configHome, contextDir string, kitfile *
 	if err != nil {
 		return err
 	}
+	unlock, err := local.LockStorageForWrite(constants.StoragePath(configHome))
+	if err != nil {
+		return fmt.Errorf("failed to lock local storage: %w", err)
+	}
+	defer unlock()
 	ignore, err := ignore.NewFromContext(contextDir, kitfile)
 	if err != nil {
 		return err
@@ -0,0 +1,86 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package load
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	shortDesc = `Import modelkits from an archive into local storage`
+	longDesc  = `Import modelkits from a tar archive in the OCI image layout format into local
+storage.
+
+Archives are typically created using 'kit save'. Every modelkit in the archive that
+is stored under a reference (the org.opencontainers.image.ref.name annotation in the
+archive's index) is imported and tagged with that reference. Existing tags with the
+same name are updated to refer to the imported modelkits.`
+
+	example = `# Import all modelkits from an archive
+kit load modelkits.tar`
+)
+
+type loadOptions struct {
+	configHome  string
+	archivePath string
+}
+
+func (opts *loadOptions) complete(ctx context.Context, args []string) error {
+	configHome, ok := ctx.Value(constants.ConfigKey{}).(string)
+	if !ok {
+		return fmt.Errorf("default config path not set on command context")
+	}
+	opts.configHome = configHome
+	opts.archivePath = args[0]
+	return nil
+}
+
+func LoadCommand() *cobra.Command {
+	opts := &loadOptions{}
+	cmd := &cobra.Command{
+		Use:     "load [flags] ARCHIVE",
+		Short:   shortDesc,
+		Long:    longDesc,
+		Example: example,
+		RunE:    runCommand(opts),
+		Args:    cobra.ExactArgs(1),
+	}
+	cmd.Flags().SortFlags = false
+
+	return cmd
+}
+
+func runCommand(opts *loadOptions) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := opts.complete(cmd.Context(), args); err != nil {
+			return output.Fatalf("Invalid arguments: %s", err)
+		}
+
+		loaded, err := runLoad(cmd.Context(), opts)
+		if err != nil {
+			return output.Fatalln(err)
+		}
+		output.Infof("Loaded %d modelkits from %s", loaded, opts.archivePath)
+		return nil
+	}
+}
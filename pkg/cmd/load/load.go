@@ -0,0 +1,105 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package load
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/local"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// runLoad imports every named modelkit in the archive at opts.archivePath into local storage, tagging each
+// with the reference it is stored under in the archive. It returns the number of modelkits imported.
+func runLoad(ctx context.Context, opts *loadOptions) (int, error) {
+	archive, err := oci.NewFromTar(ctx, opts.archivePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read archive %s: %w", opts.archivePath, err)
+	}
+	var refNames []string
+	err = archive.Tags(ctx, "", func(tags []string) error {
+		for _, tag := range tags {
+			// Every manifest in the archive is also tagged by its digest, which does not include a repository
+			if !util.ReferenceIsDigest(tag) {
+				refNames = append(refNames, tag)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read archive index: %w", err)
+	}
+	if len(refNames) == 0 {
+		return 0, fmt.Errorf("archive %s does not contain any named modelkits", opts.archivePath)
+	}
+	slices.Sort(refNames)
+
+	storageRoot := constants.StoragePath(opts.configHome)
+	for _, refName := range refNames {
+		if err := loadModelKit(ctx, storageRoot, archive, refName); err != nil {
+			return 0, err
+		}
+	}
+	return len(refNames), nil
+}
+
+// loadModelKit copies the manifest stored as refName in archive into local storage and tags it.
+func loadModelKit(ctx context.Context, storageRoot string, archive *oci.ReadOnlyStore, refName string) error {
+	ref, _, err := util.ParseReference(refName)
+	if err != nil {
+		return fmt.Errorf("invalid reference %s in archive: %w", refName, err)
+	}
+	desc, err := archive.Resolve(ctx, refName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s in archive: %w", refName, err)
+	}
+	if desc.MediaType != ocispec.MediaTypeImageManifest {
+		return fmt.Errorf("reference %s in archive is not an image manifest", refName)
+	}
+	// The archive's ref name annotation would otherwise be recorded as a tag verbatim in local storage
+	desc.Annotations = nil
+	localRepo, err := local.NewLocalRepo(storageRoot, ref)
+	if err != nil {
+		return fmt.Errorf("failed to read local storage: %w", err)
+	}
+	unlock, err := local.LockStorageForWrite(storageRoot)
+	if err != nil {
+		return fmt.Errorf("failed to lock local storage: %w", err)
+	}
+	defer unlock()
+	if err := localRepo.EnsureDirs(desc); err != nil {
+		return err
+	}
+	if err := oras.CopyGraph(ctx, archive, localRepo, desc, oras.DefaultCopyGraphOptions); err != nil {
+		return fmt.Errorf("failed to import %s: %w", refName, err)
+	}
+	if !util.ReferenceIsDigest(ref.Reference) && ref.Reference != "" {
+		if err := localRepo.Tag(ctx, desc, ref.Reference); err != nil {
+			return fmt.Errorf("failed to tag %s: %w", refName, err)
+		}
+	}
+	output.Infof("Loaded %s (%s)", util.FormatRepositoryForDisplay(ref.String()), desc.Digest)
+	return nil
+}
@@ -21,8 +21,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/kitops-ml/kitops/pkg/cmd/options"
 	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
 	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
 
@@ -46,25 +49,93 @@ as pushing to a remote registry for collaboration.
 Unless a different location is specified, this command looks for the kitfile
 at the root of the provided context directory. Any relative paths defined
 within the kitfile are interpreted as being relative to this context
-directory.`
+directory.
+
+Layers for files that are unchanged since they were last packed (based on
+their size, modification and change times, and inode) are reused from local
+storage rather than packed again.
+
+Zstd compression of each layer is split across all available CPUs. Gzip
+compression uses a single CPU per layer unless --parallel-gzip is specified.
+Parallel gzip output does not depend on the number of CPUs or on --concurrency,
+but differs from standard gzip output: layers packed with --parallel-gzip have
+different digests than the same files packed without it, including by earlier
+versions of Kit, and are not deduplicated against those layers in local storage
+or remote registries.
+
+With --push and --no-local-copy, layers are uploaded to the registry as they are
+packed, without saving them to local storage or to temporary files. Layers split
+with --chunk-size, and layers pushed to registries that do not support chunked
+uploads (such as ghcr.io, Google Artifact Registry and Amazon ECR), are still
+written to a temporary file before they are uploaded.
+
+With --chunk-size, layers larger than the chunk size are stored as multiple blobs
+with a dedicated layer chunk media type. Versions of Kit that do not support
+chunked layers fail with an unrecognized media type error when unpacking these
+modelkits.`
 
 	examples = `# Pack a modelkit using the kitfile in the current directory
 kit pack .
 
 # Pack a modelkit with a specific kitfile and tag
-kit pack . -f /path/to/your/Kitfile -t registry/repository:modelv1`
+kit pack . -f /path/to/your/Kitfile -t registry/repository:modelv1
+
+# Pack a modelkit using zstd compression at a higher compression level
+kit pack . --compression zstd --compression-level 9
+
+# Pack a modelkit with a sharded model, packing up to 16 layers at a time
+kit pack . --compression zstd --concurrency 16
+
+# Pack a modelkit using gzip compression split across all available CPUs
+kit pack . --compression gzip --parallel-gzip
+
+# Pack a modelkit, splitting layers larger than 2 GiB into multiple blobs for registries
+# that limit blob size
+kit pack . --chunk-size 2GiB
+
+# Pack a reproducible modelkit, using timestamps from $SOURCE_DATE_EPOCH, and verify
+# that packing again produces the same digest
+SOURCE_DATE_EPOCH=1700000000 kit pack . --reproducible --omit-version-annotation --check-reproducible
+
+# Pack a modelkit and push it to a remote registry, uploading layers as they are
+# packed without saving the modelkit to local storage
+kit pack . -t registry.example.com/my-org/my-model:latest --push --no-local-copy
+
+# Pack a modelkit from scratch, without reusing layers for files that are unchanged
+# since the last pack
+kit pack . --no-layer-cache
+
+# Pack a modelkit, preserving symlinks and hardlinks within layers
+kit pack . --preserve-links
+
+# Pack in ModelPack format, storing single-file entries (e.g. .safetensors) as raw layers
+kit pack . --use-model-pack --layer-format raw`
 )
 
 type packOptions struct {
-	modelFile    string
-	contextDir   string
-	configHome   string
-	storageHome  string
-	fullTagRef   string
-	compression  string
-	modelRef     *registry.Reference
-	extraRefs    []string
-	useModelPack bool
+	options.NetworkOptions
+	modelFile     string
+	contextDir    string
+	configHome    string
+	storageHome   string
+	fullTagRef    string
+	compression   string
+	compLevel     int
+	parallelGzip  bool
+	layerFormat   string
+	preserveLinks bool
+	reproducible  bool
+	omitVersion   bool
+	checkRepro    bool
+	noLayerCache  bool
+	push          bool
+	noLocalCopy   bool
+	sourceDate    *time.Time
+	chunkSizeStr  string
+	chunkSize     int64
+	modelRef      *registry.Reference
+	extraRefs     []string
+	useModelPack  bool
 }
 
 func PackCommand() *cobra.Command {
@@ -79,8 +150,21 @@ func PackCommand() *cobra.Command {
 	}
 	cmd.Flags().StringVarP(&opts.modelFile, "file", "f", "", "Specifies the path to the Kitfile explicitly (use \"-\" to read from standard input)")
 	cmd.Flags().StringVarP(&opts.fullTagRef, "tag", "t", "", "Assigns one or more tags to the built modelkit. Example: -t registry/repository:tag1,tag2")
-	cmd.Flags().StringVar(&opts.compression, "compression", "none", "Compression format to use for layers. Valid options: 'none' (default), 'gzip', 'gzip-fastest'")
+	cmd.Flags().StringVar(&opts.compression, "compression", "none", "Compression format to use for layers. Valid options: 'none' (default), 'gzip', 'gzip-fastest', 'zstd'")
+	cmd.Flags().IntVar(&opts.compLevel, "compression-level", 0, "Compression level to use for layers: 1-9 for 'gzip', 1-22 for 'zstd' (default: standard level for the compression format)")
+	cmd.Flags().BoolVar(&opts.parallelGzip, "parallel-gzip", false, "Split gzip compression of each layer across all available CPUs. Changes layer digests compared to standard gzip compression")
 	cmd.Flags().BoolVar(&opts.useModelPack, "use-model-pack", false, "Pack model in ModelPack format instead of ModelKit")
+	cmd.Flags().StringVar(&opts.layerFormat, "layer-format", "tar", "Format to use for layers in ModelPack format. Valid options: 'tar' (default), 'raw' (store single-file entries without tar framing)")
+	cmd.Flags().StringVar(&opts.chunkSizeStr, "chunk-size", "", "Split layers larger than this size (e.g. '2GiB') into multiple chunk blobs. Not supported with --use-model-pack")
+	cmd.Flags().BoolVar(&opts.reproducible, "reproducible", false, "Normalize file modes and use $SOURCE_DATE_EPOCH (or the Unix epoch, if unset) for timestamps so that packing the same inputs produces the same digest")
+	cmd.Flags().BoolVar(&opts.omitVersion, "omit-version-annotation", false, "Do not record the version of Kit used to pack the modelkit in the manifest's annotations")
+	cmd.Flags().BoolVar(&opts.checkRepro, "check-reproducible", false, "Pack the modelkit twice and fail if the resulting digests differ")
+	cmd.Flags().BoolVar(&opts.noLayerCache, "no-layer-cache", false, "Always pack layers from scratch instead of reusing layers for files that are unchanged since the last pack")
+	cmd.Flags().BoolVar(&opts.preserveLinks, "preserve-links", false, "Store symlinks and hardlinks in layers as links. Symlinks must point to a path within the same layer")
+	cmd.Flags().BoolVar(&opts.push, "push", false, "Push the modelkit to the registry specified by --tag after packing")
+	cmd.Flags().BoolVar(&opts.noLocalCopy, "no-local-copy", false, "With --push, upload layers to the registry as they are packed instead of saving the modelkit to local storage")
+	opts.AddNetworkFlags(cmd)
+	cmd.Flags().Lookup("concurrency").Usage = "Maximum number of layers to pack and upload simultaneously"
 	cmd.Flags().SortFlags = false
 	cmd.Args = cobra.ExactArgs(1)
 	cmd.CompletionOptions.SetDefaultShellCompDirective(cobra.ShellCompDirectiveDefault)
@@ -148,6 +232,56 @@ func (opts *packOptions) complete(ctx context.Context, args []string) error {
 	if err := mediatype.IsValidCompression(opts.compression); err != nil {
 		return err
 	}
+	compression, err := mediatype.ParseCompression(opts.compression)
+	if err != nil {
+		return err
+	}
+	if err := mediatype.IsValidCompressionLevel(compression, opts.compLevel); err != nil {
+		return err
+	}
+	if opts.parallelGzip && compression != mediatype.GzipCompression && compression != mediatype.GzipFastestCompression {
+		return fmt.Errorf("--parallel-gzip can only be used with gzip or gzip-fastest compression")
+	}
+	layerFormat, err := mediatype.ParseFormat(opts.layerFormat)
+	if err != nil {
+		return err
+	}
+	if layerFormat == mediatype.RawFormat && !opts.useModelPack {
+		return fmt.Errorf("raw layer format is only supported with --use-model-pack")
+	}
+	if opts.chunkSizeStr != "" {
+		chunkSize, err := output.ParseBytes(opts.chunkSizeStr)
+		if err != nil {
+			return fmt.Errorf("invalid argument for chunk size: %w", err)
+		}
+		if chunkSize < 1 {
+			return fmt.Errorf("invalid argument for chunk size (%s): must be greater than zero", opts.chunkSizeStr)
+		}
+		if opts.useModelPack {
+			return fmt.Errorf("chunked layers are not supported with --use-model-pack")
+		}
+		opts.chunkSize = chunkSize
+	}
+	if epoch := os.Getenv(constants.SourceDateEpochEnvVar); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s (%s): must be a Unix timestamp", constants.SourceDateEpochEnvVar, epoch)
+		}
+		sourceDate := time.Unix(seconds, 0).UTC()
+		opts.sourceDate = &sourceDate
+	}
+	if opts.noLocalCopy && !opts.push {
+		return fmt.Errorf("--no-local-copy can only be used with --push")
+	}
+	if opts.noLocalCopy && opts.checkRepro {
+		return fmt.Errorf("--check-reproducible cannot be used with --no-local-copy, as every layer would be uploaded twice")
+	}
+	if opts.push && (opts.fullTagRef == "" || opts.modelRef.Registry == "localhost") {
+		return fmt.Errorf("a tag including a registry is required when pushing (e.g. --tag registry.example.com/repository:tag)")
+	}
+	if err := opts.NetworkOptions.Complete(ctx, args); err != nil {
+		return err
+	}
 
 	printConfig(opts)
 	return nil
@@ -29,10 +29,13 @@ import (
 	"github.com/kitops-ml/kitops/pkg/lib/filesystem/ignore"
 	kfutils "github.com/kitops-ml/kitops/pkg/lib/kitfile"
 	"github.com/kitops-ml/kitops/pkg/lib/repo/local"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/remote"
 	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
 	"github.com/kitops-ml/kitops/pkg/output"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/registry"
 )
 
 // runPack compresses and stores a modelkit based on a Kitfile. Returns an error if packing
@@ -41,33 +44,72 @@ import (
 //
 // Packed modelkits are saved to the local on-disk cache. As OCI-spec indexes only support one
 // registry/repository reference at a time, individual blobs may be duplicated on disk if stored
-// under different references.
+// under different references. If options.push is set, the modelkit is also pushed to the remote
+// registry in its reference; with options.noLocalCopy, it is saved only to the remote registry.
 func runPack(ctx context.Context, options *packOptions) error {
-	kitfile, err := readKitfile(options.modelFile)
+	kitfile, err := readKitfile(options.modelFile, options.useModelPack)
 	if err != nil {
 		return err
 	}
 
-	storageHome := constants.StoragePath(options.configHome)
-	localRepo, err := local.NewLocalRepo(storageHome, options.modelRef)
-	if err != nil {
-		return fmt.Errorf("failed to open local storage: %w", err)
+	// With --no-local-copy, the modelkit is saved directly to the remote registry
+	var store oras.Target
+	var localRepo local.LocalRepo
+	var remoteRepo registry.Repository
+	if options.push {
+		remoteRepo, err = remote.NewRepository(ctx, options.modelRef.Registry, options.modelRef.Repository, &options.NetworkOptions)
+		if err != nil {
+			return err
+		}
+	}
+	if options.noLocalCopy {
+		store = remoteRepo
+	} else {
+		storageHome := constants.StoragePath(options.configHome)
+		localRepo, err = local.NewLocalRepo(storageHome, options.modelRef)
+		if err != nil {
+			return fmt.Errorf("failed to open local storage: %w", err)
+		}
+		// Packed blobs are not referenced until the manifest is saved; prevent them from being pruned
+		unlock, err := local.LockStorageForWrite(storageHome)
+		if err != nil {
+			return fmt.Errorf("failed to lock local storage: %w", err)
+		}
+		defer unlock()
+		store = localRepo
 	}
 
-	manifestDesc, err := pack(ctx, options, kitfile, localRepo)
+	manifestDesc, err := pack(ctx, options, kitfile, store)
 	if err != nil {
 		return err
 	}
+	if options.checkRepro {
+		output.Infof("Packing modelkit again to check reproducibility")
+		checkDesc, err := pack(ctx, options, kitfile, store)
+		if err != nil {
+			return err
+		}
+		if checkDesc.Digest != manifestDesc.Digest {
+			return fmt.Errorf("modelkit is not reproducible: packing twice produced digests %s and %s (consider using --reproducible)", manifestDesc.Digest, checkDesc.Digest)
+		}
+		output.Infof("Modelkit is reproducible: %s", manifestDesc.Digest)
+	}
 
 	if options.modelRef != nil && options.modelRef.Reference != "" {
-		if err := localRepo.Tag(ctx, *manifestDesc, options.modelRef.Reference); err != nil {
+		if err := store.Tag(ctx, *manifestDesc, options.modelRef.Reference); err != nil {
 			return fmt.Errorf("failed to tag manifest: %w", err)
 		}
 		output.Debugf("Added tag to manifest: %s", options.modelRef.Reference)
 	}
 
 	for _, tag := range options.extraRefs {
-		if err := localRepo.Tag(ctx, *manifestDesc, tag); err != nil {
+		if err := store.Tag(ctx, *manifestDesc, tag); err != nil {
+			return err
+		}
+	}
+
+	if options.push && !options.noLocalCopy {
+		if err := pushModel(ctx, localRepo, remoteRepo, manifestDesc, options); err != nil {
 			return err
 		}
 	}
@@ -77,7 +119,29 @@ func runPack(ctx context.Context, options *packOptions) error {
 	return nil
 }
 
-func pack(ctx context.Context, opts *packOptions, kitfile *artifact.KitFile, localRepo local.LocalRepo) (*ocispec.Descriptor, error) {
+// pushModel copies the packed modelkit from local storage to the remote repository, applying
+// all tags specified for the modelkit.
+func pushModel(ctx context.Context, localRepo local.LocalRepo, remoteRepo registry.Repository, manifestDesc *ocispec.Descriptor, opts *packOptions) error {
+	output.Infof("Pushing %s", opts.modelRef.String())
+	trackedRepo, logger := output.WrapTarget(remoteRepo)
+	copyOpts := oras.CopyGraphOptions{}
+	copyOpts.Concurrency = opts.Concurrency
+	if err := oras.CopyGraph(ctx, localRepo, trackedRepo, *manifestDesc, copyOpts); err != nil {
+		return fmt.Errorf("failed to push to remote: %w", err)
+	}
+	logger.Wait()
+
+	tags := append([]string{opts.modelRef.Reference}, opts.extraRefs...)
+	for _, tag := range tags {
+		if err := remoteRepo.Tag(ctx, *manifestDesc, tag); err != nil {
+			return fmt.Errorf("failed to tag remote manifest: %w", err)
+		}
+	}
+	output.Infof("Pushed %s", manifestDesc.Digest)
+	return nil
+}
+
+func pack(ctx context.Context, opts *packOptions, kitfile *artifact.KitFile, store oras.Target) (*ocispec.Descriptor, error) {
 	var extraLayerPaths []string
 	if kitfile.Model != nil && util.IsModelKitReference(kitfile.Model.Path) {
 		baseRef := util.FormatRepositoryForDisplay(opts.modelRef.String())
@@ -101,10 +165,24 @@ func pack(ctx context.Context, opts *packOptions, kitfile *artifact.KitFile, loc
 	if err != nil {
 		return nil, err
 	}
-	manifestDesc, err := filesystem.SaveModel(ctx, localRepo, kitfile, ignore, &filesystem.SaveModelOptions{
-		ModelFormat: modelFormat,
-		Compression: compression,
-		LayerFormat: mediatype.TarFormat,
+	layerFormat, err := mediatype.ParseFormat(opts.layerFormat)
+	if err != nil {
+		return nil, err
+	}
+	manifestDesc, err := filesystem.SaveModel(ctx, store, kitfile, ignore, &filesystem.SaveModelOptions{
+		ModelFormat:           modelFormat,
+		Compression:           compression,
+		CompressionLevel:      opts.compLevel,
+		ParallelGzip:          opts.parallelGzip,
+		LayerFormat:           layerFormat,
+		Concurrency:           opts.Concurrency,
+		PreserveLinks:         opts.preserveLinks,
+		Reproducible:          opts.reproducible,
+		SourceDateEpoch:       opts.sourceDate,
+		OmitVersionAnnotation: opts.omitVersion,
+		ChunkSize:             opts.chunkSize,
+		// Checking reproducibility requires packing every layer from scratch
+		UseLayerCache: !opts.noLayerCache && !opts.checkRepro,
 	})
 	if err != nil {
 		return nil, err
@@ -112,7 +190,7 @@ func pack(ctx context.Context, opts *packOptions, kitfile *artifact.KitFile, loc
 	return manifestDesc, nil
 }
 
-func readKitfile(modelFile string) (*artifact.KitFile, error) {
+func readKitfile(modelFile string, useModelPack bool) (*artifact.KitFile, error) {
 	// 1. Read the model file
 	kitfile := &artifact.KitFile{}
 	kitfileContentReader, err := readerForKitfile(modelFile)
@@ -126,6 +204,12 @@ func readKitfile(modelFile string) (*artifact.KitFile, error) {
 	if err := kfutils.ValidateKitfile(kitfile); err != nil {
 		return nil, err
 	}
+	if err := kfutils.ValidateModelPackConfig(kitfile); err != nil {
+		if useModelPack {
+			return nil, err
+		}
+		output.Logf(output.LogLevelWarn, "%s", err)
+	}
 	return kitfile, nil
 }
 
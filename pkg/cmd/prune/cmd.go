@@ -0,0 +1,118 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/local"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	shortDesc = `Remove unreferenced data from local storage`
+	longDesc  = `Remove blobs from local storage that are not referenced by any modelkit.
+
+Blobs can be left in local storage without any modelkit referring to them, for
+example when a pack or pull is interrupted or when a modelkit that shares layers
+with other modelkits is removed. This command finds every blob that is reachable
+from a modelkit in local storage (tagged or untagged) and removes all others,
+reporting the amount of disk space reclaimed.
+
+To remove untagged modelkits as well, first run 'kit remove --all'.
+
+Pruning waits for any pack, pull, import or load in progress to complete, and
+these commands wait for pruning to complete, so that blobs for a modelkit that is
+still being written are not removed. Unreferenced blobs modified within the grace
+period (24 hours by default) are also kept; use --grace-period 0 to remove them.`
+
+	example = `# Remove unreferenced blobs from local storage
+kit prune
+
+# List blobs that would be removed, without removing them
+kit prune --dry-run
+
+# Remove unreferenced blobs, including ones that were written recently
+kit prune --grace-period 0`
+)
+
+type pruneOptions struct {
+	configHome  string
+	dryRun      bool
+	gracePeriod time.Duration
+}
+
+func (opts *pruneOptions) complete(ctx context.Context, args []string) error {
+	configHome, ok := ctx.Value(constants.ConfigKey{}).(string)
+	if !ok {
+		return fmt.Errorf("default config path not set on command context")
+	}
+	opts.configHome = configHome
+	return nil
+}
+
+func PruneCommand() *cobra.Command {
+	opts := &pruneOptions{}
+	cmd := &cobra.Command{
+		Use:     "prune [flags]",
+		Short:   shortDesc,
+		Long:    longDesc,
+		Example: example,
+		RunE:    runCommand(opts),
+		Args:    cobra.NoArgs,
+	}
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "list unreferenced blobs without removing them")
+	cmd.Flags().DurationVar(&opts.gracePeriod, "grace-period", 24*time.Hour, "keep unreferenced blobs modified more recently than this duration")
+	cmd.Flags().SortFlags = false
+
+	return cmd
+}
+
+func runCommand(opts *pruneOptions) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := opts.complete(cmd.Context(), args); err != nil {
+			return output.Fatalf("Invalid arguments: %s", err)
+		}
+
+		storagePath := constants.StoragePath(opts.configHome)
+		pruned, err := local.Prune(cmd.Context(), storagePath, opts.dryRun, opts.gracePeriod)
+		var totalSize int64
+		for _, blob := range pruned {
+			totalSize += blob.Size
+			if opts.dryRun {
+				output.Infof("Would remove %s (%s)", blob.Digest, output.FormatBytes(blob.Size))
+			} else {
+				output.Debugf("Removed %s (%s)", blob.Digest, output.FormatBytes(blob.Size))
+			}
+		}
+		if err != nil {
+			return output.Fatalf("Failed to prune local storage: %s", err)
+		}
+
+		if opts.dryRun {
+			output.Infof("Would remove %d blobs, reclaiming %s", len(pruned), output.FormatBytes(totalSize))
+		} else {
+			output.Infof("Removed %d blobs, reclaimed %s", len(pruned), output.FormatBytes(totalSize))
+		}
+		return nil
+	}
+}
@@ -39,6 +39,9 @@ func PushModel(ctx context.Context, localRepo local.LocalRepo, repo registry.Rep
 		return ocispec.DescriptorEmptyJSON, fmt.Errorf("failed to copy to remote: %w", err)
 	}
 	logger.Wait()
+	if err := localRepo.MarkUsed(ctx, desc); err != nil {
+		output.Logf(output.LogLevelWarn, "Failed to record usage for %s: %s", desc.Digest, err)
+	}
 
-	return desc, err
+	return desc, nil
 }
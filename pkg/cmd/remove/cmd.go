@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/kitops-ml/kitops/pkg/cmd/options"
 	"github.com/kitops-ml/kitops/pkg/lib/completion"
@@ -47,6 +48,28 @@ tags that might refer to it. If the modelkit is specified with a tag (and the
 --force) flag is not used, it will only be untagged. If the --force flag is
 used, the modelkit and all tags referring to it will be removed (i.e. the same
 as if the digest for that tag was specified).
+
+Retention policies can be used to remove modelkits from local storage in bulk.
+Modelkits removed by a retention policy are removed along with all tags that
+refer to them:
+  --keep-last N    keeps the N most recently packed or pulled modelkits in each
+                   repository
+  --older-than D   removes modelkits packed or pulled more than D ago (e.g. 30d,
+                   2w, or 12h)
+  --max-size S     removes the least recently used modelkits until the remaining
+                   modelkits use at most S (e.g. 200GB) of disk space
+
+A modelkit is used when it is packed, pulled, unpacked (including by 'kit dev'),
+or pushed. Blobs shared between modelkits are counted once when applying
+--max-size. Policies can be combined, in which case a modelkit is removed if any
+policy applies to it. Blobs that are not referenced by any modelkit can be
+removed using 'kit prune'.
+
+Untagged modelkits that a tag previously referred to (see 'kit tag --history')
+can be restored using 'kit tag --restore'. These are kept by --all and by
+retention policies, which list them instead of removing them; use
+--include-history to remove them as well. Modelkits kept this way still count
+towards the space used when applying --max-size.
 `
 
 	examples = `# Remove modelkit by tag
@@ -58,12 +81,25 @@ kit remove my-registry.com/my-org/my-repo@sha256:<digest>
 # Remove multiple tags for a modelkit
 kit remove my-registry.com/my-org/my-repo:tag1,tag2,tag3
 
-# Remove all untagged modelkits
+# Remove all untagged modelkits, except previous targets of tags that can be
+# restored using 'kit tag --restore'
 kit remove --all
 
+# Remove all untagged modelkits, including previous targets of tags
+kit remove --all --include-history
+
 # Remove all locally stored modelkits
 kit remove --all --force
 
+# Keep only the three most recent modelkits in each repository
+kit remove --keep-last 3
+
+# Remove modelkits packed or pulled more than 30 days ago
+kit remove --older-than 30d
+
+# Remove least recently used modelkits until local storage uses at most 200GB
+kit remove --max-size 200GB
+
 # Untag a remote modelkit
 kit remove --remote my-registry.com/my-org/my-repo:my-tag
 
@@ -80,8 +116,22 @@ type removeOptions struct {
 	forceDelete bool
 	removeAll   bool
 	remote      bool
-	modelRef    *registry.Reference
-	extraTags   []string
+	// includeHistory removes untagged modelkits even if tags previously referred to them
+	includeHistory bool
+	modelRef       *registry.Reference
+	extraTags      []string
+
+	keepLast     int
+	keepLastSet  bool
+	olderThanStr string
+	olderThan    time.Duration
+	maxSizeStr   string
+	maxSize      int64
+}
+
+// hasRetentionPolicy returns true if any flags that remove modelkits according to a retention policy are set.
+func (opts *removeOptions) hasRetentionPolicy() bool {
+	return opts.keepLastSet || opts.olderThanStr != "" || opts.maxSizeStr != ""
 }
 
 func (opts *removeOptions) complete(ctx context.Context, args []string) error {
@@ -107,6 +157,33 @@ func (opts *removeOptions) complete(ctx context.Context, args []string) error {
 		return fmt.Errorf("cannot use --all with --remote")
 	}
 
+	if opts.includeHistory && !opts.removeAll && !opts.hasRetentionPolicy() {
+		return fmt.Errorf("--include-history can only be used with --all, --keep-last, --older-than, or --max-size")
+	}
+
+	if opts.hasRetentionPolicy() {
+		if opts.remote || opts.removeAll {
+			return fmt.Errorf("cannot use --keep-last, --older-than, or --max-size with --remote or --all")
+		}
+		if opts.keepLastSet && opts.keepLast < 1 {
+			return fmt.Errorf("invalid value for --keep-last: must be at least 1 (use --all --force to remove all modelkits)")
+		}
+		if opts.olderThanStr != "" {
+			olderThan, err := parseAge(opts.olderThanStr)
+			if err != nil {
+				return fmt.Errorf("invalid value for --older-than: %w", err)
+			}
+			opts.olderThan = olderThan
+		}
+		if opts.maxSizeStr != "" {
+			maxSize, err := output.ParseBytes(opts.maxSizeStr)
+			if err != nil {
+				return fmt.Errorf("invalid value for --max-size: %w", err)
+			}
+			opts.maxSize = maxSize
+		}
+	}
+
 	if err := opts.NetworkOptions.Complete(ctx, args); err != nil {
 		return err
 	}
@@ -124,7 +201,8 @@ func RemoveCommand() *cobra.Command {
 		Example: examples,
 		RunE:    runCommand(opts),
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			if cmd.Flags().Changed("all") || cmd.Flags().Changed("remote") {
+			opts.keepLastSet = cmd.Flags().Changed("keep-last")
+			if cmd.Flags().Changed("all") || cmd.Flags().Changed("remote") || opts.hasRetentionPolicy() {
 				return nil, cobra.ShellCompDirectiveNoFileComp
 			}
 			if len(args) >= 1 {
@@ -135,14 +213,19 @@ func RemoveCommand() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&opts.forceDelete, "force", "f", false, "remove modelkit and all other tags that refer to it")
 	cmd.Flags().BoolVarP(&opts.removeAll, "all", "a", false, "remove all untagged modelkits")
+	cmd.Flags().BoolVar(&opts.includeHistory, "include-history", false, "with --all or retention policies, also remove untagged modelkits that tags previously referred to")
 	cmd.Flags().BoolVarP(&opts.remote, "remote", "r", false, "remove modelkit from remote registry")
+	cmd.Flags().IntVar(&opts.keepLast, "keep-last", 0, "keep only the N most recently packed or pulled modelkits in each repository")
+	cmd.Flags().StringVar(&opts.olderThanStr, "older-than", "", "remove modelkits packed or pulled longer ago than this duration (e.g. 30d)")
+	cmd.Flags().StringVar(&opts.maxSizeStr, "max-size", "", "remove least recently used modelkits until local storage is at most this size (e.g. 200GB)")
 	opts.AddNetworkFlags(cmd)
 	cmd.Flags().SortFlags = false
 
 	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		opts.keepLastSet = cmd.Flags().Changed("keep-last")
 		switch len(args) {
 		case 0:
-			if opts.removeAll {
+			if opts.removeAll || opts.hasRetentionPolicy() {
 				return nil
 			}
 			return fmt.Errorf("modelkit is required for remove unless --all is specified")
@@ -150,6 +233,9 @@ func RemoveCommand() *cobra.Command {
 			if opts.removeAll {
 				return fmt.Errorf("modelkit should not be specified when --all flag is used")
 			}
+			if opts.hasRetentionPolicy() {
+				return fmt.Errorf("modelkit should not be specified when --keep-last, --older-than, or --max-size are used")
+			}
 			return nil
 		default:
 			return cobra.MaximumNArgs(1)(cmd, args)
@@ -167,6 +253,8 @@ func runCommand(opts *removeOptions) func(*cobra.Command, []string) error {
 
 		var err error
 		switch {
+		case opts.hasRetentionPolicy():
+			err = removeByRetentionPolicy(cmd.Context(), opts)
 		case opts.modelRef != nil:
 			if opts.remote {
 				err = removeRemoteModel(cmd.Context(), opts)
@@ -190,6 +278,9 @@ func printConfig(opts *removeOptions) {
 		displayRef := util.FormatRepositoryForDisplay(opts.modelRef.String())
 		output.Debugf("Removing %s and additional tags: [%s]", displayRef, strings.Join(opts.extraTags, ", "))
 	}
+	if opts.hasRetentionPolicy() {
+		output.Debugf("Removing modelkits by retention policy: keep last %d, older than %q, max size %q", opts.keepLast, opts.olderThanStr, opts.maxSizeStr)
+	}
 	if opts.removeAll {
 		if opts.forceDelete {
 			output.Debugf("Removing all locally-stored modelkits")
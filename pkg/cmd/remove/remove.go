@@ -18,6 +18,7 @@ package remove
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -50,14 +51,18 @@ func removeAllModels(ctx context.Context, opts *removeOptions) error {
 		// to delete it (by digest) again.
 		skipManifests := map[digest.Digest]bool{}
 		for _, manifestDesc := range models {
-			if skipManifests[manifestDesc.Digest] {
+			if skipManifests[manifestDesc.Digest] || localRepo.IsShared(manifestDesc) {
 				continue
 			}
 			tags := localRepo.GetTags(manifestDesc)
 			// First untag all manifests for this digest
 			for _, tag := range tags {
-				if err := localRepo.Untag(ctx, tag); err != nil {
-					output.Errorf("Failed to untag %s:%s: %w", repository, tag, err)
+				if err := localRepo.Untag(ctx, tag); errors.Is(err, local.ErrSharedStorage) {
+					output.Debugf("Skipping tag %s:%s in shared storage", repository, tag)
+					continue
+				} else if err != nil {
+					output.Errorf("Failed to untag %s:%s: %s", repository, tag, err)
+					continue
 				}
 				output.Infof("Untagged %s:%s", repository, tag)
 			}
@@ -74,21 +79,34 @@ func removeAllModels(ctx context.Context, opts *removeOptions) error {
 	return nil
 }
 
-// removeUntaggedModels removes all untagged modelkits from local storage
+// removeUntaggedModels removes all untagged modelkits from local storage. Unless opts.includeHistory is set,
+// modelkits that tags previously referred to are kept so that they can be restored using 'kit tag --restore'.
 func removeUntaggedModels(ctx context.Context, opts *removeOptions) error {
 	localRepos, err := local.GetAllLocalRepos(constants.StoragePath(opts.configHome))
 	if err != nil {
 		return fmt.Errorf("failed to read local storage: %w", err)
 	}
+	var keptPrevious []string
 	for _, localRepo := range localRepos {
 		manifests := localRepo.GetAllModels()
 		repo := util.FormatRepositoryForDisplay(localRepo.GetRepoName())
+		var previousTargets map[digest.Digest]bool
+		if !opts.includeHistory {
+			previousTargets = previousTagTargets(localRepo)
+		}
 		for _, manifestDesc := range manifests {
+			if localRepo.IsShared(manifestDesc) {
+				continue
+			}
 			tags := localRepo.GetTags(manifestDesc)
 			if len(tags) > 0 {
 				output.Debugf("Skipping %s (tags: %s)", manifestDesc.Digest, strings.Join(tags, ", "))
 				continue
 			}
+			if previousTargets[manifestDesc.Digest] {
+				keptPrevious = append(keptPrevious, fmt.Sprintf("%s@%s", repo, manifestDesc.Digest))
+				continue
+			}
 			if err := localRepo.Delete(ctx, manifestDesc); err != nil {
 				output.Errorf("Failed to remove %s@%s: %s", repo, manifestDesc.Digest, err)
 				continue
@@ -96,9 +114,41 @@ func removeUntaggedModels(ctx context.Context, opts *removeOptions) error {
 			output.Infof("Removed %s@%s", repo, manifestDesc.Digest)
 		}
 	}
+	printKeptPreviousTargets(keptPrevious)
 	return nil
 }
 
+// previousTagTargets returns the digests of modelkits that appear in the tag history for localRepo. Untagged
+// modelkits in this set are previous targets of tags, and can be restored using 'kit tag --restore'.
+func previousTagTargets(localRepo local.LocalRepo) map[digest.Digest]bool {
+	targets := map[digest.Digest]bool{}
+	history, err := localRepo.GetTagHistory("")
+	if err != nil {
+		output.Logf(output.LogLevelWarn, "Failed to read tag history for %s: %s", util.FormatRepositoryForDisplay(localRepo.GetRepoName()), err)
+		return targets
+	}
+	for _, entry := range history {
+		if entry.OldDigest != "" {
+			targets[entry.OldDigest] = true
+		}
+		if entry.NewDigest != "" {
+			targets[entry.NewDigest] = true
+		}
+	}
+	return targets
+}
+
+// printKeptPreviousTargets lists modelkits that were not removed because tags previously referred to them.
+func printKeptPreviousTargets(kept []string) {
+	if len(kept) == 0 {
+		return
+	}
+	output.Infof("Kept %d untagged modelkits that can be restored using 'kit tag --restore' (use --include-history to remove them):", len(kept))
+	for _, ref := range kept {
+		output.Infof("  %s", ref)
+	}
+}
+
 func removeModel(ctx context.Context, opts *removeOptions) error {
 	storageRoot := constants.StoragePath(opts.configHome)
 	localRepo, err := local.NewLocalRepo(storageRoot, opts.modelRef)
@@ -0,0 +1,221 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/local"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// retainedModelKit is a modelkit in local storage that retention policies are applied to.
+type retainedModelKit struct {
+	repo     local.LocalRepo
+	desc     ocispec.Descriptor
+	added    time.Time
+	lastUsed time.Time
+	// blobs contains the manifest, config, and layers of the modelkit
+	blobs []ocispec.Descriptor
+	// removeReason is set if the modelkit should be removed
+	removeReason string
+	// previousTarget is set if the modelkit is untagged but a tag previously referred to it. These modelkits
+	// are kept unless --include-history is used.
+	previousTarget bool
+}
+
+func (k *retainedModelKit) displayRef() string {
+	return fmt.Sprintf("%s@%s", util.FormatRepositoryForDisplay(k.repo.GetRepoName()), k.desc.Digest)
+}
+
+// removeByRetentionPolicy removes modelkits from local storage according to the --keep-last, --older-than, and
+// --max-size flags. Modelkits are removed along with all tags that refer to them. Unless opts.includeHistory is
+// set, untagged modelkits that tags previously referred to are kept.
+func removeByRetentionPolicy(ctx context.Context, opts *removeOptions) error {
+	kits, err := getRetainedModelKits(ctx, constants.StoragePath(opts.configHome), opts.includeHistory)
+	if err != nil {
+		return err
+	}
+
+	if opts.keepLast > 0 {
+		applyKeepLast(kits, opts.keepLast)
+	}
+	if opts.olderThanStr != "" {
+		applyOlderThan(kits, time.Now().Add(-opts.olderThan), opts.olderThanStr)
+	}
+	if opts.maxSizeStr != "" {
+		applyMaxSize(kits, opts.maxSize)
+	}
+
+	var removed int
+	var errs, keptPrevious []string
+	for _, kit := range kits {
+		if kit.removeReason == "" {
+			output.Debugf("Keeping %s", kit.displayRef())
+			continue
+		}
+		if kit.previousTarget {
+			keptPrevious = append(keptPrevious, kit.displayRef())
+			continue
+		}
+		if err := kit.repo.Delete(ctx, kit.desc); err != nil {
+			output.Errorf("Failed to remove %s: %s", kit.displayRef(), err)
+			errs = append(errs, kit.displayRef())
+			continue
+		}
+		output.Infof("Removed %s (%s)", kit.displayRef(), kit.removeReason)
+		removed++
+	}
+	output.Infof("Removed %d modelkits", removed)
+	printKeptPreviousTargets(keptPrevious)
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove modelkits: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// getRetainedModelKits returns the modelkits in local storage that retention policies apply to. If includeHistory is
+// false, untagged modelkits that tags previously referred to are marked as previous targets.
+func getRetainedModelKits(ctx context.Context, storageRoot string, includeHistory bool) ([]*retainedModelKit, error) {
+	localRepos, err := local.GetAllLocalRepos(storageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local storage: %w", err)
+	}
+	var kits []*retainedModelKit
+	for _, localRepo := range localRepos {
+		var previousTargets map[digest.Digest]bool
+		if !includeHistory {
+			previousTargets = previousTagTargets(localRepo)
+		}
+		for _, manifestDesc := range localRepo.GetAllModels() {
+			if localRepo.IsShared(manifestDesc) {
+				continue
+			}
+			kit := &retainedModelKit{
+				repo:  localRepo,
+				desc:  manifestDesc,
+				blobs: []ocispec.Descriptor{manifestDesc},
+			}
+			kit.previousTarget = previousTargets[manifestDesc.Digest] && len(localRepo.GetTags(manifestDesc)) == 0
+			kit.added, kit.lastUsed = localRepo.GetTimestamps(manifestDesc)
+			if manifest, err := util.GetManifest(ctx, localRepo, manifestDesc); err != nil {
+				output.Logf(output.LogLevelWarn, "Failed to read %s: %s", kit.displayRef(), err)
+			} else {
+				if manifest.Config.Data == nil {
+					kit.blobs = append(kit.blobs, manifest.Config)
+				}
+				kit.blobs = append(kit.blobs, manifest.Layers...)
+			}
+			kits = append(kits, kit)
+		}
+	}
+	return kits, nil
+}
+
+// applyKeepLast marks all but the keepLast most recently packed or pulled modelkits in each repository for removal.
+func applyKeepLast(kits []*retainedModelKit, keepLast int) {
+	byRepo := map[string][]*retainedModelKit{}
+	for _, kit := range kits {
+		byRepo[kit.repo.GetRepoName()] = append(byRepo[kit.repo.GetRepoName()], kit)
+	}
+	for _, repoKits := range byRepo {
+		slices.SortStableFunc(repoKits, func(a, b *retainedModelKit) int {
+			return b.added.Compare(a.added)
+		})
+		for _, kit := range repoKits[min(keepLast, len(repoKits)):] {
+			kit.removeReason = fmt.Sprintf("not in the last %d modelkits in repository", keepLast)
+		}
+	}
+}
+
+// applyOlderThan marks modelkits that were packed or pulled before cutoff for removal.
+func applyOlderThan(kits []*retainedModelKit, cutoff time.Time, age string) {
+	for _, kit := range kits {
+		if kit.removeReason == "" && kit.added.Before(cutoff) {
+			kit.removeReason = fmt.Sprintf("older than %s", age)
+		}
+	}
+}
+
+// applyMaxSize marks the least recently used modelkits for removal until the modelkits that remain use at most
+// maxSize bytes. Blobs shared between modelkits are counted once, and only contribute to the space reclaimed
+// once every modelkit that uses them is removed. Previous targets of tags are never removed, but count towards
+// the space used.
+func applyMaxSize(kits []*retainedModelKit, maxSize int64) {
+	refCounts := map[digest.Digest]int{}
+	var totalSize int64
+	var remaining []*retainedModelKit
+	for _, kit := range kits {
+		if kit.removeReason != "" && !kit.previousTarget {
+			continue
+		}
+		if !kit.previousTarget {
+			remaining = append(remaining, kit)
+		}
+		for _, blob := range kit.blobs {
+			if refCounts[blob.Digest] == 0 {
+				totalSize += blob.Size
+			}
+			refCounts[blob.Digest]++
+		}
+	}
+	slices.SortStableFunc(remaining, func(a, b *retainedModelKit) int {
+		return cmp.Or(a.lastUsed.Compare(b.lastUsed), a.added.Compare(b.added))
+	})
+	for _, kit := range remaining {
+		if totalSize <= maxSize {
+			break
+		}
+		for _, blob := range kit.blobs {
+			refCounts[blob.Digest]--
+			if refCounts[blob.Digest] == 0 {
+				totalSize -= blob.Size
+			}
+		}
+		kit.removeReason = fmt.Sprintf("least recently used, storage exceeds %s", output.FormatBytes(maxSize))
+	}
+}
+
+// parseAge parses a duration, additionally supporting days (e.g. "30d") and weeks (e.g. "2w") as units.
+func parseAge(s string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if numStr, ok := strings.CutSuffix(s, suffix); ok {
+			num, err := strconv.Atoi(numStr)
+			if err != nil || num < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(num) * unit, nil
+		}
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return duration, nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package save
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// archiveWriter writes an OCI image layout directly to a tar stream. It implements content.Storage so that
+// it can be used as the destination for oras.CopyGraph; blobs are written to the archive as they are pushed.
+// The index for the layout is written when the archive is closed.
+type archiveWriter struct {
+	mu      sync.Mutex
+	tw      *tar.Writer
+	written map[digest.Digest]bool
+	index   ocispec.Index
+}
+
+var _ content.Storage = (*archiveWriter)(nil)
+
+// archiveModTime is used as the modification time for all files in the archive, so that saving the same
+// modelkits produces the same archive.
+var archiveModTime = time.Unix(0, 0)
+
+func newArchiveWriter(w io.Writer) (*archiveWriter, error) {
+	archive := &archiveWriter{
+		tw:      tar.NewWriter(w),
+		written: map[digest.Digest]bool{},
+		index: ocispec.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageIndex,
+		},
+	}
+	layoutBytes, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		return nil, err
+	}
+	if err := archive.writeFile(ocispec.ImageLayoutFile, layoutBytes); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// Push writes the blob described by expected to the archive, verifying its digest.
+func (a *archiveWriter) Push(_ context.Context, expected ocispec.Descriptor, r io.Reader) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.written[expected.Digest] {
+		return nil
+	}
+	if err := expected.Digest.Validate(); err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     path.Join(ocispec.ImageBlobsDir, expected.Digest.Algorithm().String(), expected.Digest.Encoded()),
+		Size:     expected.Size,
+		Mode:     0644,
+		ModTime:  archiveModTime,
+	}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	verifier := expected.Digest.Verifier()
+	written, err := io.Copy(io.MultiWriter(a.tw, verifier), io.LimitReader(r, expected.Size))
+	if err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", expected.Digest, err)
+	}
+	if written != expected.Size || !verifier.Verified() {
+		return fmt.Errorf("blob %s does not match its digest", expected.Digest)
+	}
+	a.written[expected.Digest] = true
+	return nil
+}
+
+// Exists returns true if the blob described by target has already been written to the archive.
+func (a *archiveWriter) Exists(_ context.Context, target ocispec.Descriptor) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.written[target.Digest], nil
+}
+
+// Fetch is not supported, as the archive is written as a stream.
+func (a *archiveWriter) Fetch(_ context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("%s: %w", target.Digest, errdef.ErrUnsupported)
+}
+
+// addManifest records the manifest described by desc in the archive's index, under the name refName.
+func (a *archiveWriter) addManifest(desc ocispec.Descriptor, refName string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, existing := range a.index.Manifests {
+		if existing.Digest == desc.Digest && existing.Annotations[ocispec.AnnotationRefName] == refName {
+			return
+		}
+	}
+	desc.Annotations = map[string]string{ocispec.AnnotationRefName: refName}
+	desc.Data = nil
+	a.index.Manifests = append(a.index.Manifests, desc)
+}
+
+// refCount returns the number of references in the archive's index.
+func (a *archiveWriter) refCount() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.index.Manifests)
+}
+
+// Close writes the index for the archive and flushes the tar stream. It does not close the underlying writer.
+func (a *archiveWriter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	indexBytes, err := json.Marshal(a.index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index: %w", err)
+	}
+	if err := a.writeFile(ocispec.ImageIndexFile, indexBytes); err != nil {
+		return err
+	}
+	return a.tw.Close()
+}
+
+func (a *archiveWriter) writeFile(name string, data []byte) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(len(data)),
+		Mode:     0644,
+		ModTime:  archiveModTime,
+	}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := a.tw.Write(data)
+	return err
+}
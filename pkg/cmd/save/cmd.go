@@ -0,0 +1,126 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package save
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kitops-ml/kitops/pkg/lib/completion"
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/registry"
+)
+
+const (
+	shortDesc = `Export modelkits from local storage to an archive`
+	longDesc  = `Export one or more modelkits from local storage to a tar archive in the OCI
+image layout format.
+
+Modelkits that reference another modelkit in their Kitfile (e.g. a model path of
+myrepo/base-model:v1) are saved along with the modelkits they reference, which must
+also be present in local storage. Each modelkit is stored in the archive under its
+reference, so that tags are restored when the archive is imported using
+'kit load'.
+
+Archives can be used to transfer modelkits to environments that cannot access a
+registry.`
+
+	example = `# Save a modelkit to an archive
+kit save mymodel:latest -o mymodel.tar
+
+# Save multiple modelkits to a single archive
+kit save mymodel:v1 mymodel:v2 otherrepo/dataset:latest -o modelkits.tar`
+)
+
+type saveOptions struct {
+	configHome string
+	modelRefs  []*registry.Reference
+	outputPath string
+}
+
+func (opts *saveOptions) complete(ctx context.Context, args []string) error {
+	configHome, ok := ctx.Value(constants.ConfigKey{}).(string)
+	if !ok {
+		return fmt.Errorf("default config path not set on command context")
+	}
+	opts.configHome = configHome
+
+	for _, arg := range args {
+		modelRef, extraTags, err := util.ParseReference(arg)
+		if err != nil {
+			return fmt.Errorf("failed to parse reference %s: %w", arg, err)
+		}
+		if len(extraTags) > 0 {
+			return fmt.Errorf("reference %s cannot include multiple tags", arg)
+		}
+		if modelRef.Reference == "" {
+			output.Infof("No tag specified for %s. Using 'latest' as default ('%s:latest')", arg, arg)
+			modelRef.Reference = "latest"
+		}
+		opts.modelRefs = append(opts.modelRefs, modelRef)
+	}
+
+	if opts.outputPath == "" {
+		return fmt.Errorf("output path is required")
+	}
+	absPath, err := filepath.Abs(opts.outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve absolute path %s: %w", opts.outputPath, err)
+	}
+	opts.outputPath = absPath
+	return nil
+}
+
+func SaveCommand() *cobra.Command {
+	opts := &saveOptions{}
+	cmd := &cobra.Command{
+		Use:     "save [flags] MODELKIT [MODELKIT...]",
+		Short:   shortDesc,
+		Long:    longDesc,
+		Example: example,
+		RunE:    runCommand(opts),
+		Args:    cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completion.GetLocalModelKitsCompletion(cmd.Context(), toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.outputPath, "output", "o", "", "Path to write the archive to (required)")
+	cmd.Flags().SortFlags = false
+
+	return cmd
+}
+
+func runCommand(opts *saveOptions) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := opts.complete(cmd.Context(), args); err != nil {
+			return output.Fatalf("Invalid arguments: %s", err)
+		}
+
+		saved, err := runSave(cmd.Context(), opts)
+		if err != nil {
+			return output.Fatalln(err)
+		}
+		output.Infof("Saved %d modelkits to %s", saved, opts.outputPath)
+		return nil
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package save
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/local"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+)
+
+// runSave writes the modelkits in opts, along with any modelkits they reference, to an archive at
+// opts.outputPath. The archive is written to a temporary file first so that a partial archive is not
+// left behind if saving fails. It returns the number of modelkits saved.
+func runSave(ctx context.Context, opts *saveOptions) (int, error) {
+	storageRoot := constants.StoragePath(opts.configHome)
+	tmpFile, err := os.CreateTemp(filepath.Dir(opts.outputPath), ".kit-save-*.tar")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer func() {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}()
+
+	archive, err := newArchiveWriter(tmpFile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	for _, modelRef := range opts.modelRefs {
+		if err := saveRecursive(ctx, storageRoot, archive, modelRef, []string{}); err != nil {
+			return 0, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), opts.outputPath); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return archive.refCount(), nil
+}
+
+// saveRecursive copies the modelkit referenced by ref from local storage to archive, and then does the
+// same for the modelkit it references in its Kitfile, if any.
+func saveRecursive(ctx context.Context, storageRoot string, archive *archiveWriter, ref *registry.Reference, savedRefs []string) error {
+	refStr := util.FormatRepositoryForDisplay(ref.String())
+	if idx := getIndex(savedRefs, refStr); idx != -1 {
+		cycleStr := fmt.Sprintf("[%s=>%s]", strings.Join(savedRefs[idx:], "=>"), refStr)
+		return fmt.Errorf("found cycle in modelkit references: %s", cycleStr)
+	}
+	savedRefs = append(savedRefs, refStr)
+	if len(savedRefs) > constants.MaxModelRefChain {
+		return fmt.Errorf("reached maximum number of model references: [%s]", strings.Join(savedRefs, "=>"))
+	}
+
+	localRepo, err := local.NewLocalRepo(storageRoot, ref)
+	if err != nil {
+		return fmt.Errorf("failed to read local storage: %w", err)
+	}
+	desc, _, config, err := util.ResolveManifestAndConfig(ctx, localRepo, ref.Reference)
+	if err != nil && !errors.Is(err, util.ErrNoKitfile) {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return fmt.Errorf("modelkit %s not found in local storage", refStr)
+		}
+		return fmt.Errorf("failed to read modelkit %s: %w", refStr, err)
+	}
+	if err := oras.CopyGraph(ctx, localRepo, archive, desc, oras.DefaultCopyGraphOptions); err != nil {
+		return fmt.Errorf("failed to save %s: %w", refStr, err)
+	}
+	archive.addManifest(desc, refStr)
+	output.Infof("Saved %s (%s)", refStr, desc.Digest)
+
+	if config == nil || config.Model == nil || !util.IsModelKitReference(config.Model.Path) {
+		return nil
+	}
+	output.Infof("Saving referenced modelkit %s", config.Model.Path)
+	parentRef, _, err := util.ParseReference(config.Model.Path)
+	if err != nil {
+		return err
+	}
+	return saveRecursive(ctx, storageRoot, archive, parentRef, savedRefs)
+}
+
+func getIndex(list []string, s string) int {
+	for idx, item := range list {
+		if s == item {
+			return idx
+		}
+	}
+	return -1
+}
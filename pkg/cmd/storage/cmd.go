@@ -0,0 +1,52 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func StorageCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "storage",
+		Short: `Manage local modelkit storage`,
+		Long: `Manage modelkits and blobs stored on the local disk ($KITOPS_HOME/storage)
+
+The $KITOPS_HOME location is system dependent:
+	- Linux: $XDG_DATA_HOME/kitops with a fall back to $HOME/.local/share/kitops
+	- MacOS: ~/Library/Caches/kitops
+	- Windows: %LOCALAPPDATA%\kitops
+
+Additional storage directories can be listed in $KITOPS_SHARED_STORAGE (separated
+by ':' on Linux and MacOS, or ';' on Windows), for example to use a read-only
+store on a network filesystem that is maintained by an administrator. Modelkits in
+shared storage can be listed, inspected, unpacked, and pushed as if they were in
+local storage, and packing, pulling, and tagging reuse their blobs rather than
+storing them again. Kit only ever writes to $KITOPS_HOME/storage; modelkits and
+tags in shared storage cannot be removed.
+`,
+		Example: `# Check local storage for corrupt or missing data
+kit storage verify
+
+# Show disk usage, accounting for blobs shared between modelkits
+kit storage df`,
+	}
+	cmd.AddCommand(verifyCommand())
+	cmd.AddCommand(dfCommand())
+
+	return cmd
+}
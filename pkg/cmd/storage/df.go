@@ -0,0 +1,183 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	dfShortDesc = `Show disk usage of local storage`
+	dfLongDesc  = `Show how much disk space is used by modelkits in local storage.
+
+Modelkits frequently share blobs: the same layer may be included in several tags
+or repositories, and is only stored on disk once. For each repository and each
+modelkit, the size is split into unique bytes, which are only used by that
+repository or modelkit and would be freed by removing it, and shared bytes, which
+are also used elsewhere. The total on disk counts each blob once, and includes
+unreferenced blobs that can be removed using 'kit prune'.
+
+Use --blob to list the modelkits that refer to a specific blob, e.g. to find out
+why a large layer remains in local storage.
+
+Use the --format flag to change how results are printed. Valid values are
+'table' (the default) and 'json'.`
+
+	dfExample = `# Show disk usage for all modelkits in local storage
+kit storage df
+
+# Show which modelkits use a blob
+kit storage df --blob sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a
+
+# Print disk usage as JSON
+kit storage df --format json`
+
+	dfRepoTableHeader     = "REPOSITORY\tMODELKITS\tUNIQUE\tSHARED\tTOTAL"
+	dfModelKitTableHeader = "REPOSITORY\tTAGS\tDIGEST\tUNIQUE\tSHARED\tTOTAL"
+	dfBlobTableHeader     = "REPOSITORY\tTAGS\tDIGEST"
+)
+
+type dfOptions struct {
+	configHome string
+	blob       string
+	format     string
+}
+
+func (opts *dfOptions) complete(ctx context.Context) error {
+	configHome, ok := ctx.Value(constants.ConfigKey{}).(string)
+	if !ok {
+		return fmt.Errorf("default config path not set on command context")
+	}
+	opts.configHome = configHome
+	switch opts.format {
+	case "table", "json":
+		// valid format
+	default:
+		return fmt.Errorf("invalid format %q: must be one of table or json", opts.format)
+	}
+	return nil
+}
+
+func dfCommand() *cobra.Command {
+	opts := &dfOptions{}
+	cmd := &cobra.Command{
+		Use:     "df [flags]",
+		Short:   dfShortDesc,
+		Long:    dfLongDesc,
+		Example: dfExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.complete(cmd.Context()); err != nil {
+				return output.Fatalf("Invalid arguments: %s", err)
+			}
+			usage, err := getDiskUsage(cmd.Context(), constants.StoragePath(opts.configHome))
+			if err != nil {
+				return output.Fatalf("Failed to compute disk usage: %s", err)
+			}
+			if opts.blob != "" {
+				blob, err := usage.findBlob(opts.blob)
+				if err != nil {
+					return output.Fatalln(err)
+				}
+				if err := printBlobUsage(cmd.OutOrStdout(), blob, opts.format); err != nil {
+					return output.Fatalln(err)
+				}
+				return nil
+			}
+			if err := printDiskUsage(cmd.OutOrStdout(), usage, opts.format); err != nil {
+				return output.Fatalln(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.blob, "blob", "", "List modelkits that use the blob with this digest")
+	cmd.Flags().StringVar(&opts.format, "format", "table", "Output format: table or json")
+	cmd.Flags().SortFlags = false
+
+	return cmd
+}
+
+func printDiskUsage(w io.Writer, usage *diskUsage, format string) error {
+	if format == "json" {
+		return printJSON(w, usage)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 2, 3, ' ', 0)
+	fmt.Fprintln(tw, dfRepoTableHeader)
+	for _, repo := range usage.Repositories {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", repo.Repo, repo.ModelKits,
+			output.FormatBytes(repo.UniqueSize), output.FormatBytes(repo.SharedSize), output.FormatBytes(repo.TotalSize))
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 2, 3, ' ', 0)
+	fmt.Fprintln(tw, dfModelKitTableHeader)
+	for _, kit := range usage.ModelKits {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", kit.Repo, formatTags(kit.Tags), kit.Digest,
+			output.FormatBytes(kit.UniqueSize), output.FormatBytes(kit.SharedSize), output.FormatBytes(kit.TotalSize))
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "Total on disk: %s in %d blobs (%s shared between modelkits)\n",
+		output.FormatBytes(usage.TotalSize), usage.BlobCount, output.FormatBytes(usage.SharedSize))
+	if usage.UnreferencedSize > 0 {
+		fmt.Fprintf(w, "Unreferenced: %s (run 'kit prune' to reclaim)\n", output.FormatBytes(usage.UnreferencedSize))
+	}
+	return nil
+}
+
+func printBlobUsage(w io.Writer, blob *blobUsage, format string) error {
+	if format == "json" {
+		return printJSON(w, blob)
+	}
+	fmt.Fprintf(w, "Blob %s (%s) is used by %d modelkits\n\n", blob.Digest, output.FormatBytes(blob.Size), len(blob.ModelKits))
+	tw := tabwriter.NewWriter(w, 0, 2, 3, ' ', 0)
+	fmt.Fprintln(tw, dfBlobTableHeader)
+	for _, kit := range blob.ModelKits {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", kit.Repo, formatTags(kit.Tags), kit.Digest)
+	}
+	tw.Flush()
+	return nil
+}
+
+func printJSON(w io.Writer, v any) error {
+	jsonBytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(jsonBytes))
+	return nil
+}
+
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "<none>"
+	}
+	return strings.Join(tags, ",")
+}
@@ -0,0 +1,267 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kitops-ml/kitops/pkg/lib/repo/local"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// diskUsage describes how blobs in local storage are used by modelkits. Blobs are only counted once, no
+// matter how many modelkits refer to them.
+type diskUsage struct {
+	Repositories []*repoUsage     `json:"repositories"`
+	ModelKits    []*modelKitUsage `json:"modelkits"`
+	// TotalSize is the size of all blobs in local storage
+	TotalSize int64 `json:"totalSize"`
+	// SharedSize is the size of blobs that are referred to by more than one modelkit
+	SharedSize int64 `json:"sharedSize"`
+	// UnreferencedSize is the size of blobs that are not reachable from any manifest in local storage, which can be
+	// removed with 'kit prune'
+	UnreferencedSize int64 `json:"unreferencedSize"`
+	BlobCount        int   `json:"blobCount"`
+
+	blobs map[digest.Digest]*blobUsage
+}
+
+type repoUsage struct {
+	Repo      string `json:"repo"`
+	ModelKits int    `json:"modelkits"`
+	sizes
+}
+
+type modelKitUsage struct {
+	Repo   string        `json:"repo"`
+	Digest digest.Digest `json:"digest"`
+	Tags   []string      `json:"tags"`
+	sizes
+}
+
+// sizes records the disk usage of a modelkit or repository. UniqueSize is the size of blobs that are only
+// referred to by the modelkit (or repository), i.e. the space that would be freed by removing it.
+type sizes struct {
+	UniqueSize int64 `json:"uniqueSize"`
+	SharedSize int64 `json:"sharedSize"`
+	TotalSize  int64 `json:"totalSize"`
+}
+
+func (s *sizes) add(size int64, shared bool) {
+	if shared {
+		s.SharedSize += size
+	} else {
+		s.UniqueSize += size
+	}
+	s.TotalSize += size
+}
+
+type blobUsage struct {
+	Digest    digest.Digest    `json:"digest"`
+	Size      int64            `json:"size"`
+	ModelKits []*modelKitUsage `json:"modelkits"`
+}
+
+func (b *blobUsage) repos() []string {
+	var repos []string
+	for _, kit := range b.ModelKits {
+		if !slices.Contains(repos, kit.Repo) {
+			repos = append(repos, kit.Repo)
+		}
+	}
+	return repos
+}
+
+// displayRef returns a reference for the modelkit that is suitable for displaying to the user.
+func (m *modelKitUsage) displayRef() string {
+	if len(m.Tags) == 0 {
+		return fmt.Sprintf("%s@%s", m.Repo, m.Digest)
+	}
+	return fmt.Sprintf("%s:%s", m.Repo, strings.Join(m.Tags, ","))
+}
+
+// getDiskUsage computes the disk usage of every modelkit and repository in local storage at storageRoot.
+func getDiskUsage(ctx context.Context, storageRoot string) (*diskUsage, error) {
+	usage := &diskUsage{
+		Repositories: []*repoUsage{},
+		ModelKits:    []*modelKitUsage{},
+		blobs:        map[digest.Digest]*blobUsage{},
+	}
+	localRepos, err := local.GetAllLocalRepos(storageRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range localRepos {
+		repoName := util.FormatRepositoryForDisplay(repo.GetRepoName())
+		for _, manifestDesc := range repo.GetAllModels() {
+			if repo.IsShared(manifestDesc) {
+				// Modelkits in shared storage do not use space in local storage
+				continue
+			}
+			kit := &modelKitUsage{
+				Repo:   repoName,
+				Digest: manifestDesc.Digest,
+				Tags:   repo.GetTags(manifestDesc),
+			}
+			if kit.Tags == nil {
+				kit.Tags = []string{}
+			}
+			blobDescs := []ocispec.Descriptor{manifestDesc}
+			if manifest, err := util.GetManifest(ctx, repo, manifestDesc); err != nil {
+				output.Logf(output.LogLevelWarn, "Failed to read modelkit %s: %s", kit.displayRef(), err)
+			} else {
+				blobDescs = append(blobDescs, manifest.Config)
+				blobDescs = append(blobDescs, manifest.Layers...)
+			}
+			for _, desc := range blobDescs {
+				if err := usage.addBlob(repo, desc, kit); err != nil {
+					return nil, err
+				}
+			}
+			usage.ModelKits = append(usage.ModelKits, kit)
+		}
+	}
+
+	repos := map[string]*repoUsage{}
+	for _, blob := range usage.blobs {
+		shared := len(blob.ModelKits) > 1
+		if shared {
+			usage.SharedSize += blob.Size
+		}
+		for _, kit := range blob.ModelKits {
+			kit.add(blob.Size, shared)
+		}
+		blobRepos := blob.repos()
+		for _, repoName := range blobRepos {
+			if repos[repoName] == nil {
+				repos[repoName] = &repoUsage{Repo: repoName}
+			}
+			repos[repoName].add(blob.Size, len(blobRepos) > 1)
+		}
+	}
+	for _, kit := range usage.ModelKits {
+		if repos[kit.Repo] == nil {
+			repos[kit.Repo] = &repoUsage{Repo: kit.Repo}
+		}
+		repos[kit.Repo].ModelKits++
+	}
+	for _, repo := range repos {
+		usage.Repositories = append(usage.Repositories, repo)
+	}
+	slices.SortFunc(usage.Repositories, func(a, b *repoUsage) int {
+		return strings.Compare(a.Repo, b.Repo)
+	})
+	slices.SortStableFunc(usage.ModelKits, func(a, b *modelKitUsage) int {
+		if c := strings.Compare(a.Repo, b.Repo); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.TotalSize, a.TotalSize)
+	})
+
+	if err := usage.addUnreferenced(ctx, storageRoot); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// addBlob records that the blob described by desc is used by kit. Blobs that are not present in local storage are
+// skipped, as they do not use any disk space.
+func (u *diskUsage) addBlob(repo local.LocalRepo, desc ocispec.Descriptor, kit *modelKitUsage) error {
+	if desc.Data != nil {
+		// Content is embedded in the manifest
+		return nil
+	}
+	blob, ok := u.blobs[desc.Digest]
+	if !ok {
+		fi, err := os.Stat(repo.BlobPath(desc))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read blob %s: %w", desc.Digest, err)
+		}
+		blob = &blobUsage{Digest: desc.Digest, Size: fi.Size()}
+		u.blobs[desc.Digest] = blob
+		u.TotalSize += fi.Size()
+		u.BlobCount++
+	}
+	if !slices.Contains(blob.ModelKits, kit) {
+		blob.ModelKits = append(blob.ModelKits, kit)
+	}
+	return nil
+}
+
+// addUnreferenced adds blobs in local storage that are not referred to by any modelkit to the total. Blobs that
+// are only reachable from manifests recorded in the shared index.json are counted in the total but are not
+// unreferenced, matching the blobs that 'kit prune' removes.
+func (u *diskUsage) addUnreferenced(ctx context.Context, storageRoot string) error {
+	reachable, err := local.FindReachableBlobs(ctx, storageRoot)
+	if err != nil {
+		return err
+	}
+	blobsDir := filepath.Join(storageRoot, ocispec.ImageBlobsDir)
+	return filepath.WalkDir(blobsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		dgst := digest.NewDigestFromEncoded(digest.Algorithm(filepath.Base(filepath.Dir(path))), d.Name())
+		if _, ok := u.blobs[dgst]; ok {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("failed to read blob %s: %w", path, err)
+		}
+		if !reachable[dgst] {
+			u.UnreferencedSize += fi.Size()
+		}
+		u.TotalSize += fi.Size()
+		u.BlobCount++
+		return nil
+	})
+}
+
+// findBlob returns the usage for the blob matching dgst, which may be a full digest or an encoded digest
+// without an algorithm.
+func (u *diskUsage) findBlob(dgst string) (*blobUsage, error) {
+	for blobDigest, blob := range u.blobs {
+		if blobDigest.String() == dgst || blobDigest.Encoded() == dgst {
+			return blob, nil
+		}
+	}
+	return nil, fmt.Errorf("blob %s is not referenced by any modelkit in local storage", dgst)
+}
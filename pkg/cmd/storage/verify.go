@@ -0,0 +1,196 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kitops-ml/kitops/pkg/cmd/options"
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/local"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/remote"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+const (
+	verifyShortDesc = `Check local storage for corrupt or missing data`
+	verifyLongDesc  = `Check the integrity of all modelkits in local storage.
+
+Every blob in local storage is read and checked against its digest. Each modelkit's
+manifest is checked to ensure its config and layers are present, and the index and
+tag files for each repository, as well as the shared index.json, are checked for
+references to manifests that do not exist.
+
+By default, problems are only reported. If --quarantine is used, corrupt blobs
+are moved out of local storage into $KITOPS_HOME/storage/quarantine. If --repull
+is used, corrupt blobs are quarantined and then, along with any missing blobs,
+fetched again from the registry the modelkit was pulled from. Modelkits that were
+packed locally cannot be repaired this way.
+
+The command exits with an error if any problems remain.`
+
+	verifyExample = `# Check local storage
+kit storage verify
+
+# Move corrupt blobs out of local storage
+kit storage verify --quarantine
+
+# Replace corrupt and missing blobs with copies from their source registry
+kit storage verify --repull`
+)
+
+type verifyOptions struct {
+	options.NetworkOptions
+	configHome string
+	quarantine bool
+	repull     bool
+}
+
+func (opts *verifyOptions) complete(ctx context.Context, args []string) error {
+	configHome, ok := ctx.Value(constants.ConfigKey{}).(string)
+	if !ok {
+		return fmt.Errorf("default config path not set on command context")
+	}
+	opts.configHome = configHome
+	if opts.repull {
+		opts.quarantine = true
+	}
+	return opts.NetworkOptions.Complete(ctx, args)
+}
+
+func verifyCommand() *cobra.Command {
+	opts := &verifyOptions{}
+	cmd := &cobra.Command{
+		Use:     "verify [flags]",
+		Short:   verifyShortDesc,
+		Long:    verifyLongDesc,
+		Example: verifyExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.complete(cmd.Context(), args); err != nil {
+				return output.Fatalf("Invalid arguments: %s", err)
+			}
+			if err := verifyStorage(cmd.Context(), opts); err != nil {
+				return output.Fatalln(err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&opts.quarantine, "quarantine", false, "Move corrupt blobs out of local storage")
+	cmd.Flags().BoolVar(&opts.repull, "repull", false, "Quarantine corrupt blobs and fetch corrupt and missing blobs from their source registry")
+	opts.AddNetworkFlags(cmd)
+	cmd.Flags().SortFlags = false
+
+	return cmd
+}
+
+// verifyStorage checks local storage, reporting any problems found and repairing them if requested.
+func verifyStorage(ctx context.Context, opts *verifyOptions) error {
+	storagePath := constants.StoragePath(opts.configHome)
+	report, err := local.CheckStorage(ctx, storagePath)
+	if err != nil {
+		return err
+	}
+	for _, dgst := range report.Corrupt {
+		output.Errorf("Blob %s is corrupt (contents do not match digest)", dgst)
+	}
+	for _, dgst := range report.Missing {
+		output.Errorf("Blob %s is missing from local storage", dgst)
+	}
+	for _, problem := range report.Problems {
+		output.Errorf("Index problem: %s", problem)
+	}
+	if report.OK() {
+		output.Infof("Verified %d blobs, no problems found", report.BlobsChecked)
+		return nil
+	}
+
+	unresolved := len(report.Corrupt) + len(report.Missing) + len(report.Problems)
+	if opts.quarantine {
+		var toFetch []digest.Digest
+		for _, dgst := range report.Corrupt {
+			if err := local.QuarantineBlob(storagePath, dgst); err != nil {
+				return err
+			}
+			output.Infof("Quarantined blob %s", dgst)
+			toFetch = append(toFetch, dgst)
+		}
+		if !opts.repull {
+			// Quarantined blobs that are still referenced by a modelkit are now missing
+			for _, dgst := range report.Corrupt {
+				if len(report.References[dgst]) == 0 {
+					unresolved--
+				}
+			}
+		} else {
+			toFetch = append(toFetch, report.Missing...)
+			for _, dgst := range toFetch {
+				if err := repullBlob(ctx, storagePath, report.References[dgst], opts); err != nil {
+					output.Errorf("Failed to re-pull blob %s: %s", dgst, err)
+					continue
+				}
+				unresolved--
+			}
+		}
+	}
+
+	if unresolved > 0 {
+		return fmt.Errorf("found %d problems in local storage (verified %d blobs)", unresolved, report.BlobsChecked)
+	}
+	output.Infof("Verified %d blobs, all problems repaired", report.BlobsChecked)
+	return nil
+}
+
+// repullBlob fetches a blob from the source registry of one of the local repositories that refer to it. If the
+// blob is a manifest, any of its config and layers that are not in local storage are fetched as well.
+func repullBlob(ctx context.Context, storagePath string, refs []local.BlobReference, opts *verifyOptions) error {
+	storage, err := oci.NewStorage(storagePath)
+	if err != nil {
+		return fmt.Errorf("failed to read local storage: %w", err)
+	}
+	var errs []error
+	for _, ref := range refs {
+		registry, repository, ok := strings.Cut(ref.Repo, "/")
+		if !ok || registry == util.DefaultRegistry {
+			continue
+		}
+		repo, err := remote.NewRepository(ctx, registry, repository, &opts.NetworkOptions)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read repository %s: %w", ref.Repo, err))
+			continue
+		}
+		if err := oras.CopyGraph(ctx, repo, storage, ref.Desc, oras.DefaultCopyGraphOptions); err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch from %s: %w", ref.Repo, err))
+			continue
+		}
+		output.Infof("Re-pulled blob %s from %s", ref.Desc.Digest, ref.Repo)
+		return nil
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("no remote source is known for this blob")
+	}
+	return errors.Join(errs...)
+}
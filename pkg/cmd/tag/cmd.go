@@ -64,15 +64,42 @@ A full modelkit reference has the following format:
 Tagging is a powerful way to manage different versions or configurations of
 your modelkits, making it easier to organize, retrieve, and deploy specific
 iterations. Ensure tags are meaningful and consistent across your team or
-organization to maintain clarity and avoid confusion.`
+organization to maintain clarity and avoid confusion.
 
-	example = `kit tag myregistry.com/myrepo/mykit:latest myregistry.com/myrepo/mykit:v1.0.0`
+Every change to a tag in local storage, whether made by tag, pack, pull, load,
+or remove, is recorded in the repository's tag history along with the command
+that made it. Use --history to view the history of a tag (or of all tags in a
+repository, if no tag is specified), and --restore to point a tag back at the
+modelkit it referred to before its most recent change. To restore a different
+point in the tag's history, pass --to with either a digest the tag has referred
+to or the index of a change shown by --history; the tag is pointed at the
+modelkit it referred to after that change.
+
+A previous target can only be restored while it is still in local storage.
+'kit remove --all' and retention policies keep untagged modelkits that appear
+in tag history unless --include-history is used, but removing a modelkit by
+tag or digest, or with --force, means it can no longer be restored.`
+
+	example = `# Tag a modelkit
+kit tag myregistry.com/myrepo/mykit:latest myregistry.com/myrepo/mykit:v1.0.0
+
+# Show the modelkits a tag has referred to
+kit tag --history myregistry.com/myrepo/mykit:latest
+
+# Undo the most recent change to a tag
+kit tag --restore myregistry.com/myrepo/mykit:latest
+
+# Point a tag at the modelkit it referred to after the third most recent change
+kit tag --restore myregistry.com/myrepo/mykit:latest --to 3`
 )
 
 type tagOptions struct {
 	configHome string
 	sourceRef  *registry.Reference
 	targetRef  *registry.Reference
+	history    bool
+	restore    bool
+	restoreTo  string
 }
 
 func (opts *tagOptions) complete(ctx context.Context, args []string) error {
@@ -82,6 +109,27 @@ func (opts *tagOptions) complete(ctx context.Context, args []string) error {
 		return fmt.Errorf("default config path not set on command context")
 	}
 	opts.configHome = configHome
+	if opts.history && opts.restore {
+		return fmt.Errorf("--history and --restore cannot be used together")
+	}
+	if opts.restoreTo != "" && !opts.restore {
+		return fmt.Errorf("--to requires --restore")
+	}
+	if opts.history || opts.restore {
+		modelRef, _, err := util.ParseReference(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to parse reference: %w", err)
+		}
+		if opts.restore && modelRef.Reference == "" {
+			return fmt.Errorf("a tag is required to restore (%s:<tag>)", args[0])
+		}
+		if modelRef.ValidateReferenceAsDigest() == nil {
+			return fmt.Errorf("tag history requires a tag, not a digest")
+		}
+		opts.targetRef = modelRef
+		return nil
+	}
+
 	modelRef, _, err := util.ParseReference(args[0])
 	if err != nil {
 		return fmt.Errorf("failed to parse reference: %w", err)
@@ -103,13 +151,13 @@ func (opts *tagOptions) complete(ctx context.Context, args []string) error {
 }
 
 func TagCommand() *cobra.Command {
-
+	opts := &tagOptions{}
 	cmd := &cobra.Command{
 		Use:     "tag SOURCE_MODELKIT[:TAG] TARGET_MODELKIT[:TAG]",
 		Short:   shortDesc,
 		Long:    longDesc,
 		Example: example,
-		RunE:    runCommand(&tagOptions{}),
+		RunE:    runCommand(opts),
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			if len(args) >= 1 {
 				return nil, cobra.ShellCompDirectiveNoFileComp
@@ -118,7 +166,17 @@ func TagCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Args = cobra.ExactArgs(2)
+	cmd.Flags().BoolVar(&opts.history, "history", false, "show the history of changes to a tag")
+	cmd.Flags().BoolVar(&opts.restore, "restore", false, "point a tag at the modelkit it referred to before its most recent change")
+	cmd.Flags().StringVar(&opts.restoreTo, "to", "", "with --restore, the digest or history index (as shown by --history) to restore the tag to")
+	cmd.Flags().SortFlags = false
+
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if opts.history || opts.restore {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	}
 	return cmd
 }
 
@@ -128,6 +186,19 @@ func runCommand(opts *tagOptions) func(cmd *cobra.Command, args []string) error
 			return output.Fatalf("Invalid arguments: %s", err)
 		}
 
+		switch {
+		case opts.history:
+			if err := printTagHistory(cmd.Context(), cmd.OutOrStdout(), opts); err != nil {
+				return output.Fatalf("Failed to read tag history: %s", err)
+			}
+			return nil
+		case opts.restore:
+			if err := restoreTag(cmd.Context(), opts); err != nil {
+				return output.Fatalf("Failed to restore tag: %s", err)
+			}
+			return nil
+		}
+
 		err := RunTag(cmd.Context(), opts)
 		if err != nil {
 			return output.Fatalf("Failed to tag modelkit: %s", err)
@@ -0,0 +1,139 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/local"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/opencontainers/go-digest"
+	"oras.land/oras-go/v2/errdef"
+)
+
+const historyTableHeader = "INDEX\tTIME\tTAG\tPREVIOUS\tNEW\tCOMMAND"
+
+// printTagHistory writes the history for the tag in opts.targetRef to w, most recent change first. Each
+// change is listed with its index in the history of its tag, starting from 1 for the most recent change.
+func printTagHistory(ctx context.Context, w io.Writer, opts *tagOptions) error {
+	localRepo, err := local.NewLocalRepo(constants.StoragePath(opts.configHome), opts.targetRef)
+	if err != nil {
+		return fmt.Errorf("failed to open local storage: %w", err)
+	}
+	history, err := localRepo.GetTagHistory(opts.targetRef.Reference)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		output.Infof("No tag history for %s", util.FormatRepositoryForDisplay(opts.targetRef.String()))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 2, 3, ' ', 0)
+	fmt.Fprintln(tw, historyTableHeader)
+	tagIndexes := map[string]int{}
+	for _, entry := range slices.Backward(history) {
+		tagIndexes[entry.Tag]++
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", tagIndexes[entry.Tag], entry.Time.Local().Format(time.DateTime), entry.Tag,
+			formatDigest(entry.OldDigest), formatDigest(entry.NewDigest), entry.Command)
+	}
+	return tw.Flush()
+}
+
+// restoreTag points the tag in opts.targetRef at a modelkit from its history: the modelkit selected by
+// opts.restoreTo if set, or the modelkit it referred to before its most recent change otherwise.
+func restoreTag(ctx context.Context, opts *tagOptions) error {
+	localRepo, err := local.NewLocalRepo(constants.StoragePath(opts.configHome), opts.targetRef)
+	if err != nil {
+		return fmt.Errorf("failed to open local storage: %w", err)
+	}
+	displayRef := util.FormatRepositoryForDisplay(opts.targetRef.String())
+	history, err := localRepo.GetTagHistory(opts.targetRef.Reference)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		return fmt.Errorf("no tag history for %s", displayRef)
+	}
+	target, err := restoreTarget(history, opts.restoreTo)
+	if err != nil {
+		return fmt.Errorf("%s: %w", displayRef, err)
+	}
+	desc, err := localRepo.Resolve(ctx, target.String())
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return fmt.Errorf("previous target %s is no longer in local storage (it may have been removed by 'kit remove')", target)
+		}
+		return fmt.Errorf("error resolving previous target: %w", err)
+	}
+	if err := localRepo.Tag(ctx, desc, opts.targetRef.Reference); err != nil {
+		return fmt.Errorf("failed to tag %s: %w", displayRef, err)
+	}
+	output.Infof("Restored %s to %s (previously %s)", displayRef, target, formatDigest(history[len(history)-1].NewDigest))
+	return nil
+}
+
+// restoreTarget returns the digest a tag with the given history should be restored to. If restoreTo is empty,
+// this is the digest the tag referred to before its most recent change. Otherwise, restoreTo is either an
+// index into the history as printed by printTagHistory, in which case the digest the tag referred to after
+// that change is returned, or a digest the tag has referred to.
+func restoreTarget(history []local.TagHistoryEntry, restoreTo string) (digest.Digest, error) {
+	if restoreTo == "" {
+		lastChange := history[len(history)-1]
+		if lastChange.OldDigest == "" {
+			return "", fmt.Errorf("tag did not exist before its most recent change")
+		}
+		return lastChange.OldDigest, nil
+	}
+	if index, err := strconv.Atoi(restoreTo); err == nil {
+		if index < 1 || index > len(history) {
+			return "", fmt.Errorf("invalid history index %d: must be between 1 and %d", index, len(history))
+		}
+		entry := history[len(history)-index]
+		if entry.NewDigest == "" {
+			return "", fmt.Errorf("tag was removed by change %d", index)
+		}
+		return entry.NewDigest, nil
+	}
+	target, err := digest.Parse(restoreTo)
+	if err != nil {
+		return "", fmt.Errorf("invalid value %q for --to: must be a history index or digest", restoreTo)
+	}
+	for _, entry := range history {
+		if entry.OldDigest == target || entry.NewDigest == target {
+			return target, nil
+		}
+	}
+	return "", fmt.Errorf("tag has not referred to %s", target)
+}
+
+func formatDigest(dgst digest.Digest) string {
+	if dgst == "" {
+		return "<none>"
+	}
+	return dgst.String()
+}
@@ -18,9 +18,12 @@ package unpack
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"text/tabwriter"
 
 	"github.com/kitops-ml/kitops/pkg/cmd/options"
 	"github.com/kitops-ml/kitops/pkg/lib/completion"
@@ -60,8 +63,41 @@ to apply, which are matched against the Kitfile to further restrict what is extr
 Additional filters match elements of the Kitfile on either the name (if present) or
 the path used.
 
+Filters may also be glob patterns, which select files within layers. Patterns
+without a '/' (e.g. *.json) match file and directory names at any depth, while other
+patterns (e.g. data/shard-0*) match paths relative to the unpack directory. Prefix
+a pattern with '!' to exclude matching paths; exclusions take precedence over other
+patterns. Layers that cannot contain any matching paths are skipped.
+
+Any filter containing '*', '?', or '[' is treated as a glob pattern. To match a
+Kitfile name or path that contains these characters, prefix the filter with '='
+(e.g. --filter=datasets:=data[v2]); such filters are always matched literally.
+
 The filter field can be specified multiple times. A layer will be unpacked if it matches
-any of the specified filters`
+any of the specified filters
+
+Layers are extracted concurrently; use the --concurrency flag to limit how many layers
+are extracted at once.
+
+With the --atomic flag, the modelkit is first unpacked to a hidden staging directory
+inside the target directory. Files are moved into place only once all layers are
+unpacked successfully; if unpacking fails, the target directory is left unchanged.
+
+The --sync flag updates a directory that already contains unpacked files, e.g. from
+a previous version of the modelkit. Existing files are compared with the contents of
+the modelkit and only files that differ are rewritten. With --delete, files within
+the paths of unpacked layers that are not present in the modelkit are deleted; this
+includes files that were excluded by a .kitignore file when the modelkit was packed.
+
+With the --dry-run flag, the files that would be unpacked are listed along with
+their sizes and what would happen to any existing files, without writing anything to
+disk. Files that already exist are reported as conflicts unless --overwrite or
+--ignore-existing is used. Use --format=json for machine-readable output.
+
+With the --stream flag, the modelkit is always read from its remote registry, even if
+it is present in local storage. Layers are extracted as they are downloaded and
+nothing is added to local storage. If a download is interrupted, it is resumed from
+where it stopped.`
 
 	example = `# Unpack all components of a modelkit to the current directory
 kit unpack myrepo/my-model:latest -d /path/to/unpacked
@@ -75,9 +111,27 @@ kit unpack myrepo/my-model:latest --filter=datasets:my-dataset
 # Unpack only the docs layer with path "./README.md" to the current directory
 kit unpack myrepo/my-model:latest --filter=docs:./README.md
 
+# Unpack only JSON and tokenizer files from the model, excluding .bin files
+kit unpack myrepo/my-model:latest --filter='model:*.json,tokenizer*,!*.bin'
+
 # Unpack the model and the dataset named "validation"
 kit unpack myrepo/my-model:latest --filter=model --filter=datasets:validation
 
+# Unpack a modelkit that was packed with symlinks and hardlinks preserved
+kit unpack myrepo/my-model:latest --preserve-links -d /path/to/unpacked
+
+# Unpack a modelkit, leaving the target directory unchanged if unpacking fails
+kit unpack myrepo/my-model:latest --atomic -d /path/to/unpacked
+
+# Update a previously unpacked modelkit to a new version, rewriting only changed files
+kit unpack myrepo/my-model:v2 --sync --delete -d /path/to/unpacked
+
+# List the files that would be unpacked and any conflicts with existing files
+kit unpack myrepo/my-model:latest --dry-run -d /path/to/unpacked
+
+# Unpack a modelkit directly from a remote registry without storing it locally
+kit unpack registry.example.com/myrepo/my-model:latest --stream -d /path/to/unpacked
+
 # Unpack a modelkit from a remote registry with overwrite enabled
 kit unpack registry.example.com/myrepo/my-model:latest -o -d /path/to/unpacked`
 )
@@ -91,6 +145,13 @@ type unpackOptions struct {
 	modelRef       *registry.Reference
 	overwrite      bool
 	ignoreExisting bool
+	preserveLinks  bool
+	atomic         bool
+	sync           bool
+	syncDelete     bool
+	stream         bool
+	dryRun         bool
+	format         string
 }
 
 // unpackConf configures which elements of the modelkit should be unpacked.
@@ -122,6 +183,30 @@ func (opts *unpackOptions) complete(ctx context.Context, args []string) error {
 	}
 	opts.modelRef = modelRef
 
+	if opts.sync && (opts.overwrite || opts.ignoreExisting) {
+		return fmt.Errorf("--sync cannot be used with --overwrite or --ignore-existing")
+	}
+	if opts.sync && opts.atomic {
+		return fmt.Errorf("--sync cannot be used with --atomic")
+	}
+	if opts.syncDelete && !opts.sync {
+		return fmt.Errorf("--delete requires --sync")
+	}
+	if opts.dryRun && (opts.atomic || opts.sync) {
+		return fmt.Errorf("--dry-run cannot be used with --atomic or --sync")
+	}
+	switch opts.format {
+	case "", "table":
+		opts.format = "table"
+	case "json":
+		// valid format
+	default:
+		return fmt.Errorf("invalid format %s: must be table or json", opts.format)
+	}
+	if opts.stream && modelRef.Registry == util.DefaultRegistry {
+		return fmt.Errorf("--stream requires a reference that includes a registry")
+	}
+
 	absDir, err := filepath.Abs(opts.unpackDir)
 	if err != nil {
 		return fmt.Errorf("failed to resolve absolute path %s: %w", opts.unpackDir, err)
@@ -157,6 +242,13 @@ func UnpackCommand() *cobra.Command {
 	cmd.Flags().StringVarP(&opts.unpackDir, "dir", "d", "", "The target directory to unpack components into. This directory will be created if it does not exist")
 	cmd.Flags().BoolVarP(&opts.overwrite, "overwrite", "o", false, "Overwrites existing files and directories in the target unpack directory without prompting")
 	cmd.Flags().BoolVarP(&opts.ignoreExisting, "ignore-existing", "i", false, "Skip unpacking files if a file with that name already exists")
+	cmd.Flags().BoolVar(&opts.preserveLinks, "preserve-links", false, "Unpack symlinks and hardlinks stored in layers. Links must point to a path within the target directory")
+	cmd.Flags().BoolVar(&opts.atomic, "atomic", false, "Unpack to a staging directory and move files into place only once all layers are unpacked. If unpacking fails, the target directory is left unchanged")
+	cmd.Flags().BoolVar(&opts.sync, "sync", false, "Update existing files to match the modelkit, rewriting only files that differ")
+	cmd.Flags().BoolVar(&opts.syncDelete, "delete", false, "With --sync, delete files within unpacked paths that are not in the modelkit")
+	cmd.Flags().BoolVar(&opts.stream, "stream", false, "Unpack directly from the remote registry without storing the modelkit in local storage")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "List the files that would be unpacked and any conflicts with existing files without writing to disk")
+	cmd.Flags().StringVar(&opts.format, "format", "table", "Output format for --dry-run: table or json")
 	cmd.Flags().StringArrayVarP(&opts.filters, "filter", "f", []string{}, "Filter what is unpacked from the modelkit based on type and name. Can be specified multiple times")
 	cmd.Flags().BoolVar(&opts.unpackConf.unpackKitfile, "kitfile", false, "Unpack only Kitfile (deprecated: use --filter=kitfile)")
 	cmd.Flags().BoolVar(&opts.unpackConf.unpackModels, "model", false, "Unpack only model (deprecated: use --filter=model)")
@@ -164,6 +256,7 @@ func UnpackCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&opts.unpackConf.unpackDatasets, "datasets", false, "Unpack only datasets (deprecated: use --filter=datasets)")
 	cmd.Flags().BoolVar(&opts.unpackConf.unpackDocs, "docs", false, "Unpack only docs (deprecated: use --filter=docs)")
 	opts.AddNetworkFlags(cmd)
+	cmd.Flags().Lookup("concurrency").Usage = "Maximum number of layers to download and extract simultaneously"
 	cmd.Flags().SortFlags = false
 
 	cmd.CompletionOptions.SetDefaultShellCompDirective(cobra.ShellCompDirectiveDefault)
@@ -180,16 +273,6 @@ func runCommand(opts *unpackOptions) func(*cobra.Command, []string) error {
 			return output.Fatalf("Invalid reference: unpacking requires a tag or digest")
 		}
 
-		unpackTo := opts.unpackDir
-		if unpackTo == "" {
-			unpackTo = "current directory"
-		}
-		// Make sure target directory exists, in case user is using the -d flag
-		if err := os.MkdirAll(opts.unpackDir, 0755); err != nil {
-			return output.Fatalf("failed to create directory %s: %w", opts.unpackDir, err)
-		}
-		output.Infof("Unpacking to %s", unpackTo)
-
 		// Convert command options to library options
 		libOpts := &unpack.UnpackOptions{
 			ModelRef:       opts.modelRef,
@@ -198,6 +281,11 @@ func runCommand(opts *unpackOptions) func(*cobra.Command, []string) error {
 			Filters:        opts.filters,
 			Overwrite:      opts.overwrite,
 			IgnoreExisting: opts.ignoreExisting,
+			PreserveLinks:  opts.preserveLinks,
+			Atomic:         opts.atomic,
+			Sync:           opts.sync,
+			SyncDelete:     opts.syncDelete,
+			Stream:         opts.stream,
 			NetworkOptions: opts.NetworkOptions,
 		}
 
@@ -219,6 +307,27 @@ func runCommand(opts *unpackOptions) func(*cobra.Command, []string) error {
 			}
 		}
 
+		if opts.dryRun {
+			plan, err := unpack.PlanUnpack(cmd.Context(), libOpts)
+			if err != nil {
+				return output.Fatalln(err)
+			}
+			if err := printPlan(cmd.OutOrStdout(), plan, opts.format); err != nil {
+				return output.Fatalln(err)
+			}
+			return nil
+		}
+
+		unpackTo := opts.unpackDir
+		if unpackTo == "" {
+			unpackTo = "current directory"
+		}
+		// Make sure target directory exists, in case user is using the -d flag
+		if err := os.MkdirAll(opts.unpackDir, 0755); err != nil {
+			return output.Fatalf("failed to create directory %s: %w", opts.unpackDir, err)
+		}
+		output.Infof("Unpacking to %s", unpackTo)
+
 		err := unpack.UnpackModelKit(cmd.Context(), libOpts)
 		if err != nil {
 			return output.Fatalln(err)
@@ -227,7 +336,35 @@ func runCommand(opts *unpackOptions) func(*cobra.Command, []string) error {
 	}
 }
 
+// printPlan writes the files that would be unpacked to w, as a table or JSON depending on format.
+func printPlan(w io.Writer, plan *unpack.UnpackPlan, format string) error {
+	if format == "json" {
+		jsonBytes, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(jsonBytes))
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 2, 3, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tTYPE\tSIZE\tPATH")
+	for _, file := range plan.Files {
+		size := ""
+		if file.Type != "directory" {
+			size = output.FormatBytes(file.Size)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", file.Action, file.Type, size, file.Path)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d paths in %s (%s to write), %d conflicts\n", len(plan.Files), plan.UnpackDir, output.FormatBytes(plan.TotalSize()), len(plan.Conflicts()))
+	return nil
+}
+
 func printConfig(opts *unpackOptions) {
 	output.Debugf("Overwrite: %t", opts.overwrite)
+	output.Debugf("Atomic: %t", opts.atomic)
+	output.Debugf("Sync: %t (delete: %t)", opts.sync, opts.syncDelete)
+	output.Debugf("Stream: %t", opts.stream)
+	output.Debugf("Dry run: %t", opts.dryRun)
 	output.Debugf("Unpacking %s", opts.modelRef.String())
 }
@@ -0,0 +1,115 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kitops-ml/kitops/pkg/lib/completion"
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+)
+
+const (
+	shortDesc = `Verify the contents of a modelkit in local storage`
+	longDesc  = `Check that the layers of a modelkit in local storage are intact.
+
+Each layer is read from local storage and checked against the digest recorded in
+the modelkit's manifest. The uncompressed contents of each layer are also checked
+against the layer's diffID, which is recorded in the modelkit's config. No files
+are unpacked.
+
+Modelkits packed by older versions of Kit may not include diffIDs; for these,
+only the digest of each layer is checked.`
+	example = `# Verify a local modelkit:
+kit verify mymodel:mytag
+
+# Verify a local modelkit by digest:
+kit verify mymodel@sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a`
+)
+
+type verifyOptions struct {
+	configHome string
+	modelRef   *registry.Reference
+}
+
+func VerifyCommand() *cobra.Command {
+	opts := &verifyOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "verify [flags] MODELKIT",
+		Short:   shortDesc,
+		Long:    longDesc,
+		Example: example,
+		RunE:    runCommand(opts),
+		Args:    cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) >= 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completion.GetLocalModelKitsCompletion(cmd.Context(), toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+		},
+	}
+	cmd.Flags().SortFlags = false
+
+	return cmd
+}
+
+func runCommand(opts *verifyOptions) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := opts.complete(cmd.Context(), args); err != nil {
+			return output.Fatalf("Invalid arguments: %s", err)
+		}
+		if err := verifyModelKit(cmd.Context(), opts); err != nil {
+			if errors.Is(err, errdef.ErrNotFound) {
+				return output.Fatalf("Could not find modelkit %s", util.FormatRepositoryForDisplay(opts.modelRef.String()))
+			}
+			return output.Fatalf("Failed to verify modelkit: %s", err)
+		}
+		output.Infof("Verified modelkit %s", util.FormatRepositoryForDisplay(opts.modelRef.String()))
+		return nil
+	}
+}
+
+func (opts *verifyOptions) complete(ctx context.Context, args []string) error {
+	configHome, ok := ctx.Value(constants.ConfigKey{}).(string)
+	if !ok {
+		return fmt.Errorf("default config path not set on command context")
+	}
+	opts.configHome = configHome
+
+	ref, extraTags, err := util.ParseReference(args[0])
+	if err != nil {
+		return err
+	}
+	if len(extraTags) > 0 {
+		return fmt.Errorf("invalid reference format: extra tags are not supported: %s", strings.Join(extraTags, ", "))
+	}
+	if ref.Reference == "" {
+		return fmt.Errorf("missing tag or digest from ModelKit reference '%s'", args[0])
+	}
+	opts.modelRef = ref
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
+	"github.com/kitops-ml/kitops/pkg/lib/filesystem/unpack"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/local"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
+)
+
+// verifyModelKit checks every layer of the modelkit referenced by opts against its digest and diffID.
+// All layers are checked, and an error is returned if any layer fails verification.
+func verifyModelKit(ctx context.Context, opts *verifyOptions) error {
+	storageRoot := constants.StoragePath(opts.configHome)
+	localRepo, err := local.NewLocalRepo(storageRoot, opts.modelRef)
+	if err != nil {
+		return fmt.Errorf("failed to read local storage: %w", err)
+	}
+	_, manifest, kitfile, err := util.ResolveManifestAndConfig(ctx, localRepo, opts.modelRef.Reference)
+	if err != nil && !errors.Is(err, util.ErrNoKitfile) {
+		return err
+	}
+	layers, layerChunks, err := util.GroupLayerChunks(manifest.Layers)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	diffIDs, err := util.GetLayerDiffIDs(ctx, localRepo, manifest, kitfile, layers)
+	if err != nil {
+		return fmt.Errorf("failed to read layer diffIDs: %w", err)
+	}
+
+	var failed int
+	progress := output.NewUnpackProgress(ctx)
+	for idx, layer := range layers {
+		layerType := layer.MediaType
+		if mediaType, err := mediatype.ParseMediaType(layer.MediaType); err == nil {
+			layerType = mediaType.UserString()
+		}
+		if err := unpack.VerifyLayer(ctx, localRepo, layer, layerChunks[idx], diffIDs[idx], progress); err != nil {
+			progress.Logf(output.LogLevelError, "Failed to verify %s layer %s: %s", layerType, layer.Digest, err)
+			failed++
+			continue
+		}
+		if diffIDs[idx] == "" {
+			progress.Infof("Verified %s layer %s (no diffID available)", layerType, layer.Digest)
+		} else {
+			progress.Infof("Verified %s layer %s", layerType, layer.Digest)
+		}
+	}
+	progress.Done()
+	if failed > 0 {
+		return fmt.Errorf("%d of %d layers failed verification", failed, len(layers))
+	}
+	return nil
+}
@@ -27,6 +27,10 @@ import (
 
 type ConfigKey struct{}
 
+// CommandLineKey is the context key for the command line being run (e.g. "kit pack ."), which is recorded in
+// local tag history.
+type CommandLineKey struct{}
+
 const (
 	// Default name for Kitfile (otherwise specified via the -f flag in pack)
 	DefaultKitfileName = "Kitfile"
@@ -59,6 +63,19 @@ const (
 	LayerSubtypeAnnotation = "ml.kitops.modelkit.layer-subtype"
 	LayerSubtypePrompt     = "prompt"
 
+	// Layers that are split into multiple chunk blobs are stored as consecutive layers in the manifest,
+	// each annotated with its index within the layer, the total number of chunks, and the digest and media
+	// type of the full, reassembled layer.
+	LayerChunkIndexAnnotation     = "ml.kitops.modelkit.layer-chunk.index"
+	LayerChunkCountAnnotation     = "ml.kitops.modelkit.layer-chunk.count"
+	LayerChunkDigestAnnotation    = "ml.kitops.modelkit.layer-chunk.digest"
+	LayerChunkMediaTypeAnnotation = "ml.kitops.modelkit.layer-chunk.mediatype"
+
+	// Manifest descriptors in local storage indexes record when the modelkit was packed or pulled, and when it was
+	// last unpacked or pushed. These are used to apply retention policies to local storage.
+	StorageAddedAnnotation    = "ml.kitops.storage.added"
+	StorageLastUsedAnnotation = "ml.kitops.storage.last-used"
+
 	// MaxModelRefChain is the maximum number of "parent" modelkits a modelkit may have
 	// by e.g. referring to another modelkit in its .model.path
 	MaxModelRefChain = 10
@@ -129,6 +146,11 @@ func IngestPath(storageBase string) string {
 	return filepath.Join(storageBase, "ingest")
 }
 
+// QuarantinePath returns the directory that corrupt blobs are moved to by 'kit storage verify'
+func QuarantinePath(storageBase string) string {
+	return filepath.Join(storageBase, "quarantine")
+}
+
 func HarnessPath(configBase string) string {
 	return filepath.Join(configBase, HarnessSubpath)
 }
@@ -192,3 +214,10 @@ func TagIndexPathForRepo(storageBase, repo string) string {
 	indexFileName := fmt.Sprintf("%s-tags.json", repoEncoded)
 	return filepath.Join(storageBase, indexFileName)
 }
+
+// TagHistoryPathForRepo returns the path to the file recording changes to tags in a specific repo (org/name)
+func TagHistoryPathForRepo(storageBase, repo string) string {
+	repoEncoded := base64.URLEncoding.EncodeToString([]byte(repo))
+	historyFileName := fmt.Sprintf("%s-tag-history.json", repoEncoded)
+	return filepath.Join(storageBase, historyFileName)
+}
@@ -20,4 +20,10 @@ const (
 	KitopsHomeEnvVar    = "KITOPS_HOME"
 	ClientCertEnvVar    = "KITOPS_CLIENT_CERT"
 	ClientCertKeyEnvVar = "KITOPS_CLIENT_KEY"
+	// SharedStorageEnvVar lists additional, read-only storage directories (separated by the OS path list
+	// separator) whose modelkits and blobs are available alongside those in $KITOPS_HOME/storage.
+	SharedStorageEnvVar = "KITOPS_SHARED_STORAGE"
+	// SourceDateEpochEnvVar is the standard environment variable for specifying timestamps in reproducible builds.
+	// See https://reproducible-builds.org/specs/source-date-epoch/
+	SourceDateEpochEnvVar = "SOURCE_DATE_EPOCH"
 )
@@ -27,6 +27,12 @@ const (
 	ArtifactTypeModelManifest = "application/vnd.cncf.model.manifest.v1+json"
 )
 
+// LayerChunkMediaType is the media type for blobs that store one chunk of a layer that is split into
+// multiple blobs. The media type of the reassembled layer is stored in the chunk's annotations. Using a
+// separate media type ensures clients that do not support chunked layers fail instead of treating each
+// chunk as a complete layer.
+const LayerChunkMediaType = "application/vnd.kitops.modelkit.layer-chunk.v1"
+
 func ModelFormatForManifest(manifest *ocispec.Manifest) (ModelFormat, error) {
 	if manifest.ArtifactType == ArtifactTypeKitManifest || manifest.Config.MediaType == KitConfigMediaType.String() {
 		return KitFormat, nil
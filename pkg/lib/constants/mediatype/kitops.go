@@ -51,6 +51,8 @@ func (mt *kitopsMediaType) String() string {
 		return fmt.Sprintf("application/vnd.kitops.modelkit.%s.v1.tar", mt.baseTypeString())
 	case GzipCompression, GzipFastestCompression:
 		return fmt.Sprintf("application/vnd.kitops.modelkit.%s.v1.tar+gzip", mt.baseTypeString())
+	case ZstdCompression:
+		return fmt.Sprintf("application/vnd.kitops.modelkit.%s.v1.tar+zstd", mt.baseTypeString())
 	}
 	// Should never happen since parsing should only result in valid values
 	return "invalid mediatype"
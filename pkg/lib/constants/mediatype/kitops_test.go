@@ -28,14 +28,19 @@ func TestParseKitopsMediaType(t *testing.T) {
 		"application/vnd.kitops.modelkit.config.v1+json",
 		"application/vnd.kitops.modelkit.model.v1.tar",
 		"application/vnd.kitops.modelkit.model.v1.tar+gzip",
+		"application/vnd.kitops.modelkit.model.v1.tar+zstd",
 		"application/vnd.kitops.modelkit.modelpart.v1.tar",
 		"application/vnd.kitops.modelkit.modelpart.v1.tar+gzip",
+		"application/vnd.kitops.modelkit.modelpart.v1.tar+zstd",
 		"application/vnd.kitops.modelkit.dataset.v1.tar",
 		"application/vnd.kitops.modelkit.dataset.v1.tar+gzip",
+		"application/vnd.kitops.modelkit.dataset.v1.tar+zstd",
 		"application/vnd.kitops.modelkit.code.v1.tar",
 		"application/vnd.kitops.modelkit.code.v1.tar+gzip",
+		"application/vnd.kitops.modelkit.code.v1.tar+zstd",
 		"application/vnd.kitops.modelkit.docs.v1.tar",
 		"application/vnd.kitops.modelkit.docs.v1.tar+gzip",
+		"application/vnd.kitops.modelkit.docs.v1.tar+zstd",
 	}
 
 	for _, mediaType := range mediaTypes {
@@ -57,6 +62,7 @@ func TestParseKitopsInvalidType(t *testing.T) {
 		{mediaType: "application/vnd.kitops.modelkit.badbase.v1.tar", errRegexp: "invalid base type"},
 		{mediaType: "application/vnd.kitops.modelkit.model.v1.tar+badCompression", errRegexp: "invalid compression"},
 		{mediaType: "application/vnd.kitops.modelkit.model.v1.badFormat", errRegexp: "unrecognized media type"},
+		{mediaType: LayerChunkMediaType, errRegexp: "unrecognized media type"},
 	}
 
 	for _, tt := range tests {
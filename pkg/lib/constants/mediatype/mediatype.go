@@ -189,19 +189,42 @@ func ParseFormat(f string) (Format, error) {
 }
 
 func IsValidCompression(c string) error {
-	// Not supporting zstd for now; no stable implementation available
 	switch c {
-	case "none", "gzip", "gzip-fastest":
+	case "none", "gzip", "gzip-fastest", "zstd":
 		return nil
 	default:
-		return fmt.Errorf("invalid compression type: must be one of 'none', 'gzip', or 'gzip-fastest'")
+		return fmt.Errorf("invalid compression type: must be one of 'none', 'gzip', 'gzip-fastest', or 'zstd'")
 	}
 }
 
+// IsValidCompressionLevel checks that level is a valid compression level for compression c. A level
+// of zero is always valid and selects the default level for the compression type.
+func IsValidCompressionLevel(c CompressionType, level int) error {
+	if level == 0 {
+		return nil
+	}
+	switch c {
+	case GzipCompression:
+		if level < 1 || level > 9 {
+			return fmt.Errorf("invalid compression level %d: gzip supports levels 1-9", level)
+		}
+	case ZstdCompression:
+		if level < 1 || level > 22 {
+			return fmt.Errorf("invalid compression level %d: zstd supports levels 1-22", level)
+		}
+	default:
+		return fmt.Errorf("compression level is not supported for compression type %s", c)
+	}
+	return nil
+}
+
 func FormatMediaTypeForUser(mediatype string) string {
 	if mediatype == ocispec.MediaTypeImageManifest {
 		return "manifest"
 	}
+	if mediatype == LayerChunkMediaType {
+		return "layer chunk"
+	}
 	parsed, err := ParseMediaType(mediatype)
 	if err != nil {
 		// Should never happen
@@ -22,6 +22,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/kitops-ml/kitops/pkg/output"
 )
@@ -41,6 +42,9 @@ type CacheSubDir string
 const (
 	CachePackSubdir   CacheSubDir = "pack"
 	CacheImportSubdir CacheSubDir = "import"
+	// CacheLayerSubdir stores metadata about previously packed layers. Unlike other subdirectories, it
+	// persists between commands.
+	CacheLayerSubdir CacheSubDir = "layers"
 )
 
 // MkCacheDir creates a directory within configHome to be used for temporary storage and returns a function that can
@@ -95,7 +99,48 @@ func MkCacheFile(subDir CacheSubDir, basename string) (tempFile *os.File, cleanu
 	return f, cleanup, nil
 }
 
+// ReadCacheFile reads the file name within subDir. If the file does not exist, the returned
+// error wraps fs.ErrNotExist.
+func ReadCacheFile(subDir CacheSubDir, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(cacheHome(), string(subDir), name))
+}
+
+// WriteCacheFile writes data to the file name within subDir, replacing it if it exists. The file
+// is written to a temporary file first so that concurrent readers never see a partial file.
+func WriteCacheFile(subDir CacheSubDir, name string, data []byte) error {
+	cacheSubDir := filepath.Join(cacheHome(), string(subDir))
+	if err := os.MkdirAll(cacheSubDir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory %s: %w", cacheSubDir, err)
+	}
+	f, err := os.CreateTemp(cacheSubDir, name+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file in %s: %w", cacheSubDir, err)
+	}
+	tempPath := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write cache file %s: %w", tempPath, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write cache file %s: %w", tempPath, err)
+	}
+	if err := os.Rename(tempPath, filepath.Join(cacheSubDir, name)); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write cache file %s: %w", name, err)
+	}
+	return nil
+}
+
 func CleanCacheDir(subDir CacheSubDir) error {
+	return CleanStaleCacheEntries(subDir, 0)
+}
+
+// CleanStaleCacheEntries removes entries in subDir that have not been modified within maxAge. This can be used
+// to clean up files left behind by cancelled commands without interfering with other kit processes that are
+// using the same cache directory concurrently. If maxAge is zero, all entries are removed.
+func CleanStaleCacheEntries(subDir CacheSubDir, maxAge time.Duration) error {
 	cacheSubDir := filepath.Join(cacheHome(), string(subDir))
 	ds, err := os.ReadDir(cacheSubDir)
 	if err != nil {
@@ -106,6 +151,17 @@ func CleanCacheDir(subDir CacheSubDir) error {
 	}
 	for _, d := range ds {
 		entryPath := filepath.Join(cacheSubDir, d.Name())
+		if maxAge > 0 {
+			info, err := d.Info()
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to examine %s: %w", entryPath, err)
+			}
+			if time.Since(info.ModTime()) < maxAge {
+				continue
+			}
+		}
 		if d.IsDir() {
 			if err := os.RemoveAll(entryPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
 				return fmt.Errorf("failed to remove directory %s: %w", entryPath, err)
@@ -0,0 +1,82 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strconv"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// saveLayerChunks splits the layer blob at path, described by layerDesc, into chunks of at most chunkSize
+// bytes and saves each chunk to store as a separate blob. The returned descriptors are annotated
+// with the information required to reassemble the layer (see util.GroupLayerChunks).
+func saveLayerChunks(ctx context.Context, store oras.Target, path string, layerDesc ocispec.Descriptor, chunkSize int64) ([]ocispec.Descriptor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open temporary file: %w", err)
+	}
+	defer file.Close()
+
+	numChunks := (layerDesc.Size + chunkSize - 1) / chunkSize
+	var chunks []ocispec.Descriptor
+	for idx := int64(0); idx < numChunks; idx++ {
+		offset := idx * chunkSize
+		size := min(chunkSize, layerDesc.Size-offset)
+		chunkDigest, err := digest.Canonical.FromReader(io.NewSectionReader(file, offset, size))
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute digest for layer chunk: %w", err)
+		}
+		chunkDesc := ocispec.Descriptor{
+			MediaType:   mediatype.LayerChunkMediaType,
+			Digest:      chunkDigest,
+			Size:        size,
+			Annotations: maps.Clone(layerDesc.Annotations),
+		}
+		if chunkDesc.Annotations == nil {
+			chunkDesc.Annotations = map[string]string{}
+		}
+		chunkDesc.Annotations[constants.LayerChunkIndexAnnotation] = strconv.FormatInt(idx, 10)
+		chunkDesc.Annotations[constants.LayerChunkCountAnnotation] = strconv.FormatInt(numChunks, 10)
+		chunkDesc.Annotations[constants.LayerChunkDigestAnnotation] = layerDesc.Digest.String()
+		chunkDesc.Annotations[constants.LayerChunkMediaTypeAnnotation] = layerDesc.MediaType
+
+		if exists, err := store.Exists(ctx, chunkDesc); err != nil {
+			return nil, err
+		} else if !exists {
+			// Another layer with identical contents may have been saved concurrently
+			err := store.Push(ctx, chunkDesc, io.NewSectionReader(file, offset, size))
+			if err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+				return nil, fmt.Errorf("failed to add layer chunk to storage: %w", err)
+			}
+		}
+		chunks = append(chunks, chunkDesc)
+	}
+	return chunks, nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build darwin
+// +build darwin
+
+package filesystem
+
+import (
+	"os"
+	"syscall"
+)
+
+// changeTime returns the time the metadata or contents of the file described by fi last changed, in
+// nanoseconds. If it cannot be determined, ok is false.
+func changeTime(fi os.FileInfo) (ctime int64, ok bool) {
+	stat, isStat := fi.Sys().(*syscall.Stat_t)
+	if !isStat {
+		return 0, false
+	}
+	return stat.Ctimespec.Nano(), true
+}
@@ -21,6 +21,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	kfutils "github.com/kitops-ml/kitops/pkg/lib/repo/util"
 
@@ -63,12 +64,16 @@ func New(kitIgnorePaths []string, kitfile *artifact.KitFile, extraLayers ...stri
 type ignorePaths struct {
 	ignoreFileMatcher *patternmatcher.PatternMatcher
 	layers            []string
+	// mu guards ignoreFileMatcher, which compiles patterns lazily and is not safe for concurrent use
+	mu sync.Mutex
 }
 
 func (pm *ignorePaths) Matches(path, layerPath string) (bool, error) {
 	path = cleanPath(path)
 	layerPath = cleanPath(layerPath)
+	pm.mu.Lock()
 	ignoreFileMatches, err := pm.ignoreFileMatcher.MatchesOrParentMatches(path)
+	pm.mu.Unlock()
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,172 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/kitops-ml/kitops/pkg/artifact"
+	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
+	"github.com/kitops-ml/kitops/pkg/lib/filesystem/cache"
+	"github.com/kitops-ml/kitops/pkg/lib/filesystem/ignore"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+)
+
+// layerCacheVersion should be incremented whenever a change to packing would produce different layers
+// for the same files, to invalidate existing cache entries.
+const layerCacheVersion = 1
+
+// layerCacheKey identifies a Kitfile entry and the options that affect how it is packed. Entries in the
+// layer cache are stored under a digest of this struct. Compression is recorded separately from MediaType
+// as some compression types (e.g. gzip and gzip-fastest) share a media type.
+type layerCacheKey struct {
+	Version          int    `json:"version"`
+	Path             string `json:"path"`
+	MediaType        string `json:"mediaType"`
+	Compression      string `json:"compression"`
+	CompressionLevel int    `json:"compressionLevel"`
+	ParallelGzip     bool   `json:"parallelGzip"`
+	PreserveLinks    bool   `json:"preserveLinks"`
+	Reproducible     bool   `json:"reproducible"`
+	Timestamp        int64  `json:"timestamp"`
+	ChunkSize        int64  `json:"chunkSize"`
+}
+
+// layerCacheEntry records the layer produced the last time a Kitfile entry was packed, along with a
+// fingerprint of the files that were packed.
+type layerCacheEntry struct {
+	Fingerprint digest.Digest        `json:"fingerprint"`
+	Layers      []ocispec.Descriptor `json:"layers"`
+	LayerInfo   *artifact.LayerInfo  `json:"layerInfo"`
+}
+
+// layerCacheName returns the name of the cache file for the Kitfile entry at path when packed with
+// mediaType and opts.
+func layerCacheName(path string, mediaType mediatype.MediaType, opts *SaveModelOptions) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path %s: %w", path, err)
+	}
+	key := layerCacheKey{
+		Version:          layerCacheVersion,
+		Path:             absPath,
+		MediaType:        mediaType.String(),
+		Compression:      opts.Compression.String(),
+		CompressionLevel: opts.CompressionLevel,
+		ParallelGzip:     opts.ParallelGzip,
+		PreserveLinks:    opts.PreserveLinks,
+		Reproducible:     opts.Reproducible,
+		ChunkSize:        opts.ChunkSize,
+	}
+	// Raw layers record file metadata, including timestamps, in annotations
+	if opts.Reproducible {
+		key.Timestamp = opts.timestamp().Unix()
+	}
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal layer cache key: %w", err)
+	}
+	return digest.FromBytes(keyBytes).Encoded() + ".json", nil
+}
+
+// layerFingerprint computes a digest of the path, size, modification and change times, mode and inode of
+// each file that would be included in the layer for path. If the fingerprint is unchanged, the files are
+// assumed to be unchanged as well. The change time is included as it cannot be set by tools that restore
+// a file's modification time after changing its contents.
+func layerFingerprint(path string, mediaType mediatype.MediaType, ignore ignore.Paths, opts *SaveModelOptions, plog *output.ProgressLogger) (digest.Digest, error) {
+	digester := digest.Canonical.Digester()
+	addFile := func(file string, fi os.FileInfo) error {
+		var linkTarget string
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(file)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink %s: %w", file, err)
+			}
+			linkTarget = target
+		}
+		id, _ := fileID(fi)
+		ctime, _ := changeTime(fi)
+		_, err := fmt.Fprintf(digester.Hash(), "%s\x00%d\x00%d\x00%d\x00%o\x00%d\x00%d\x00%s\n",
+			filepath.ToSlash(file), fi.Size(), fi.ModTime().UnixNano(), ctime, fi.Mode(), id.dev, id.ino, linkTarget)
+		return err
+	}
+
+	path = filepath.Clean(path)
+	if mediaType.Format() == mediatype.RawFormat {
+		// Raw layers are stored regardless of ignore rules, so only the file itself matters
+		fi, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		if err := addFile(path, fi); err != nil {
+			return "", err
+		}
+	} else if err := walkLayer(path, ignore, opts.PreserveLinks, plog, addFile); err != nil {
+		return "", err
+	}
+	return digester.Digest(), nil
+}
+
+// readLayerCache returns the layers stored in the cache entry cacheName if its fingerprint matches and
+// all layers are present in store. Otherwise, ok is false.
+func readLayerCache(ctx context.Context, store oras.Target, cacheName string, fingerprint digest.Digest, plog *output.ProgressLogger) (layers []ocispec.Descriptor, layerInfo *artifact.LayerInfo, ok bool) {
+	entryBytes, err := cache.ReadCacheFile(cache.CacheLayerSubdir, cacheName)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			plog.Debugf("Failed to read layer cache entry %s: %s", cacheName, err)
+		}
+		return nil, nil, false
+	}
+	entry := &layerCacheEntry{}
+	if err := json.Unmarshal(entryBytes, entry); err != nil {
+		plog.Debugf("Failed to parse layer cache entry %s: %s", cacheName, err)
+		return nil, nil, false
+	}
+	if entry.Fingerprint != fingerprint || len(entry.Layers) == 0 || entry.LayerInfo == nil {
+		return nil, nil, false
+	}
+	for _, desc := range entry.Layers {
+		if exists, err := store.Exists(ctx, desc); err != nil || !exists {
+			plog.Debugf("Cached layer %s is not in storage", desc.Digest)
+			return nil, nil, false
+		}
+	}
+	return entry.Layers, entry.LayerInfo, true
+}
+
+// writeLayerCache stores layers and layerInfo in the cache entry cacheName.
+func writeLayerCache(cacheName string, fingerprint digest.Digest, layers []ocispec.Descriptor, layerInfo *artifact.LayerInfo) error {
+	entryBytes, err := json.Marshal(layerCacheEntry{
+		Fingerprint: fingerprint,
+		Layers:      layers,
+		LayerInfo:   layerInfo,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal layer cache entry: %w", err)
+	}
+	return cache.WriteCacheFile(cache.CacheLayerSubdir, cacheName, entryBytes)
+}
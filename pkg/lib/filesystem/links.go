@@ -0,0 +1,52 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// fileKey uniquely identifies a file on disk, and is used to detect hardlinks to the same file.
+type fileKey struct {
+	dev uint64
+	ino uint64
+}
+
+// verifySymlinkInLayer checks that the symlink at linkPath, which points to target, resolves to a path
+// within layerPath. Both linkPath and layerPath are relative to the current working directory, which
+// is expected to be the context directory. Symlinks within target are resolved one at a time, so that
+// chains of symlinks cannot be used to point outside the layer.
+func verifySymlinkInLayer(layerPath, linkPath, target string) error {
+	if filepath.IsAbs(target) {
+		return fmt.Errorf("symlink %s points to absolute path %s", linkPath, target)
+	}
+	resolvedLayerPath, err := ResolveInRoot(".", layerPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve layer path %s: %w", layerPath, err)
+	}
+	// Avoid filepath.Join here, as it would clean '..' elements in target lexically
+	resolvedTarget, err := ResolveInRoot(".", filepath.Dir(linkPath)+string(filepath.Separator)+target)
+	if err != nil {
+		return fmt.Errorf("symlink %s points outside context directory: %w", linkPath, err)
+	}
+	relPath, err := filepath.Rel(resolvedLayerPath, resolvedTarget)
+	if err != nil || !filepath.IsLocal(relPath) {
+		return fmt.Errorf("symlink %s points outside layer %s", linkPath, layerPath)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+// +build !windows
+
+package filesystem
+
+import (
+	"os"
+	"syscall"
+)
+
+// hardlinkKey returns a key identifying the file described by fi if it has more than one hardlink. If
+// the file has only one link, or the key cannot be determined, ok is false.
+func hardlinkKey(fi os.FileInfo) (key fileKey, ok bool) {
+	stat, isStat := fi.Sys().(*syscall.Stat_t)
+	if !isStat || stat.Nlink < 2 {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
+
+// fileID returns the device and inode numbers for the file described by fi. If they cannot be
+// determined, ok is false.
+func fileID(fi os.FileInfo) (key fileKey, ok bool) {
+	stat, isStat := fi.Sys().(*syscall.Stat_t)
+	if !isStat {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
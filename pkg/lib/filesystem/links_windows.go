@@ -0,0 +1,32 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+// +build windows
+
+package filesystem
+
+import "os"
+
+// hardlinkKey is not supported on Windows; hardlinked files are stored as regular files.
+func hardlinkKey(fi os.FileInfo) (key fileKey, ok bool) {
+	return fileKey{}, false
+}
+
+// fileID is not supported on Windows; files are identified by path, size and modification time only.
+func fileID(fi os.FileInfo) (key fileKey, ok bool) {
+	return fileKey{}, false
+}
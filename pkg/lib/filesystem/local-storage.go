@@ -22,7 +22,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/kitops-ml/kitops/pkg/artifact"
 	"github.com/kitops-ml/kitops/pkg/lib/constants"
@@ -36,30 +39,78 @@ import (
 	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
 	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/errdef"
 )
 
+// staleCacheAge is the age after which files in the pack cache are assumed to have been left behind by a
+// cancelled pack, rather than belonging to a pack that is still running.
+const staleCacheAge = 24 * time.Hour
+
 type SaveModelOptions struct {
 	ModelFormat mediatype.ModelFormat
 	Compression mediatype.CompressionType
-	LayerFormat mediatype.Format
+	// CompressionLevel is the compression level to use for compressed layers. If zero, the default
+	// level for the compression format is used.
+	CompressionLevel int
+	// ParallelGzip splits gzip compression across all available CPUs. Layers compressed in parallel have
+	// different digests than layers compressed with compress/gzip, which is used by default.
+	ParallelGzip bool
+	LayerFormat  mediatype.Format
+	// Concurrency is the maximum number of layers to pack simultaneously. If less than one, layers
+	// are packed one at a time.
+	Concurrency int
+	// PreserveLinks stores symlinks and hardlinks within a layer as links in tar layers. If false,
+	// symlinks are skipped and hardlinked files are stored as separate regular files.
+	PreserveLinks bool
+	// Reproducible normalizes file modes in layers and uses SourceDateEpoch (or the Unix epoch, if unset)
+	// for timestamps, so that packing the same inputs always results in the same digest.
+	Reproducible bool
+	// SourceDateEpoch, if not nil, is used for timestamps stored in the modelkit instead of the current time.
+	SourceDateEpoch *time.Time
+	// OmitVersionAnnotation omits the annotation recording the version of Kit used to pack the modelkit.
+	OmitVersionAnnotation bool
+	// ChunkSize, if greater than zero, is the maximum size of a layer blob. Larger layers are split into
+	// multiple chunk blobs, which are reassembled when unpacking. Only supported for ModelKit format.
+	ChunkSize int64
+	// UseLayerCache enables reusing layers saved by a previous pack if the files for a layer are unchanged,
+	// based on their size, modification and change times, and inode. See layerFingerprint.
+	UseLayerCache bool
+}
+
+// timestamp returns the time to use for timestamps stored in the modelkit (e.g. the creation time).
+func (opts *SaveModelOptions) timestamp() time.Time {
+	if opts.SourceDateEpoch != nil {
+		return opts.SourceDateEpoch.UTC()
+	}
+	if opts.Reproducible {
+		return time.Unix(0, 0).UTC()
+	}
+	return time.Now()
 }
 
 // SaveModel saves an *artifact.Model to the provided oras.Target, compressing layers. It attempts to block
 // modelkits that include paths that leave the base context directory, allowing only subdirectories of the root
 // context to be included in the modelkit.
-func SaveModel(ctx context.Context, localRepo local.LocalRepo, kitfile *artifact.KitFile, ignore ignore.Paths, opts *SaveModelOptions) (*ocispec.Descriptor, error) {
-	layerDescs, diffIDs, err := saveKitfileLayers(ctx, localRepo, kitfile, ignore, opts)
+func SaveModel(ctx context.Context, store oras.Target, kitfile *artifact.KitFile, ignore ignore.Paths, opts *SaveModelOptions) (*ocispec.Descriptor, error) {
+	if opts.LayerFormat == mediatype.RawFormat && opts.ModelFormat != mediatype.ModelPackFormat {
+		return nil, fmt.Errorf("raw layers are only supported for ModelPack format")
+	}
+	if opts.ChunkSize > 0 && opts.ModelFormat != mediatype.KitFormat {
+		return nil, fmt.Errorf("chunked layers are only supported for ModelKit format")
+	}
+	layerDescs, diffIDs, err := saveKitfileLayers(ctx, store, kitfile, ignore, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	configDesc, err := saveConfig(ctx, localRepo, kitfile, diffIDs, opts.ModelFormat)
+	configDesc, err := saveConfig(ctx, store, kitfile, diffIDs, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	manifest, err := createManifest(configDesc, layerDescs, opts.ModelFormat)
+	manifest, err := createManifest(configDesc, layerDescs, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error creating manifest: %w", err)
 	}
@@ -76,22 +127,23 @@ func SaveModel(ctx context.Context, localRepo local.LocalRepo, kitfile *artifact
 		manifest.Annotations[constants.KitfileJsonAnnotation] = string(kitfileBytes)
 	}
 
-	manifestDesc, err := saveModelManifest(ctx, localRepo, manifest)
+	manifestDesc, err := saveModelManifest(ctx, store, manifest)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := cache.CleanCacheDir(cache.CachePackSubdir); err != nil {
+	// Other pack commands may be using the cache concurrently, so only remove files left behind by cancelled packs
+	if err := cache.CleanStaleCacheEntries(cache.CachePackSubdir, staleCacheAge); err != nil {
 		output.Logf(output.LogLevelWarn, "Failed to clean cache directory: %s", err)
 	}
 
 	return manifestDesc, nil
 }
 
-func saveConfig(ctx context.Context, localRepo local.LocalRepo, kitfile *artifact.KitFile, diffIDs []digest.Digest, modelFormat mediatype.ModelFormat) (ocispec.Descriptor, error) {
+func saveConfig(ctx context.Context, store oras.Target, kitfile *artifact.KitFile, diffIDs []digest.Digest, opts *SaveModelOptions) (ocispec.Descriptor, error) {
 	var configBytes []byte
 	var configMediaType string
-	switch modelFormat {
+	switch opts.ModelFormat {
 	case mediatype.KitFormat:
 		configMediaType = mediatype.KitConfigMediaType.String()
 		bytes, err := kitfile.MarshalToJSON()
@@ -101,7 +153,7 @@ func saveConfig(ctx context.Context, localRepo local.LocalRepo, kitfile *artifac
 		configBytes = bytes
 	case mediatype.ModelPackFormat:
 		configMediaType = mediatype.ModelPackConfigMediaType.String()
-		modelpackConfig := kitfile.ToModelPackConfig(diffIDs)
+		modelpackConfig := kitfile.ToModelPackConfig(diffIDs, opts.timestamp())
 		bytes, err := json.Marshal(modelpackConfig)
 		if err != nil {
 			return ocispec.DescriptorEmptyJSON, err
@@ -117,13 +169,13 @@ func saveConfig(ctx context.Context, localRepo local.LocalRepo, kitfile *artifac
 		Size:      int64(len(configBytes)),
 	}
 
-	exists, err := localRepo.Exists(ctx, desc)
+	exists, err := store.Exists(ctx, desc)
 	if err != nil {
 		return ocispec.DescriptorEmptyJSON, err
 	}
 	if !exists {
 		// Does not exist in storage, need to push
-		err = localRepo.Push(ctx, desc, bytes.NewReader(configBytes))
+		err = store.Push(ctx, desc, bytes.NewReader(configBytes))
 		if err != nil {
 			return ocispec.DescriptorEmptyJSON, err
 		}
@@ -135,135 +187,314 @@ func saveConfig(ctx context.Context, localRepo local.LocalRepo, kitfile *artifac
 	return desc, nil
 }
 
-func saveKitfileLayers(ctx context.Context, localRepo local.LocalRepo, kitfile *artifact.KitFile, ignore ignore.Paths, opts *SaveModelOptions) (layers []ocispec.Descriptor, diffIDs []digest.Digest, err error) {
-	if kitfile.Model != nil {
-		if kitfile.Model.Path != "" && !util.IsModelKitReference(kitfile.Model.Path) {
-			mediaType := mediatype.New(opts.ModelFormat, mediatype.ModelBaseType, opts.LayerFormat, opts.Compression)
-			layer, layerInfo, err := saveContentLayer(ctx, localRepo, kitfile.Model.Path, mediaType, ignore)
+// kitfileLayer is a layer to be packed for an entry in a Kitfile.
+type kitfileLayer struct {
+	path     string
+	baseType mediatype.BaseType
+	isPrompt bool
+	// setLayerInfo stores the LayerInfo for the packed layer in the Kitfile entry
+	setLayerInfo func(*artifact.LayerInfo)
+}
+
+func saveKitfileLayers(ctx context.Context, store oras.Target, kitfile *artifact.KitFile, ignore ignore.Paths, opts *SaveModelOptions) (layers []ocispec.Descriptor, diffIDs []digest.Digest, err error) {
+	toPack := kitfileLayersToPack(kitfile)
+
+	// Layers are packed concurrently, but stored by index so that the order of layers in the manifest
+	// matches the order of entries in the Kitfile.
+	packedLayers := make([][]ocispec.Descriptor, len(toPack))
+	layerInfos := make([]*artifact.LayerInfo, len(toPack))
+	progress := output.NewPackProgress(ctx)
+	errs, errCtx := errgroup.WithContext(ctx)
+	errs.SetLimit(max(opts.Concurrency, 1))
+	for idx, layer := range toPack {
+		errs.Go(func() error {
+			mediaType := layerMediaType(opts, layer.baseType, layer.path)
+			descs, layerInfo, err := saveContentLayer(errCtx, store, layer.path, mediaType, ignore, opts, progress)
 			if err != nil {
-				return nil, nil, err
+				return err
 			}
-			layers = append(layers, layer)
-			diffIDs = append(diffIDs, digest.FromString(layerInfo.DiffId))
-			kitfile.Model.LayerInfo = layerInfo
+			if layer.isPrompt {
+				for descIdx := range descs {
+					if descs[descIdx].Annotations == nil {
+						descs[descIdx].Annotations = map[string]string{}
+					}
+					descs[descIdx].Annotations[constants.LayerSubtypeAnnotation] = constants.LayerSubtypePrompt
+				}
+			}
+			packedLayers[idx] = descs
+			layerInfos[idx] = layerInfo
+			return nil
+		})
+	}
+	err = errs.Wait()
+	progress.Done()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for idx, layer := range toPack {
+		layers = append(layers, packedLayers[idx]...)
+		// DiffId is already a digest of the uncompressed layer; ModelPack configs packed by older versions of
+		// Kit instead list the digest of this string, so their config digests differ from current ones
+		diffIDs = append(diffIDs, digest.Digest(layerInfos[idx].DiffId))
+		layer.setLayerInfo(layerInfos[idx])
+	}
+	return layers, diffIDs, nil
+}
+
+// kitfileLayersToPack returns the layers that need to be packed for kitfile, in the order they should
+// appear in the manifest.
+func kitfileLayersToPack(kitfile *artifact.KitFile) []kitfileLayer {
+	var toPack []kitfileLayer
+	if kitfile.Model != nil {
+		if kitfile.Model.Path != "" && !util.IsModelKitReference(kitfile.Model.Path) {
+			toPack = append(toPack, kitfileLayer{
+				path:         kitfile.Model.Path,
+				baseType:     mediatype.ModelBaseType,
+				setLayerInfo: func(info *artifact.LayerInfo) { kitfile.Model.LayerInfo = info },
+			})
 		}
 		for idx, part := range kitfile.Model.Parts {
-			mediaType := mediatype.New(opts.ModelFormat, mediatype.ModelPartBaseType, opts.LayerFormat, opts.Compression)
-			layer, layerInfo, err := saveContentLayer(ctx, localRepo, part.Path, mediaType, ignore)
-			if err != nil {
-				return nil, nil, err
-			}
-			layers = append(layers, layer)
-			diffIDs = append(diffIDs, digest.FromString(layerInfo.DiffId))
-			kitfile.Model.Parts[idx].LayerInfo = layerInfo
+			toPack = append(toPack, kitfileLayer{
+				path:         part.Path,
+				baseType:     mediatype.ModelPartBaseType,
+				setLayerInfo: func(info *artifact.LayerInfo) { kitfile.Model.Parts[idx].LayerInfo = info },
+			})
 		}
 	}
 	for idx, code := range kitfile.Code {
-		mediaType := mediatype.New(opts.ModelFormat, mediatype.CodeBaseType, opts.LayerFormat, opts.Compression)
-		layer, layerInfo, err := saveContentLayer(ctx, localRepo, code.Path, mediaType, ignore)
-		if err != nil {
-			return nil, nil, err
-		}
-		layers = append(layers, layer)
-		diffIDs = append(diffIDs, digest.FromString(layerInfo.DiffId))
-		kitfile.Code[idx].LayerInfo = layerInfo
+		toPack = append(toPack, kitfileLayer{
+			path:         code.Path,
+			baseType:     mediatype.CodeBaseType,
+			setLayerInfo: func(info *artifact.LayerInfo) { kitfile.Code[idx].LayerInfo = info },
+		})
 	}
 	for idx, dataset := range kitfile.DataSets {
-		mediaType := mediatype.New(opts.ModelFormat, mediatype.DatasetBaseType, opts.LayerFormat, opts.Compression)
-		layer, layerInfo, err := saveContentLayer(ctx, localRepo, dataset.Path, mediaType, ignore)
-		if err != nil {
-			return nil, nil, err
-		}
-		layers = append(layers, layer)
-		diffIDs = append(diffIDs, digest.FromString(layerInfo.DiffId))
-		kitfile.DataSets[idx].LayerInfo = layerInfo
+		toPack = append(toPack, kitfileLayer{
+			path:         dataset.Path,
+			baseType:     mediatype.DatasetBaseType,
+			setLayerInfo: func(info *artifact.LayerInfo) { kitfile.DataSets[idx].LayerInfo = info },
+		})
 	}
 	for idx, docs := range kitfile.Docs {
-		mediaType := mediatype.New(opts.ModelFormat, mediatype.DocsBaseType, opts.LayerFormat, opts.Compression)
-		layer, layerInfo, err := saveContentLayer(ctx, localRepo, docs.Path, mediaType, ignore)
-		if err != nil {
-			return nil, nil, err
-		}
-		layers = append(layers, layer)
-		diffIDs = append(diffIDs, digest.FromString(layerInfo.DiffId))
-		kitfile.Docs[idx].LayerInfo = layerInfo
+		toPack = append(toPack, kitfileLayer{
+			path:         docs.Path,
+			baseType:     mediatype.DocsBaseType,
+			setLayerInfo: func(info *artifact.LayerInfo) { kitfile.Docs[idx].LayerInfo = info },
+		})
 	}
 	for idx, prompt := range kitfile.Prompts {
 		// Prompt layers are saved as `code` layers with an annotation to distinguish them
-		mediaType := mediatype.New(opts.ModelFormat, mediatype.CodeBaseType, opts.LayerFormat, opts.Compression)
-		layer, layerInfo, err := saveContentLayer(ctx, localRepo, prompt.Path, mediaType, ignore)
+		toPack = append(toPack, kitfileLayer{
+			path:         prompt.Path,
+			baseType:     mediatype.CodeBaseType,
+			isPrompt:     true,
+			setLayerInfo: func(info *artifact.LayerInfo) { kitfile.Prompts[idx].LayerInfo = info },
+		})
+	}
+	return toPack
+}
+
+// layerMediaType returns the media type to use for the Kitfile entry at path. Raw layers can only store
+// a single file, so entries that refer to directories are always packed as tar layers.
+func layerMediaType(opts *SaveModelOptions, baseType mediatype.BaseType, path string) mediatype.MediaType {
+	if opts.LayerFormat == mediatype.RawFormat && isRawLayerPath(path) {
+		return mediatype.New(opts.ModelFormat, baseType, mediatype.RawFormat, mediatype.NoneCompression)
+	}
+	return mediatype.New(opts.ModelFormat, baseType, mediatype.TarFormat, opts.Compression)
+}
+
+// saveContentLayer packs the Kitfile entry at path into a layer and saves it to store. It returns
+// the descriptors for the layer as they should appear in the manifest: if the layer is split into chunks,
+// one descriptor is returned for each chunk. If opts.UseLayerCache is true and the files for the entry are
+// unchanged since it was last packed, the previously saved layer is reused.
+func saveContentLayer(ctx context.Context, store oras.Target, path string, mediaType mediatype.MediaType, ignore ignore.Paths, opts *SaveModelOptions, progress *output.PackProgress) ([]ocispec.Descriptor, *artifact.LayerInfo, error) {
+	if !opts.UseLayerCache {
+		return packContentLayer(ctx, store, path, mediaType, ignore, opts, progress)
+	}
+
+	cacheName, err := layerCacheName(path, mediaType, opts)
+	if err != nil {
+		progress.Debugf("Skipping layer cache for %s: %s", path, err)
+		return packContentLayer(ctx, store, path, mediaType, ignore, opts, progress)
+	}
+	fingerprint, err := layerFingerprint(path, mediaType, ignore, opts, &progress.ProgressLogger)
+	if err != nil {
+		progress.Debugf("Skipping layer cache for %s: %s", path, err)
+		return packContentLayer(ctx, store, path, mediaType, ignore, opts, progress)
+	}
+	if descs, layerInfo, ok := readLayerCache(ctx, store, cacheName, fingerprint, &progress.ProgressLogger); ok {
+		progress.Infof("Reusing cached %s layer: %s", mediaType.UserString(), layerInfo.Digest)
+		return descs, layerInfo, nil
+	}
+
+	descs, layerInfo, err := packContentLayer(ctx, store, path, mediaType, ignore, opts, progress)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writeLayerCache(cacheName, fingerprint, descs, layerInfo); err != nil {
+		progress.Logf(output.LogLevelWarn, "Failed to update layer cache for %s: %s", path, err)
+	}
+	return descs, layerInfo, nil
+}
+
+// packContentLayer packs the Kitfile entry at path into a layer and saves it to store, without
+// using the layer cache.
+func packContentLayer(ctx context.Context, store oras.Target, path string, mediaType mediatype.MediaType, ignore ignore.Paths, opts *SaveModelOptions, progress *output.PackProgress) ([]ocispec.Descriptor, *artifact.LayerInfo, error) {
+	if mediaType.Format() == mediatype.RawFormat {
+		desc, info, err := saveRawLayer(ctx, store, path, mediaType, ignore, opts, progress)
 		if err != nil {
 			return nil, nil, err
 		}
-		if layer.Annotations == nil {
-			layer.Annotations = map[string]string{}
-		}
-		layer.Annotations[constants.LayerSubtypeAnnotation] = constants.LayerSubtypePrompt
-		layers = append(layers, layer)
-		diffIDs = append(diffIDs, digest.FromString(layerInfo.DiffId))
-		kitfile.Prompts[idx].LayerInfo = layerInfo
+		return []ocispec.Descriptor{desc}, info, nil
+	}
+	if mediaType.Format() != mediatype.TarFormat {
+		return nil, nil, fmt.Errorf("unsupported layer format for %s layer", mediaType.UserString())
 	}
 
-	return layers, diffIDs, nil
-}
+	// Chunked layers are split after packing, so they always need to be saved to a temporary file first
+	if streamer, ok := store.(blobStreamer); ok && opts.ChunkSize == 0 && streamer.CanStreamBlobs() {
+		desc, info, err := streamContentLayer(ctx, streamer, path, mediaType, ignore, opts, progress)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []ocispec.Descriptor{desc}, info, nil
+	}
 
-func saveContentLayer(ctx context.Context, localRepo local.LocalRepo, path string, mediaType mediatype.MediaType, ignore ignore.Paths) (ocispec.Descriptor, *artifact.LayerInfo, error) {
-	// We want to store a gzipped tar file in store, but to do so we need a descriptor, so we have to compress
+	// We want to store a compressed tar file in store, but to do so we need a descriptor, so we have to compress
 	// to a temporary file. Ideally, we can also add this to the internal store by moving the file to avoid
 	// copying if possible.
-	if mediaType.Format() != mediatype.TarFormat {
-		// TODO: Add support for ModelPack's "raw" layer type
-		return ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("Only tar-formatted layers are currently supported")
-	}
-	tempPath, desc, info, err := packLayerToTar(path, mediaType, ignore)
+	tempPath, desc, info, err := packLayerToTar(path, mediaType, ignore, opts, progress)
 	if err != nil {
-		return ocispec.DescriptorEmptyJSON, nil, err
+		return nil, nil, err
 	}
 
 	defer func() {
 		if err := os.Remove(tempPath); err != nil && !errors.Is(err, os.ErrNotExist) {
-			output.Errorf("Failed to remove temporary file %s: %s", tempPath, err)
+			progress.Logf(output.LogLevelError, "Failed to remove temporary file %s: %s", tempPath, err)
 		}
 	}()
 
-	if exists, err := localRepo.Exists(ctx, desc); err != nil {
-		return ocispec.DescriptorEmptyJSON, nil, err
+	if opts.ChunkSize > 0 && desc.Size > opts.ChunkSize {
+		chunks, err := saveLayerChunks(ctx, store, tempPath, desc, opts.ChunkSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		progress.Infof("Saved %s layer in %d chunks: %s", mediaType.UserString(), len(chunks), desc.Digest)
+		return chunks, info, nil
+	}
+
+	if exists, err := store.Exists(ctx, desc); err != nil {
+		return nil, nil, err
 	} else if exists {
-		output.Infof("Already saved %s layer: %s", mediaType.UserString(), desc.Digest)
-		return desc, info, nil
+		progress.Infof("Already saved %s layer: %s", mediaType.UserString(), desc.Digest)
+		return []ocispec.Descriptor{desc}, info, nil
 	}
 
-	// Workaround to avoid copying a potentially very large file: move it to the expected path
-	// and verify that it exists afterwards.
-	if err := localRepo.EnsureDirs(desc); err != nil {
+	// When saving to local storage, avoid copying a potentially very large file by moving it to the
+	// expected path instead. Other targets (e.g. remote registries) receive the file as an upload.
+	if localRepo, ok := store.(local.LocalRepo); ok {
+		if err := moveLayerToStorage(ctx, localRepo, tempPath, desc, progress); err != nil {
+			return nil, nil, err
+		}
+	} else if err := pushLayerFile(ctx, store, tempPath, desc); err != nil {
+		return nil, nil, err
+	}
+
+	progress.Infof("Saved %s layer: %s", mediaType.UserString(), desc.Digest)
+	return []ocispec.Descriptor{desc}, info, nil
+}
+
+// errUploadStopped is returned to a layer that is being streamed if the upload ends before the layer is packed.
+var errUploadStopped = errors.New("upload stopped before layer was packed")
+
+// blobStreamer is implemented by stores that can save blobs whose size and digest are not known until all of their
+// content has been written, such as remote repositories that support chunked uploads.
+type blobStreamer interface {
+	CanStreamBlobs() bool
+	PushBlobStream(ctx context.Context, mediaType string, content io.Reader) (ocispec.Descriptor, error)
+}
+
+// streamContentLayer packs the Kitfile entry at path into a tar layer and streams it to store as it is packed,
+// without saving it to disk first.
+func streamContentLayer(ctx context.Context, store blobStreamer, path string, mediaType mediatype.MediaType, ignore ignore.Paths, opts *SaveModelOptions, progress *output.PackProgress) (ocispec.Descriptor, *artifact.LayerInfo, error) {
+	path = filepath.Clean(path)
+	progress.Debugf("Streaming %s layer to remote storage", mediaType.UserString())
+
+	pr, pw := io.Pipe()
+	type packResult struct {
+		size      int64
+		layerInfo *artifact.LayerInfo
+		err       error
+	}
+	packed := make(chan packResult, 1)
+	go func() {
+		size, layerInfo, err := writeLayer(pw, path, mediaType, ignore, opts, progress)
+		// A nil error closes the pipe normally, signalling the end of the layer to the upload
+		pw.CloseWithError(err)
+		packed <- packResult{size, layerInfo, err}
+	}()
+
+	desc, pushErr := store.PushBlobStream(ctx, mediaType.String(), pr)
+	// Unblock packing if the upload stopped reading before the end of the layer
+	pr.CloseWithError(errUploadStopped)
+	result := <-packed
+	if result.err != nil && !errors.Is(result.err, errUploadStopped) {
+		return ocispec.DescriptorEmptyJSON, nil, result.err
+	}
+	if pushErr != nil {
+		return ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("failed to upload %s layer: %w", mediaType.UserString(), pushErr)
+	}
+	if desc.Digest.String() != result.layerInfo.Digest || desc.Size != result.size {
+		return ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("uploaded %s layer does not match packed layer: expected %s (%d bytes), uploaded %s (%d bytes)",
+			mediaType.UserString(), result.layerInfo.Digest, result.size, desc.Digest, desc.Size)
+	}
+	if err := fillDescAnnotations(&desc, path, nil); err != nil {
 		return ocispec.DescriptorEmptyJSON, nil, err
 	}
+	progress.Infof("Saved %s layer: %s", mediaType.UserString(), desc.Digest)
+	return desc, result.layerInfo, nil
+}
+
+// moveLayerToStorage moves the layer file at tempPath to the expected path in localRepo and verifies that it
+// exists afterwards. If the file cannot be moved, it is copied instead.
+func moveLayerToStorage(ctx context.Context, localRepo local.LocalRepo, tempPath string, desc ocispec.Descriptor, progress *output.PackProgress) error {
+	if err := localRepo.EnsureDirs(desc); err != nil {
+		return err
+	}
 	blobPath := localRepo.BlobPath(desc)
 	if err := os.Rename(tempPath, blobPath); err != nil {
 		// This may fail on some systems (e.g. linux where / and /home are different partitions)
 		// Fallback to regular push which is basically a copy
-		output.Debugf("Failed to move temp file into storage (will copy instead): %s", err)
-		file, err := os.Open(tempPath)
-		if err != nil {
-			return ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("failed to open temporary file: %w", err)
-		}
-		defer file.Close()
-		if err := localRepo.Push(ctx, desc, file); err != nil {
-			return ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("failed to add layer to storage: %w", err)
+		progress.Debugf("Failed to move temp file into storage (will copy instead): %s", err)
+		if err := pushLayerFile(ctx, localRepo, tempPath, desc); err != nil {
+			return err
 		}
 	}
 
 	// Verify blob is in store now
 	exists, err := localRepo.Exists(ctx, desc)
 	if err != nil {
-		return ocispec.DescriptorEmptyJSON, nil, err
+		return err
 	}
 	if !exists {
-		return ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("failed to move layer to storage: file is not stored")
+		return fmt.Errorf("failed to move layer to storage: file is not stored")
 	}
+	return nil
+}
 
-	output.Infof("Saved %s layer: %s", mediaType.UserString(), desc.Digest)
-	return desc, info, nil
+// pushLayerFile pushes the layer file at path to store.
+func pushLayerFile(ctx context.Context, store oras.Target, path string, desc ocispec.Descriptor) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open temporary file: %w", err)
+	}
+	defer file.Close()
+	// Another layer with identical contents may have been saved concurrently
+	if err := store.Push(ctx, desc, file); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return fmt.Errorf("failed to add layer to storage: %w", err)
+	}
+	return nil
 }
 
 func saveModelManifest(ctx context.Context, store oras.Target, manifest ocispec.Manifest) (*ocispec.Descriptor, error) {
@@ -293,9 +524,9 @@ func saveModelManifest(ctx context.Context, store oras.Target, manifest ocispec.
 	return &desc, nil
 }
 
-func createManifest(configDesc ocispec.Descriptor, layerDescs []ocispec.Descriptor, modelFormat mediatype.ModelFormat) (ocispec.Manifest, error) {
+func createManifest(configDesc ocispec.Descriptor, layerDescs []ocispec.Descriptor, opts *SaveModelOptions) (ocispec.Manifest, error) {
 	var manifest ocispec.Manifest
-	switch modelFormat {
+	switch opts.ModelFormat {
 	case mediatype.KitFormat:
 		manifest = ocispec.Manifest{
 			Versioned:    specs.Versioned{SchemaVersion: 2},
@@ -318,7 +549,9 @@ func createManifest(configDesc ocispec.Descriptor, layerDescs []ocispec.Descript
 	if manifest.Annotations == nil {
 		manifest.Annotations = map[string]string{}
 	}
-	manifest.Annotations[constants.CliVersionAnnotation] = constants.Version
+	if !opts.OmitVersionAnnotation {
+		manifest.Annotations[constants.CliVersionAnnotation] = constants.Version
+	}
 
 	return manifest, nil
 }
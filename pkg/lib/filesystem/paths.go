@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/kitops-ml/kitops/pkg/lib/constants"
 	"github.com/kitops-ml/kitops/pkg/lib/filesystem/ignore"
@@ -75,6 +76,70 @@ func VerifySubpath(context, subDir string) (absPath, relPath string, err error)
 	return fullPath, relPath, nil
 }
 
+// maxSymlinks is the maximum number of symlinks followed by ResolveInRoot before giving up
+const maxSymlinks = 255
+
+// ResolveInRoot resolves path, relative to root, by following symlinks one component at a time and
+// returns the result relative to root. Unlike filepath.EvalSymlinks, '..' is applied to the resolved
+// path rather than lexically, so a chain of symlinks (e.g. s -> . and l -> s/..) cannot be used to
+// escape root. Components that do not exist are resolved lexically, but may not be followed by '..',
+// since a symlink created there later could otherwise change where the path points.
+func ResolveInRoot(root, path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return "", fmt.Errorf("absolute paths are not supported (%s)", path)
+	}
+	var resolved []string
+	remaining := strings.Split(filepath.ToSlash(path), "/")
+	linksFollowed := 0
+	missing := false
+	for len(remaining) > 0 {
+		part := remaining[0]
+		remaining = remaining[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if missing {
+				return "", fmt.Errorf("path %s contains '..' after a path that does not exist", path)
+			}
+			if len(resolved) == 0 {
+				return "", fmt.Errorf("path %s resolves outside of %s", path, root)
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+		resolved = append(resolved, part)
+		if missing {
+			continue
+		}
+		current := filepath.Join(append([]string{root}, resolved...)...)
+		fi, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+			missing = true
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("failed to check path %s: %w", current, err)
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		linksFollowed++
+		if linksFollowed > maxSymlinks {
+			return "", fmt.Errorf("too many levels of symlinks resolving %s", path)
+		}
+		target, err := os.Readlink(current)
+		if err != nil {
+			return "", fmt.Errorf("failed to read symlink %s: %w", current, err)
+		}
+		if filepath.IsAbs(target) {
+			return "", fmt.Errorf("path %s resolves through absolute symlink %s", path, current)
+		}
+		resolved = resolved[:len(resolved)-1]
+		remaining = append(strings.Split(filepath.ToSlash(target), "/"), remaining...)
+	}
+	return filepath.Join(append([]string{"."}, resolved...)...), nil
+}
+
 func PathExists(path string) (fs.FileInfo, bool) {
 	fi, err := os.Stat(path)
 	if err != nil && os.IsNotExist(err) {
@@ -0,0 +1,123 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/kitops-ml/kitops/pkg/artifact"
+	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
+	"github.com/kitops-ml/kitops/pkg/lib/filesystem/ignore"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// isRawLayerPath returns true if the path for a Kitfile entry can be stored as a raw (untarred)
+// layer, i.e. if it refers to a single regular file.
+func isRawLayerPath(path string) bool {
+	fi, err := os.Stat(filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return fi.Mode().IsRegular()
+}
+
+// saveRawLayer stores the file at path as a layer blob as-is, without tar framing or compression.
+// The file's path is recorded in the descriptor's annotations so that it can be restored when unpacking.
+func saveRawLayer(ctx context.Context, store oras.Target, path string, mediaType mediatype.MediaType, ignore ignore.Paths, opts *SaveModelOptions, progress *output.PackProgress) (ocispec.Descriptor, *artifact.LayerInfo, error) {
+	path = filepath.Clean(path)
+	if mediaType.Compression() != mediatype.NoneCompression {
+		return ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("raw layers do not support compression")
+	}
+	if layerIgnored, err := ignore.Matches(path, path); err != nil {
+		return ocispec.DescriptorEmptyJSON, nil, err
+	} else if layerIgnored {
+		progress.Logf(output.LogLevelWarn, "%s layer path %s ignored by kitignore", mediaType.UserString(), path)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("error processing %s: %w", mediaType.UserString(), err)
+	}
+	if !fi.Mode().IsRegular() {
+		return ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("path %s is not a regular file and cannot be stored as a raw layer", path)
+	}
+
+	fileDigest, err := digestFile(path)
+	if err != nil {
+		return ocispec.DescriptorEmptyJSON, nil, err
+	}
+	desc := ocispec.Descriptor{
+		MediaType: mediaType.String(),
+		Digest:    fileDigest,
+		Size:      fi.Size(),
+	}
+	var fileMeta fs.FileInfo = fi
+	if opts.Reproducible {
+		fileMeta = reproducibleFileInfo{FileInfo: fi, modTime: opts.timestamp()}
+	}
+	if err := fillDescAnnotations(&desc, filepath.ToSlash(path), fileMeta); err != nil {
+		return ocispec.DescriptorEmptyJSON, nil, err
+	}
+	layerInfo := &artifact.LayerInfo{
+		Digest: fileDigest.String(),
+		DiffId: fileDigest.String(),
+	}
+
+	if exists, err := store.Exists(ctx, desc); err != nil {
+		return ocispec.DescriptorEmptyJSON, nil, err
+	} else if exists {
+		progress.Infof("Already saved %s layer: %s", mediaType.UserString(), desc.Digest)
+		return desc, layerInfo, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+	// Another layer with identical contents may have been saved concurrently
+	if err := store.Push(ctx, desc, file); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("failed to add layer to storage: %w", err)
+	}
+
+	progress.Infof("Saved %s layer: %s", mediaType.UserString(), desc.Digest)
+	return desc, layerInfo, nil
+}
+
+func digestFile(path string) (digest.Digest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+	digester := digest.Canonical.Digester()
+	if _, err := io.Copy(digester.Hash(), file); err != nil {
+		return "", fmt.Errorf("failed to compute digest for %s: %w", path, err)
+	}
+	return digester.Digest(), nil
+}
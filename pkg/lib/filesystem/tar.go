@@ -24,6 +24,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -33,55 +34,80 @@ import (
 	"github.com/kitops-ml/kitops/pkg/lib/filesystem/ignore"
 	"github.com/kitops-ml/kitops/pkg/output"
 
+	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/pgzip"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
-// packLayerToTar compresses an *artifact.ModelLayer to a gzipped tar file. In order to return
-// a descriptor (including hash) for the compressed file, the layer is saved to a temporary file
+// compressionBlockSize is the size of blocks compressed in parallel when using parallel gzip compression
+const compressionBlockSize = 1 << 20
+
+// packLayerToTar writes an *artifact.ModelLayer to a tar file, compressed according to mediaType. In order
+// to return a descriptor (including hash) for the compressed file, the layer is saved to a temporary file
 // on disk and must be moved to an appropriate location. It is the responsibility of the caller
 // to clean up the temporary file when it is no longer needed.
-func packLayerToTar(path string, mediaType mediatype.MediaType, ignore ignore.Paths) (tempFilePath string, desc ocispec.Descriptor, layerInfo *artifact.LayerInfo, err error) {
+func packLayerToTar(path string, mediaType mediatype.MediaType, ignore ignore.Paths, opts *SaveModelOptions, progress *output.PackProgress) (tempFilePath string, desc ocispec.Descriptor, layerInfo *artifact.LayerInfo, err error) {
 	// Clean path to ensure consistent format (./path vs path/ vs path)
 	path = filepath.Clean(path)
 
-	if layerIgnored, err := ignore.Matches(path, path); err != nil {
+	tempFile, tempFileCleanup, err := cache.MkCacheFile(cache.CachePackSubdir, "kitops_layer_")
+	if err != nil {
+		return "", ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tempFileName := tempFile.Name()
+	progress.Debugf("Compressing %s layer to temporary file %s", mediaType.UserString(), tempFileName)
+
+	size, layerInfo, err := writeLayer(tempFile, path, mediaType, ignore, opts, progress)
+	if err != nil {
+		tempFileCleanup()
+		return "", ocispec.DescriptorEmptyJSON, nil, err
+	}
+	if err := tempFile.Close(); err != nil {
+		tempFileCleanup()
+		return "", ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	desc = ocispec.Descriptor{
+		MediaType: mediaType.String(),
+		Digest:    digest.Digest(layerInfo.Digest),
+		Size:      size,
+	}
+	if err := fillDescAnnotations(&desc, path, nil); err != nil {
 		return "", ocispec.DescriptorEmptyJSON, nil, err
+	}
+	return tempFileName, desc, layerInfo, nil
+}
+
+// writeLayer writes the tar layer for path to w, compressed according to mediaType. It returns the size of the
+// data written to w, along with a LayerInfo recording its digest and the digest of the uncompressed tar.
+func writeLayer(w io.Writer, path string, mediaType mediatype.MediaType, ignore ignore.Paths, opts *SaveModelOptions, progress *output.PackProgress) (size int64, layerInfo *artifact.LayerInfo, err error) {
+	if layerIgnored, err := ignore.Matches(path, path); err != nil {
+		return 0, nil, err
 	} else if layerIgnored {
-		output.Errorf("Warning: %s layer path %s ignored by kitignore", mediaType.UserString(), path)
+		progress.Logf(output.LogLevelWarn, "%s layer path %s ignored by kitignore", mediaType.UserString(), path)
 	}
 
 	totalSize, err := getTotalSize(path, ignore)
 	if err != nil {
-		return "", ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("error processing %s: %w", mediaType.UserString(), err)
+		return 0, nil, fmt.Errorf("error processing %s: %w", mediaType.UserString(), err)
 	}
 	if totalSize == 0 {
-		output.Logf(output.LogLevelWarn, "No files detected in %s layer with path %s", mediaType.UserString(), path)
-	}
-
-	tempFile, tempFileCleanup, err := cache.MkCacheFile(cache.CachePackSubdir, "kitops_layer_")
-	if err != nil {
-		return "", ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("failed to create temporary file: %w", err)
+		progress.Logf(output.LogLevelWarn, "No files detected in %s layer with path %s", mediaType.UserString(), path)
 	}
-	tempFileName := tempFile.Name()
-	output.Debugf("Compressing layer to temporary file %s", tempFileName)
 
 	digester := digest.Canonical.Digester()
 	var diffIdDigester digest.Digester
-	fileWriter := io.MultiWriter(tempFile, digester.Hash())
+	counter := &countingWriter{}
+	fileWriter := io.MultiWriter(w, digester.Hash(), counter)
 
 	var compressedWriter io.WriteCloser
 	var tarWriter *tar.Writer
 	switch mediaType.Compression() {
-	case mediatype.GzipCompression:
-		compressedWriter = gzip.NewWriter(fileWriter)
-		diffIdDigester = digest.Canonical.Digester()
-		mw := io.MultiWriter(compressedWriter, diffIdDigester.Hash())
-		tarWriter = tar.NewWriter(mw)
-	case mediatype.GzipFastestCompression:
-		compressedWriter, err = gzip.NewWriterLevel(fileWriter, gzip.BestSpeed)
+	case mediatype.GzipCompression, mediatype.GzipFastestCompression, mediatype.ZstdCompression:
+		compressedWriter, err = newCompressedWriter(fileWriter, mediaType.Compression(), opts.CompressionLevel, opts.ParallelGzip)
 		if err != nil {
-			return "", ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("failed to set up gzip compression: %w", err)
+			return 0, nil, err
 		}
 		diffIdDigester = digest.Canonical.Digester()
 		mw := io.MultiWriter(compressedWriter, diffIdDigester.Hash())
@@ -90,51 +116,130 @@ func packLayerToTar(path string, mediaType mediatype.MediaType, ignore ignore.Pa
 		tarWriter = tar.NewWriter(fileWriter)
 		diffIdDigester = digester
 	default:
-		return "", ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("Unsupported compression format: %s", mediaType.Compression())
+		return 0, nil, fmt.Errorf("Unsupported compression format: %s", mediaType.Compression())
 	}
-	progressTarWriter, plog := output.TarProgress(totalSize, tarWriter)
+	progressTarWriter := progress.TarProgress(mediaType.UserString(), totalSize, tarWriter)
 
-	if err := writeLayerToTar(path, ignore, progressTarWriter, plog); err != nil {
-		// Don't care about these errors since we'll be deleting the file anyways
+	if err := writeLayerToTar(path, ignore, opts, progressTarWriter, &progress.ProgressLogger); err != nil {
+		// Don't care about these errors since the layer is discarded anyways
+		progressTarWriter.Abort()
 		_ = progressTarWriter.Close()
 		_ = tarWriter.Close()
 		if compressedWriter != nil {
 			_ = compressedWriter.Close()
 		}
-		tempFileCleanup()
+		return 0, nil, fmt.Errorf("failed to pack %s layer: %w", mediaType.UserString(), err)
 	}
-	plog.Wait()
 
 	callAndPrintError(progressTarWriter.Close, "Failed to close writer: %s")
-	callAndPrintError(tarWriter.Close, "Failed to close tar writer: %s")
-	if compressedWriter != nil {
-		callAndPrintError(compressedWriter.Close, "Failed to close compression writer: %s")
-	}
-
-	tempFileInfo, err := tempFile.Stat()
-	if err != nil {
-		tempFileCleanup()
-		return "", ocispec.DescriptorEmptyJSON, nil, fmt.Errorf("failed to stat temporary file: %w", err)
-	}
-	callAndPrintError(tempFile.Close, "Failed to close temporary file: %s")
-
-	desc = ocispec.Descriptor{
-		MediaType: mediaType.String(),
-		Digest:    digester.Digest(),
-		Size:      tempFileInfo.Size(),
+	if err := tarWriter.Close(); err != nil {
+		return 0, nil, fmt.Errorf("failed to write %s layer: %w", mediaType.UserString(), err)
 	}
-	if err := fillDescAnnotations(&desc, path, nil); err != nil {
-		return "", ocispec.DescriptorEmptyJSON, nil, err
+	if compressedWriter != nil {
+		if err := compressedWriter.Close(); err != nil {
+			return 0, nil, fmt.Errorf("failed to write %s layer: %w", mediaType.UserString(), err)
+		}
 	}
 
 	layerInfo = &artifact.LayerInfo{
 		Digest: digester.Digest().String(),
 		DiffId: diffIdDigester.Digest().String(),
 	}
-	return tempFileName, desc, layerInfo, nil
+	return counter.n, layerInfo, nil
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
 }
 
-func writeLayerToTar(basePath string, ignore ignore.Paths, tarWriter *output.ProgressTar, plog *output.ProgressLogger) error {
+// newCompressedWriter wraps w in a writer that compresses data according to compression. If level
+// is zero, the default level for the compression format is used. Zstd compression is split across all
+// available CPUs. Gzip compression uses compress/gzip unless parallelGzip is set, in which case it is
+// also split across all available CPUs; the compressed output does not depend on the number of CPUs
+// used. The returned writer must be closed to flush any buffered data.
+//
+// Parallel gzip compression produces different output than compress/gzip, which is used by default so
+// that gzip layer digests match those of layers packed by earlier versions of Kit.
+func newCompressedWriter(w io.Writer, compression mediatype.CompressionType, level int, parallelGzip bool) (io.WriteCloser, error) {
+	switch compression {
+	case mediatype.GzipCompression, mediatype.GzipFastestCompression:
+		if compression == mediatype.GzipFastestCompression {
+			level = gzip.BestSpeed
+		} else if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		if !parallelGzip {
+			gw, err := gzip.NewWriterLevel(w, level)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set up gzip compression: %w", err)
+			}
+			return gw, nil
+		}
+		gw, err := pgzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up gzip compression: %w", err)
+		}
+		// Output depends on block size but not on the number of blocks compressed in parallel
+		if err := gw.SetConcurrency(compressionBlockSize, runtime.GOMAXPROCS(0)); err != nil {
+			return nil, fmt.Errorf("failed to set up gzip compression: %w", err)
+		}
+		return gw, nil
+	case mediatype.ZstdCompression:
+		zstdOpts := []zstd.EOption{zstd.WithEncoderConcurrency(runtime.GOMAXPROCS(0))}
+		if level != 0 {
+			zstdOpts = append(zstdOpts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		zw, err := zstd.NewWriter(w, zstdOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up zstd compression: %w", err)
+		}
+		return zw, nil
+	default:
+		return nil, fmt.Errorf("Unsupported compression format: %s", compression)
+	}
+}
+
+// writeLayerToTar writes the files under basePath to tarWriter. If opts.PreserveLinks is true, symlinks that
+// point to a path within basePath are stored as symlinks and files with multiple hardlinks are stored once,
+// with additional links stored as hardlinks to the first file. Otherwise, symlinks are skipped and
+// hardlinked files are stored as regular files.
+func writeLayerToTar(basePath string, ignore ignore.Paths, opts *SaveModelOptions, tarWriter *output.ProgressTar, plog *output.ProgressLogger) error {
+	// Paths of files already written to the tar, used to store additional hardlinks to a file as links
+	hardlinks := map[fileKey]string{}
+
+	return walkLayer(basePath, ignore, opts.PreserveLinks, plog, func(file string, fi os.FileInfo) error {
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return writeSymlinkToTar(basePath, file, fi, opts.Reproducible, tarWriter, plog)
+		}
+		if opts.PreserveLinks && fi.Mode().IsRegular() {
+			if key, ok := hardlinkKey(fi); ok {
+				if linkTarget, seen := hardlinks[key]; seen {
+					return writeHardlinkToTar(file, linkTarget, fi, opts.Reproducible, tarWriter, plog)
+				}
+				hardlinks[key] = file
+			}
+		}
+
+		if err := writeHeaderToTar(file, fi, opts.Reproducible, tarWriter, plog); err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		return writeFileToTar(file, fi, tarWriter, plog)
+	})
+}
+
+// walkLayer calls fn for each path that should be included in the layer for basePath, in the order they
+// should be stored. Paths are relative to the current working directory, which is expected to be the
+// context directory. Symlinks are only included if preserveLinks is true.
+func walkLayer(basePath string, ignore ignore.Paths, preserveLinks bool, plog *output.ProgressLogger, fn func(file string, fi os.FileInfo) error) error {
 	// Make sure target path exists; otherwise we'll miss it while walking below
 	_, err := os.Stat(basePath)
 	if err != nil {
@@ -158,15 +263,16 @@ func writeLayerToTar(basePath string, ignore ignore.Paths, tarWriter *output.Pro
 		return true
 	}
 
-	err = filepath.Walk(".", func(file string, fi os.FileInfo, err error) error {
+	return filepath.Walk(".", func(file string, fi os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 		if file == "." {
 			return nil
 		}
-		// Skip anything that's not a regular file or directory
-		if !fi.Mode().IsRegular() && !fi.Mode().IsDir() {
+		// Skip anything that's not a regular file or directory (or a symlink, if links are preserved)
+		isSymlink := fi.Mode()&os.ModeSymlink != 0
+		if !fi.Mode().IsRegular() && !fi.Mode().IsDir() && !(preserveLinks && isSymlink) {
 			return nil
 		}
 		// Since we're walking from the context directory, we want to skip irrelevant files (e.g. sibling directories)
@@ -189,32 +295,63 @@ func writeLayerToTar(basePath string, ignore ignore.Paths, tarWriter *output.Pro
 			return nil
 		}
 
-		if err := writeHeaderToTar(file, fi, tarWriter, plog); err != nil {
-			return err
-		}
-		if fi.IsDir() {
-			return nil
-		}
-		return writeFileToTar(file, fi, tarWriter, plog)
+		return fn(file, fi)
 	})
+}
+
+func writeHeaderToTar(name string, fi os.FileInfo, normalizeModes bool, ptw *output.ProgressTar, plog *output.ProgressLogger) error {
+	header, err := tar.FileInfoHeader(fi, "")
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to generate header for %s: %w", name, err)
 	}
+	header.Name = name
+	sanitizeTarHeader(header, normalizeModes)
+	if err := ptw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	plog.Debugf("Wrote header %s to tar file", header.Name)
+	return nil
+}
 
+// writeSymlinkToTar writes a header for the symlink at file to the tar. The symlink must point to
+// a path within basePath.
+func writeSymlinkToTar(basePath, file string, fi os.FileInfo, normalizeModes bool, ptw *output.ProgressTar, plog *output.ProgressLogger) error {
+	target, err := os.Readlink(file)
+	if err != nil {
+		return fmt.Errorf("failed to read symlink %s: %w", file, err)
+	}
+	if err := verifySymlinkInLayer(basePath, file, target); err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(fi, target)
+	if err != nil {
+		return fmt.Errorf("failed to generate header for %s: %w", file, err)
+	}
+	header.Name = file
+	sanitizeTarHeader(header, normalizeModes)
+	if err := ptw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	plog.Debugf("Wrote symlink %s -> %s to tar file", header.Name, header.Linkname)
 	return nil
 }
 
-func writeHeaderToTar(name string, fi os.FileInfo, ptw *output.ProgressTar, plog *output.ProgressLogger) error {
+// writeHardlinkToTar writes a header for file to the tar as a hardlink to target, which must already
+// have been written to the tar.
+func writeHardlinkToTar(file, target string, fi os.FileInfo, normalizeModes bool, ptw *output.ProgressTar, plog *output.ProgressLogger) error {
 	header, err := tar.FileInfoHeader(fi, "")
 	if err != nil {
-		return fmt.Errorf("failed to generate header for %s: %w", name, err)
+		return fmt.Errorf("failed to generate header for %s: %w", file, err)
 	}
-	header.Name = name
-	sanitizeTarHeader(header)
+	header.Typeflag = tar.TypeLink
+	header.Name = file
+	header.Linkname = target
+	header.Size = 0
+	sanitizeTarHeader(header, normalizeModes)
 	if err := ptw.WriteHeader(header); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
-	plog.Debugf("Wrote header %s to tar file", header.Name)
+	plog.Debugf("Wrote hardlink %s -> %s to tar file", header.Name, header.Linkname)
 	return nil
 }
 
@@ -234,10 +371,14 @@ func writeFileToTar(file string, fi os.FileInfo, ptw *output.ProgressTar, plog *
 	return nil
 }
 
-func sanitizeTarHeader(header *tar.Header) {
+// sanitizeTarHeader clears fields in header that would prevent tar layers from being reproducible. If
+// normalizeModes is true, file modes are normalized as well, so that layers do not depend on e.g. the
+// umask used when files were created.
+func sanitizeTarHeader(header *tar.Header, normalizeModes bool) {
 	// On windows, store paths linux-style (forward slashes). This is a no-op if
 	// filepath.Separator is '/'
 	header.Name = filepath.ToSlash(header.Name)
+	header.Linkname = filepath.ToSlash(header.Linkname)
 	// Clear fields that break reproducible tars
 	header.AccessTime = time.Time{}
 	header.ModTime = time.Time{}
@@ -246,4 +387,7 @@ func sanitizeTarHeader(header *tar.Header) {
 	header.Gid = 0
 	header.Uname = ""
 	header.Gname = ""
+	if normalizeModes {
+		header.Mode = int64(normalizeFileMode(header.FileInfo().Mode()).Perm())
+	}
 }
@@ -0,0 +1,237 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package unpack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/output"
+)
+
+// unpackAtomic unpacks a ModelKit into a hidden staging directory inside opts.UnpackDir and moves the
+// unpacked files into place only once every layer is unpacked successfully. If unpacking fails, the
+// target directory is left unchanged. Staging inside the target directory rather than next to it keeps
+// renames on the same filesystem even when the target is a mount point, does not require its parent to
+// be writable, and leaves the target directory's own permissions and ownership untouched.
+func unpackAtomic(ctx context.Context, opts *UnpackOptions) error {
+	unpackDir := opts.UnpackDir
+	if unpackDir == "" {
+		unpackDir = "."
+	}
+	targetDir, err := filepath.Abs(unpackDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve unpack directory %s: %w", unpackDir, err)
+	}
+	if err := os.MkdirAll(targetDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", targetDir, err)
+	}
+	stagingDir, err := os.MkdirTemp(targetDir, stagingDirPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(stagingDir); err != nil {
+			output.Logf(output.LogLevelWarn, "Failed to remove staging directory %s: %s", stagingDir, err)
+		}
+	}()
+	if err := os.Chmod(stagingDir, 0o755); err != nil {
+		return fmt.Errorf("failed to set permissions on staging directory: %w", err)
+	}
+	output.Debugf("Unpacking to staging directory %s", stagingDir)
+
+	stagingOpts := *opts
+	stagingOpts.UnpackDir = stagingDir
+	stagingOpts.Atomic = false
+	if err := UnpackModelKit(ctx, &stagingOpts); err != nil {
+		return err
+	}
+	// Don't start moving files into place if the unpack was cancelled
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	output.Debugf("Moving unpacked files from %s to %s", stagingDir, targetDir)
+	return commitStagingDir(stagingDir, targetDir, opts.Overwrite, opts.IgnoreExisting)
+}
+
+const (
+	stagingDirPrefix = ".kit-unpack-staging-"
+	backupDirPrefix  = ".kit-unpack-backup-"
+)
+
+// commitStagingDir moves the contents of stagingDir into targetDir. All conflicts with existing files are
+// checked before anything is moved. If moving any file fails, all changes to targetDir are rolled back.
+// Files that are replaced are moved to a backup directory inside targetDir until the commit completes.
+func commitStagingDir(stagingDir, targetDir string, overwrite, ignoreExisting bool) error {
+	ops, err := planCommit(stagingDir, targetDir, targetDir, overwrite, ignoreExisting)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(ops, func(op commitOp) bool { return op.replace }) {
+		return applyCommit(ops)
+	}
+
+	backupDir, err := os.MkdirTemp(targetDir, backupDirPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(backupDir); err != nil {
+			output.Logf(output.LogLevelWarn, "Failed to remove backup directory %s: %s", backupDir, err)
+		}
+	}()
+	for idx := range ops {
+		if ops[idx].replace {
+			ops[idx].backup = filepath.Join(backupDir, strconv.Itoa(idx))
+		}
+	}
+	return applyCommit(ops)
+}
+
+// applyCommit applies ops in order, rolling back all applied ops if any of them fails.
+func applyCommit(ops []commitOp) error {
+	var done []commitOp
+	for _, op := range ops {
+		if err := op.apply(); err != nil {
+			return errors.Join(err, rollbackCommit(done))
+		}
+		done = append(done, op)
+	}
+	return nil
+}
+
+// commitOp moves a single file or directory from the staging directory into the target directory.
+type commitOp struct {
+	src string
+	dst string
+	// replace is true if an existing file at dst is overwritten
+	replace bool
+	// backup is the path that an existing file at dst is moved to so that it can be restored on rollback
+	backup string
+}
+
+func (op *commitOp) apply() error {
+	if op.replace {
+		if err := os.Rename(op.dst, op.backup); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", op.dst, err)
+		}
+	}
+	if err := os.Rename(op.src, op.dst); err != nil {
+		err = fmt.Errorf("failed to move unpacked file to %s: %w", op.dst, err)
+		if op.replace {
+			err = errors.Join(err, os.Rename(op.backup, op.dst))
+		}
+		return err
+	}
+	return nil
+}
+
+func (op *commitOp) revert() error {
+	if err := os.Rename(op.dst, op.src); err != nil {
+		return fmt.Errorf("failed to roll back %s: %w", op.dst, err)
+	}
+	if op.replace {
+		if err := os.Rename(op.backup, op.dst); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", op.dst, err)
+		}
+	}
+	return nil
+}
+
+// rollbackCommit reverts ops in reverse order, restoring any files that were overwritten.
+func rollbackCommit(ops []commitOp) error {
+	var errs []error
+	for _, op := range slices.Backward(ops) {
+		if err := op.revert(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// planCommit returns the operations required to move the contents of srcDir into dstDir. Directories that
+// do not exist in dstDir are moved as a whole; directories that already exist are merged. An error is returned
+// if any file conflicts with an existing file and neither overwrite nor ignoreExisting is set.
+func planCommit(srcDir, dstDir, targetDir string, overwrite, ignoreExisting bool) ([]commitOp, error) {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staging directory: %w", err)
+	}
+	var ops []commitOp
+	for _, entry := range entries {
+		src, dst := filepath.Join(srcDir, entry.Name()), filepath.Join(dstDir, entry.Name())
+		dstInfo, err := os.Lstat(dst)
+		if errors.Is(err, fs.ErrNotExist) {
+			ops = append(ops, commitOp{src: src, dst: dst})
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to check path %s: %w", dst, err)
+		}
+
+		if entry.IsDir() {
+			if !dstInfo.IsDir() {
+				return nil, fmt.Errorf("path '%s' already exists and is not a directory", dst)
+			}
+			subOps, err := planCommit(src, dst, targetDir, overwrite, ignoreExisting)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, subOps...)
+			continue
+		}
+		if dstInfo.IsDir() {
+			return nil, fmt.Errorf("path '%s' already exists and is a directory", dst)
+		}
+		// An existing Kitfile is kept if it matches the ModelKit's Kitfile, as when unpacking without staging
+		if dst == filepath.Join(targetDir, constants.DefaultKitfileName) && !overwrite {
+			if same, err := sameFileContents(src, dst); err != nil {
+				return nil, err
+			} else if same {
+				continue
+			}
+		}
+		if ignoreExisting {
+			output.Debugf("File %s already exists; skipping", dst)
+			continue
+		}
+		if !overwrite {
+			return nil, fmt.Errorf("path '%s' already exists", dst)
+		}
+		ops = append(ops, commitOp{src: src, dst: dst, replace: true})
+	}
+	return ops, nil
+}
+
+func sameFileContents(pathA, pathB string) (bool, error) {
+	contentsA, err := os.ReadFile(pathA)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", pathA, err)
+	}
+	contentsB, err := os.ReadFile(pathB)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", pathB, err)
+	}
+	return slices.Equal(contentsA, contentsB), nil
+}
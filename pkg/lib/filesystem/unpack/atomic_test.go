@@ -0,0 +1,131 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package unpack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitStagingDir(t *testing.T) {
+	writeFiles := func(t *testing.T, dir string, files map[string]string) {
+		for name, contents := range files {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+		}
+	}
+	readFiles := func(t *testing.T, dir string) map[string]string {
+		files := map[string]string{}
+		err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			relPath, _ := filepath.Rel(dir, path)
+			files[filepath.ToSlash(relPath)] = string(contents)
+			return nil
+		})
+		require.NoError(t, err)
+		return files
+	}
+
+	staged := map[string]string{
+		"Kitfile":        "kitfile",
+		"model/weights":  "new weights",
+		"data/train.csv": "new train",
+	}
+	tests := []struct {
+		name           string
+		existing       map[string]string
+		overwrite      bool
+		ignoreExisting bool
+		expected       map[string]string
+		errContains    string
+	}{
+		{
+			name:     "empty target",
+			expected: staged,
+		},
+		{
+			name:     "merge into existing directories",
+			existing: map[string]string{"data/test.csv": "test", "other": "other"},
+			expected: map[string]string{
+				"Kitfile": "kitfile", "model/weights": "new weights", "data/train.csv": "new train",
+				"data/test.csv": "test", "other": "other",
+			},
+		},
+		{
+			name:     "matching Kitfile is kept",
+			existing: map[string]string{"Kitfile": "kitfile"},
+			expected: staged,
+		},
+		{
+			name:        "conflict leaves target unchanged",
+			existing:    map[string]string{"data/test.csv": "test", "model/weights": "old weights"},
+			expected:    map[string]string{"data/test.csv": "test", "model/weights": "old weights"},
+			errContains: "already exists",
+		},
+		{
+			name:        "file conflicts with directory",
+			existing:    map[string]string{"other": "other", "model": "not a directory"},
+			expected:    map[string]string{"other": "other", "model": "not a directory"},
+			errContains: "not a directory",
+		},
+		{
+			name:      "overwrite existing files",
+			existing:  map[string]string{"Kitfile": "old kitfile", "model/weights": "old weights"},
+			overwrite: true,
+			expected:  staged,
+		},
+		{
+			name:           "ignore existing files",
+			existing:       map[string]string{"Kitfile": "old kitfile", "model/weights": "old weights"},
+			ignoreExisting: true,
+			expected:       map[string]string{"Kitfile": "old kitfile", "model/weights": "old weights", "data/train.csv": "new train"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			stagingDir, targetDir := filepath.Join(tmpDir, "staging"), filepath.Join(tmpDir, "target")
+			writeFiles(t, stagingDir, staged)
+			require.NoError(t, os.MkdirAll(targetDir, 0755))
+			writeFiles(t, targetDir, tt.existing)
+
+			err := commitStagingDir(stagingDir, targetDir, tt.overwrite, tt.ignoreExisting)
+			if tt.errContains != "" {
+				assert.ErrorContains(t, err, tt.errContains)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, readFiles(t, targetDir))
+			entries, err := os.ReadDir(targetDir)
+			require.NoError(t, err)
+			for _, entry := range entries {
+				assert.NotContains(t, entry.Name(), backupDirPrefix, "Backup directory should be removed")
+			}
+		})
+	}
+}
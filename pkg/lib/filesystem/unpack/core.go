@@ -20,9 +20,11 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"slices"
@@ -32,16 +34,46 @@ import (
 	"github.com/kitops-ml/kitops/pkg/lib/constants"
 	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
 	"github.com/kitops-ml/kitops/pkg/lib/filesystem"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/local"
 	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
 	"github.com/kitops-ml/kitops/pkg/output"
 
+	"github.com/klauspost/compress/zstd"
 	modelspecv1 "github.com/modelpack/model-spec/specs-go/v1"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
 	"oras.land/oras-go/v2/content"
 )
 
-// UnpackModelKit performs the core unpacking logic for a ModelKit.
+// UnpackModelKit performs the core unpacking logic for a ModelKit. If opts.Atomic is set, the ModelKit is
+// unpacked to a staging directory first and the target directory is left unchanged if unpacking fails. If
+// opts.DryRun is set, the files that would be unpacked are logged and nothing is written to disk.
 func UnpackModelKit(ctx context.Context, opts *UnpackOptions) error {
+	if opts != nil {
+		for _, conf := range opts.FilterConfs {
+			if err := conf.validate(); err != nil {
+				return err
+			}
+		}
+	}
+	if opts != nil && opts.DryRun {
+		plan, err := PlanUnpack(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, file := range plan.Files {
+			output.Infof("Would %s %s %s (%s)", file.Action, file.Type, file.Path, output.FormatBytes(file.Size))
+		}
+		output.Infof("Dry run: %d paths (%s to write), %d conflicts", len(plan.Files), output.FormatBytes(plan.TotalSize()), len(plan.Conflicts()))
+		return nil
+	}
+	if opts != nil && opts.Atomic {
+		if opts.Sync {
+			return fmt.Errorf("sync mode cannot be used with atomic unpack")
+		}
+		return unpackAtomic(ctx, opts)
+	}
 	// If an unpack directory is provided, temporarily change the working directory
 	// so that unpack operations that are relative to CWD behave as expected.
 	// This centralizes tar -C semantics inside the unpack library.
@@ -63,7 +95,25 @@ func UnpackModelKit(ctx context.Context, opts *UnpackOptions) error {
 			}
 		}()
 	}
-	return unpackRecursive(ctx, opts, []string{})
+	if opts == nil || !opts.Sync {
+		return unpackRecursive(ctx, opts, []string{})
+	}
+
+	opts.syncTracker = newSyncTracker()
+	defer func() {
+		opts.syncTracker = nil
+	}()
+	if err := unpackRecursive(ctx, opts, []string{}); err != nil {
+		return err
+	}
+	if opts.SyncDelete {
+		if err := opts.syncTracker.deleteRemoved(); err != nil {
+			return err
+		}
+	}
+	tracker := opts.syncTracker
+	output.Infof("Synced files: %d updated, %d unchanged, %d deleted", tracker.updated, tracker.unchanged, tracker.deleted)
+	return nil
 }
 
 func unpackRecursive(ctx context.Context, opts *UnpackOptions, visitedRefs []string) error {
@@ -81,36 +131,40 @@ func unpackRecursive(ctx context.Context, opts *UnpackOptions, visitedRefs []str
 	if err != nil {
 		return fmt.Errorf("failed to resolve reference: %w", err)
 	}
+	if localRepo, ok := store.(local.LocalRepo); ok && !opts.DryRun {
+		if err := localRepo.MarkUsed(ctx, manifestDesc); err != nil {
+			output.Logf(output.LogLevelWarn, "Failed to record usage for %s: %s", manifestDesc.Digest, err)
+		}
+	}
 
 	manifest, err := util.GetManifest(ctx, store, manifestDesc)
 	if err != nil {
 		return fmt.Errorf("failed to read manifest: %s", err)
 	}
+	// For ModelPack artifacts not packed by Kit, this is a Kitfile generated from the config and layers
 	config, err := util.GetKitfileForManifest(ctx, store, manifest)
 	if err != nil {
-		if !errors.Is(err, util.ErrNoKitfile) {
-			return err
-		}
-		output.Logf(output.LogLevelWarn, "Could not get Kitfile: %s", err)
-		output.Logf(output.LogLevelWarn, "Functionality may be impacted")
-		// TODO: we can probably _also_ handle getting the model-spec config and using it here
-		genconfig, err := generateKitfileForModelPack(manifest)
-		if err != nil {
+		if errors.Is(err, util.ErrNoKitfile) {
 			return fmt.Errorf("could not process manifest: %w", err)
 		}
-		config = genconfig
-	} else {
-		// These steps only make sense if we have a legitimate Kitfile available
-		if config.Model != nil && util.IsModelKitReference(config.Model.Path) {
-			output.Infof("Unpacking referenced modelkit %s", config.Model.Path)
-			if err := unpackParent(ctx, config.Model.Path, opts, visitedRefs); err != nil {
-				return err
-			}
+		return err
+	}
+	if config.Model != nil && util.IsModelKitReference(config.Model.Path) {
+		logUnpackf(opts, "Unpacking referenced modelkit %s", config.Model.Path)
+		if err := unpackParent(ctx, config.Model.Path, opts, visitedRefs); err != nil {
+			return err
 		}
-		if shouldUnpackLayer(config, opts.FilterConfs) {
-			if err := unpackConfig(config, opts.UnpackDir, opts.Overwrite); err != nil {
+	}
+	if opts.syncTracker != nil {
+		opts.syncTracker.protect(append(util.LayerPathsFromKitfile(config), constants.DefaultKitfileName)...)
+	}
+	if shouldUnpackLayer(config, opts.FilterConfs) {
+		if opts.plan != nil {
+			if err := opts.plan.addConfig(config, opts.Overwrite); err != nil {
 				return err
 			}
+		} else if err := unpackConfig(config, opts.UnpackDir, opts.Overwrite || opts.Sync); err != nil {
+			return err
 		}
 	}
 
@@ -118,8 +172,17 @@ func unpackRecursive(ctx context.Context, opts *UnpackOptions, visitedRefs []str
 	// through the config's relevant field to get the correct path for unpacking
 	// We need to support older ModelKits (that were packed without diffIDs and digest
 	// in the config) for now, so we need to continue using the old structure.
+	layers, layerChunks, err := util.GroupLayerChunks(manifest.Layers)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	diffIDs, err := util.GetLayerDiffIDs(ctx, store, manifest, config, layers)
+	if err != nil {
+		return fmt.Errorf("failed to read layer diffIDs: %w", err)
+	}
+	var jobs []layerJob
 	var modelPartIdx, codeIdx, datasetIdx, docsIdx, promptIdx int
-	for _, layerDesc := range manifest.Layers {
+	for layerIdx, layerDesc := range layers {
 		// This variable supports older-format tar layers (that don't include the
 		// layer path). For current ModelKits, this will be empty
 		var relPath string
@@ -135,61 +198,64 @@ func unpackRecursive(ctx context.Context, opts *UnpackOptions, visitedRefs []str
 		// Grab path + layer info from the config object corresponding to this layer
 		var layerPath string
 		var layerInfo *artifact.LayerInfo
+		// paths selects files within the layer to unpack if filters only match some of the layer
+		var paths *pathFilter
+		var selected bool
 		switch mediaType.Base() {
 		case mediatype.ModelBaseType:
 			entry := config.Model
-			if !shouldUnpackLayer(entry, opts.FilterConfs) {
+			if selected, paths = layerFilter(entry, opts.FilterConfs); !selected {
 				continue
 			}
 			layerInfo, layerPath = entry.LayerInfo, entry.Path
-			output.Infof("Unpacking model %s to %s", config.Model.Name, config.Model.Path)
+			logUnpackf(opts, "Unpacking model %s to %s", config.Model.Name, config.Model.Path)
 
 		case mediatype.ModelPartBaseType:
 			entry := config.Model.Parts[modelPartIdx]
 			modelPartIdx += 1
-			if !shouldUnpackLayer(entry, opts.FilterConfs) {
+			if selected, paths = layerFilter(entry, opts.FilterConfs); !selected {
 				continue
 			}
 			layerInfo, layerPath = entry.LayerInfo, entry.Path
-			output.Infof("Unpacking model part %s to %s", entry.Name, entry.Path)
+			logUnpackf(opts, "Unpacking model part %s to %s", entry.Name, entry.Path)
 
 		case mediatype.CodeBaseType:
 			// Code-type layers may be either regular code or prompts
 			if layerDesc.Annotations[constants.LayerSubtypeAnnotation] == constants.LayerSubtypePrompt {
 				entry := config.Prompts[promptIdx]
 				promptIdx += 1
-				if !shouldUnpackLayer(entry, opts.FilterConfs) {
+				if selected, paths = layerFilter(entry, opts.FilterConfs); !selected {
 					continue
 				}
 				layerInfo, layerPath = entry.LayerInfo, entry.Path
-				output.Infof("Unpacking prompt to %s", entry.Path)
+				logUnpackf(opts, "Unpacking prompt to %s", entry.Path)
 			} else {
 				entry := config.Code[codeIdx]
 				codeIdx += 1
-				if !shouldUnpackLayer(entry, opts.FilterConfs) {
+				if selected, paths = layerFilter(entry, opts.FilterConfs); !selected {
 					continue
 				}
 				layerInfo, layerPath = entry.LayerInfo, entry.Path
-				output.Infof("Unpacking code to %s", entry.Path)
+				logUnpackf(opts, "Unpacking code to %s", entry.Path)
 			}
 
 		case mediatype.DatasetBaseType:
 			entry := config.DataSets[datasetIdx]
 			datasetIdx += 1
-			if !shouldUnpackLayer(entry, opts.FilterConfs) {
+			if selected, paths = layerFilter(entry, opts.FilterConfs); !selected {
 				continue
 			}
 			layerInfo, layerPath = entry.LayerInfo, entry.Path
-			output.Infof("Unpacking dataset %s to %s", entry.Name, entry.Path)
+			logUnpackf(opts, "Unpacking dataset %s to %s", entry.Name, entry.Path)
 
 		case mediatype.DocsBaseType:
 			entry := config.Docs[docsIdx]
 			docsIdx += 1
-			if !shouldUnpackLayer(entry, opts.FilterConfs) {
+			if selected, paths = layerFilter(entry, opts.FilterConfs); !selected {
 				continue
 			}
 			layerInfo, layerPath = entry.LayerInfo, entry.Path
-			output.Infof("Unpacking docs to %s", entry.Path)
+			logUnpackf(opts, "Unpacking docs to %s", entry.Path)
 
 		case mediatype.ConfigBaseType:
 			// ModelPacks may contain a Kitfile in their layers, which is unpacked separately
@@ -212,10 +278,22 @@ func unpackRecursive(ctx context.Context, opts *UnpackOptions, visitedRefs []str
 			}
 		}
 
-		// TODO: handle DiffIDs when unpacking layers
-		if err := unpackLayer(ctx, store, layerDesc, relPath, opts.Overwrite, opts.IgnoreExisting, mediaType.Compression()); err != nil {
-			return fmt.Errorf("failed to unpack: %w", err)
+		if opts.syncTracker != nil && mediaType.Format() != mediatype.RawFormat && layerPath != "" {
+			opts.syncTracker.addRoot(layerPath)
 		}
+		jobs = append(jobs, layerJob{
+			desc:      layerDesc,
+			chunks:    layerChunks[layerIdx],
+			diffID:    diffIDs[layerIdx],
+			mediaType: mediaType,
+			layerPath: layerPath,
+			relPath:   relPath,
+			paths:     paths,
+		})
+	}
+
+	if err := unpackLayers(ctx, store, jobs, opts); err != nil {
+		return err
 	}
 	output.Debugf("Unpacked %d model part layers", modelPartIdx)
 	output.Debugf("Unpacked %d code layers", codeIdx)
@@ -226,6 +304,75 @@ func unpackRecursive(ctx context.Context, opts *UnpackOptions, visitedRefs []str
 	return nil
 }
 
+// logUnpackf logs progress messages for unpacking. In a dry run, messages are logged at debug level so that
+// they do not interfere with the report of files that would be unpacked.
+func logUnpackf(opts *UnpackOptions, s string, args ...any) {
+	if opts.plan != nil {
+		output.Debugf(s, args...)
+		return
+	}
+	output.Infof(s, args...)
+}
+
+// layerJob describes a single layer to be extracted during unpack.
+type layerJob struct {
+	desc   ocispec.Descriptor
+	chunks []ocispec.Descriptor
+	// diffID is the expected digest of the layer's uncompressed contents, if known
+	diffID    digest.Digest
+	mediaType mediatype.MediaType
+	// layerPath is the path for the layer in the Kitfile
+	layerPath string
+	// relPath is the path to extract older-format tar layers (that don't include the layer path) to
+	relPath string
+	// paths selects the files within the layer to extract. If nil, all files are extracted
+	paths *pathFilter
+}
+
+// unpackLayers extracts layers concurrently, with at most opts.Concurrency layers in progress at once.
+// Layers in a ModelKit refer to disjoint paths, so they can be extracted in any order. If extracting
+// any layer fails, the remaining layers are cancelled.
+func unpackLayers(ctx context.Context, store content.Storage, jobs []layerJob, opts *UnpackOptions) error {
+	progress := output.NewUnpackProgress(ctx)
+	errs, errCtx := errgroup.WithContext(ctx)
+	errs.SetLimit(max(opts.Concurrency, 1))
+	for _, job := range jobs {
+		errs.Go(func() error {
+			// Don't start extracting further layers once one has failed
+			if err := errCtx.Err(); err != nil {
+				return err
+			}
+			if opts.plan != nil {
+				if err := planLayer(errCtx, store, job, opts, progress); err != nil {
+					return fmt.Errorf("failed to read layer: %w", err)
+				}
+				return nil
+			}
+			if job.mediaType.Format() == mediatype.RawFormat {
+				// Raw layers are not compressed, so their contents always match the layer digest
+				diffID := job.diffID
+				if diffID == "" {
+					diffID = job.desc.Digest
+				}
+				if err := unpackRawLayer(errCtx, store, job.desc, diffID, job.layerPath, job.paths, opts, progress); err != nil {
+					return fmt.Errorf("failed to unpack: %w", err)
+				}
+				return nil
+			}
+			if job.diffID == "" {
+				progress.Debugf("No diffID for layer %s; skipping verification of uncompressed contents", job.desc.Digest)
+			}
+			if err := unpackLayer(errCtx, store, job.desc, job.chunks, job.diffID, job.relPath, job.paths, job.mediaType.Compression(), opts, progress); err != nil {
+				return fmt.Errorf("failed to unpack: %w", err)
+			}
+			return nil
+		})
+	}
+	err := errs.Wait()
+	progress.Done()
+	return err
+}
+
 func unpackParent(ctx context.Context, ref string, optsIn *UnpackOptions, visitedRefs []string) error {
 	if idx := getIndex(visitedRefs, ref); idx != -1 {
 		cycleStr := fmt.Sprintf("[%s=>%s]", strings.Join(visitedRefs[idx:], "=>"), ref)
@@ -300,28 +447,31 @@ func unpackConfig(config *artifact.KitFile, unpackDir string, overwrite bool) er
 	return nil
 }
 
-func unpackLayer(ctx context.Context, store content.Storage, desc ocispec.Descriptor, unpackPath string, overwrite, ignoreExisting bool, compression mediatype.CompressionType) error {
-	rc, err := store.Fetch(ctx, desc)
+// unpackLayer extracts the tar layer described by desc to unpackPath. If paths is not nil, only the files it
+// selects are extracted. If the layer is split into multiple chunk blobs, chunks contains the descriptors
+// for each chunk. If diffID is not empty, the uncompressed
+// contents of the layer are verified against it and an error wrapping ErrDiffIDMismatch is returned if
+// they do not match. The compressed contents are always verified against the layer digest.
+func unpackLayer(ctx context.Context, store content.Storage, desc ocispec.Descriptor, chunks []ocispec.Descriptor, diffID digest.Digest, unpackPath string, paths *pathFilter, compression mediatype.CompressionType, opts *UnpackOptions, progress *output.UnpackProgress) error {
+	rc, err := util.FetchLayer(ctx, store, desc, chunks)
 	if err != nil {
 		return fmt.Errorf("failed get layer %s: %w", desc.Digest, err)
 	}
-	var logger *output.ProgressLogger
-	rc, logger = output.WrapUnpackReadCloser(desc.Size, rc)
+	rc = progress.ProxyReader(desc.Digest.Encoded(), desc.Size, newContextReader(ctx, rc))
 	defer rc.Close()
-
-	var cr io.ReadCloser
-	var cErr error
-	switch compression {
-	case mediatype.GzipCompression, mediatype.GzipFastestCompression:
-		cr, cErr = gzip.NewReader(rc)
-	case mediatype.NoneCompression:
-		cr = rc
+	// Chunked layers are already verified against the layer digest by FetchLayer
+	var r io.Reader = rc
+	if len(chunks) == 0 {
+		r = newVerifyReader(rc, desc.Digest, ErrDigestMismatch)
 	}
-	if cErr != nil {
-		return fmt.Errorf("error setting up decompress: %w", cErr)
+
+	cr, err := newDecompressedReader(r, compression)
+	if err != nil {
+		return fmt.Errorf("error setting up decompress: %w", err)
 	}
 	defer cr.Close()
-	tr := tar.NewReader(cr)
+	dr := newVerifyReader(cr, diffID, ErrDiffIDMismatch)
+	tr := tar.NewReader(dr)
 
 	if unpackPath != "" {
 		unpackPath = filepath.Dir(unpackPath)
@@ -330,15 +480,182 @@ func unpackLayer(ctx context.Context, store content.Storage, desc ocispec.Descri
 		}
 	}
 
-	if err := extractTar(tr, unpackPath, overwrite, ignoreExisting, logger); err != nil {
+	if err := extractTar(tr, unpackPath, opts.Overwrite, opts.IgnoreExisting, opts.PreserveLinks, paths, opts.syncTracker, &progress.ProgressLogger); err != nil {
+		return err
+	}
+	// Read any remaining data (e.g. tar padding) so that the diffID and layer digest are verified
+	if _, err := io.Copy(io.Discard, dr); err != nil {
+		return fmt.Errorf("failed to read layer %s: %w", desc.Digest, err)
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return fmt.Errorf("failed to read layer %s: %w", desc.Digest, err)
+	}
+	return nil
+}
+
+// unpackRawLayer writes a raw (untarred) layer to disk as a single file. The file's path is read from the
+// layer's filepath annotation if present, falling back to the path of the layer's entry in the Kitfile.
+// If diffID is not empty, the file's contents are verified against it. If paths does not select the file,
+// the layer is skipped.
+func unpackRawLayer(ctx context.Context, store content.Storage, desc ocispec.Descriptor, diffID digest.Digest, layerPath string, paths *pathFilter, opts *UnpackOptions, progress *output.UnpackProgress) (err error) {
+	if annotationPath := desc.Annotations[modelspecv1.AnnotationFilepath]; annotationPath != "" {
+		layerPath = annotationPath
+	}
+	if layerPath == "" {
+		return fmt.Errorf("unknown file path for raw layer %s", desc.Digest)
+	}
+	_, outPath, err := filesystem.VerifySubpath(opts.UnpackDir, filepath.FromSlash(layerPath))
+	if err != nil {
+		return fmt.Errorf("illegal file path: %s: %w", layerPath, err)
+	}
+	if !paths.matches(outPath) {
+		progress.Debugf("Skipping file %s: does not match filters", outPath)
+		return nil
+	}
+	if opts.syncTracker != nil {
+		if handled, err := syncRawLayer(ctx, store, desc, diffID, outPath, opts.syncTracker, progress); err != nil || handled {
+			return err
+		}
+	}
+	if skip, err := checkExistingFile(outPath, opts.Overwrite || opts.Sync, opts.IgnoreExisting); err != nil {
 		return err
+	} else if skip {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(outPath), err)
 	}
 
-	logger.Wait()
+	rc, err := store.Fetch(ctx, desc)
+	if err != nil {
+		return fmt.Errorf("failed get layer %s: %w", desc.Digest, err)
+	}
+	rc = progress.ProxyReader(desc.Digest.Encoded(), desc.Size, newContextReader(ctx, rc))
+	defer rc.Close()
+
+	progress.Debugf("Unpacking file %s", outPath)
+	file, err := os.OpenFile(outPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, rawLayerFileMode(desc))
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", outPath, err)
+	}
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+	written, err := io.Copy(file, newVerifyReader(rc, diffID, ErrDiffIDMismatch))
+	if err != nil {
+		return fmt.Errorf("failed to write file %s: %w", outPath, err)
+	}
+	if written != desc.Size {
+		return fmt.Errorf("could not unpack file %s", outPath)
+	}
 	return nil
 }
 
-func extractTar(tr *tar.Reader, extractDir string, overwrite, ignoreExisting bool, logger *output.ProgressLogger) (err error) {
+// contextReader wraps an io.ReadCloser so that reads fail once ctx is cancelled, allowing in-progress
+// layers to stop early when unpacking another layer fails.
+type contextReader struct {
+	ctx context.Context
+	io.ReadCloser
+}
+
+func newContextReader(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	return &contextReader{ctx: ctx, ReadCloser: rc}
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.ReadCloser.Read(p)
+}
+
+// syncRawLayer updates an existing file at outPath to match a raw layer in sync mode. If no regular file
+// exists at outPath, it returns handled=false and the layer should be unpacked as usual.
+func syncRawLayer(ctx context.Context, store content.Storage, desc ocispec.Descriptor, diffID digest.Digest, outPath string, tracker *syncTracker, progress *output.UnpackProgress) (handled bool, err error) {
+	if fi, err := os.Lstat(outPath); errors.Is(err, fs.ErrNotExist) {
+		tracker.record(outPath, false, true)
+		return false, nil
+	} else if err != nil || !fi.Mode().IsRegular() {
+		return false, nil
+	}
+	rc, err := store.Fetch(ctx, desc)
+	if err != nil {
+		return false, fmt.Errorf("failed get layer %s: %w", desc.Digest, err)
+	}
+	rc = progress.ProxyReader(desc.Digest.Encoded(), desc.Size, newContextReader(ctx, rc))
+	defer rc.Close()
+	return syncExistingFile(outPath, newVerifyReader(rc, diffID, ErrDiffIDMismatch), desc.Size, rawLayerFileMode(desc), tracker, &progress.ProgressLogger)
+}
+
+// rawLayerFileMode returns the permissions to use for a file unpacked from a raw layer, based on
+// the layer's file metadata annotation.
+func rawLayerFileMode(desc ocispec.Descriptor) os.FileMode {
+	metaJson := desc.Annotations[modelspecv1.AnnotationFileMetadata]
+	if metaJson == "" {
+		return 0644
+	}
+	meta := modelspecv1.FileMetadata{}
+	if err := json.Unmarshal([]byte(metaJson), &meta); err != nil || meta.Mode == 0 {
+		return 0644
+	}
+	return os.FileMode(meta.Mode).Perm()
+}
+
+// checkExistingFile checks whether a file can be written to outPath. If a file already exists at
+// that path, it returns skip=true if ignoreExisting is set, or an error unless overwrite is set.
+func checkExistingFile(outPath string, overwrite, ignoreExisting bool) (skip bool, err error) {
+	fi, err := os.Lstat(outPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to check path %s: %w", outPath, err)
+	}
+	if ignoreExisting {
+		output.Debugf("File %s already exists; skipping", outPath)
+		return true, nil
+	}
+	if !overwrite {
+		return false, fmt.Errorf("path '%s' already exists", outPath)
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		// Replace the symlink rather than writing through it
+		if err := os.Remove(outPath); err != nil {
+			return false, fmt.Errorf("failed to remove existing symlink %s: %w", outPath, err)
+		}
+		return false, nil
+	}
+	if !fi.Mode().IsRegular() {
+		return false, fmt.Errorf("path '%s' already exists and is not a regular file", outPath)
+	}
+	return false, nil
+}
+
+// newDecompressedReader wraps rc in a reader that decompresses its contents according to compression.
+// Closing the returned reader does not close rc.
+func newDecompressedReader(rc io.Reader, compression mediatype.CompressionType) (io.ReadCloser, error) {
+	switch compression {
+	case mediatype.GzipCompression, mediatype.GzipFastestCompression:
+		return gzip.NewReader(rc)
+	case mediatype.ZstdCompression:
+		zr, err := zstd.NewReader(rc)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	case mediatype.NoneCompression:
+		return io.NopCloser(rc), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression format: %s", compression)
+	}
+}
+
+// extractTar extracts the contents of tr to extractDir. If paths is not nil, only the files it selects are
+// extracted. If tracker is not nil, files are extracted in sync mode: existing files are updated to match the
+// archive, rewriting only files that differ.
+func extractTar(tr *tar.Reader, extractDir string, overwrite, ignoreExisting, preserveLinks bool, paths *pathFilter, tracker *syncTracker, logger *output.ProgressLogger) (err error) {
+	if tracker != nil {
+		overwrite = true
+	}
 	for {
 		header, err := tr.Next()
 		if err == io.EOF {
@@ -356,9 +673,32 @@ func extractTar(tr *tar.Reader, extractDir string, overwrite, ignoreExisting boo
 		if err != nil {
 			return fmt.Errorf("illegal file path: %s: %w", outPath, err)
 		}
+		if err := verifyNoSymlinkParents(extractDir, header.Name); err != nil {
+			return fmt.Errorf("illegal file path: %s: %w", outPath, err)
+		}
+		if !paths.matches(outPath) {
+			if tracker != nil {
+				// Files that are filtered out are still part of the modelkit and should not be deleted
+				if header.Typeflag == tar.TypeDir {
+					tracker.record(outPath, true, false)
+				} else {
+					tracker.protect(outPath)
+				}
+			}
+			continue
+		}
+		if paths != nil && header.Typeflag != tar.TypeDir {
+			// The directory entries containing this file may have been filtered out
+			if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(outPath), err)
+			}
+		}
 
 		switch header.Typeflag {
 		case tar.TypeDir:
+			if tracker != nil {
+				tracker.record(outPath, true, false)
+			}
 			if fi, exists := filesystem.PathExists(outPath); exists {
 				if !fi.IsDir() {
 					return fmt.Errorf("path '%s' already exists and is not a directory", outPath)
@@ -371,17 +711,17 @@ func extractTar(tr *tar.Reader, extractDir string, overwrite, ignoreExisting boo
 			}
 
 		case tar.TypeReg:
-			if fi, exists := filesystem.PathExists(outPath); exists {
-				if ignoreExisting {
-					output.Debugf("File %s already exists; skipping", outPath)
+			if tracker != nil {
+				if handled, err := syncExistingFile(outPath, tr, header.Size, header.FileInfo().Mode(), tracker, logger); err != nil {
+					return err
+				} else if handled {
 					continue
 				}
-				if !overwrite {
-					return fmt.Errorf("path '%s' already exists", outPath)
-				}
-				if !fi.Mode().IsRegular() {
-					return fmt.Errorf("path '%s' already exists and is not a regular file", outPath)
-				}
+			}
+			if skip, err := checkExistingFile(outPath, overwrite, ignoreExisting); err != nil {
+				return err
+			} else if skip {
+				continue
 			}
 			logger.Debugf("Unpacking file %s", outPath)
 			file, err := os.OpenFile(outPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, header.FileInfo().Mode())
@@ -399,6 +739,26 @@ func extractTar(tr *tar.Reader, extractDir string, overwrite, ignoreExisting boo
 				return fmt.Errorf("could not unpack file %s", outPath)
 			}
 
+		case tar.TypeSymlink, tar.TypeLink:
+			if !preserveLinks {
+				return fmt.Errorf("archive contains link %s: use --preserve-links to unpack links", header.Name)
+			}
+			if tracker != nil {
+				unchanged := linkUnchanged(header, outPath)
+				tracker.record(outPath, false, !unchanged)
+				if unchanged {
+					continue
+				}
+			}
+			if header.Typeflag == tar.TypeLink && !paths.matches(filepath.Join(extractDir, filepath.FromSlash(header.Linkname))) {
+				// The target of the hardlink was filtered out and has not been unpacked
+				logger.Logf(output.LogLevelWarn, "Skipping hardlink %s: target %s was excluded by filters", outPath, header.Linkname)
+				continue
+			}
+			if err := extractLink(header, extractDir, outPath, overwrite, ignoreExisting, logger); err != nil {
+				return err
+			}
+
 		default:
 			return fmt.Errorf("unrecognized type in archive: %s", header.Name)
 		}
@@ -406,48 +766,91 @@ func extractTar(tr *tar.Reader, extractDir string, overwrite, ignoreExisting boo
 	return nil
 }
 
-func getIndex(list []string, s string) int {
-	for idx, item := range list {
-		if s == item {
-			return idx
+// extractLink creates the symlink or hardlink described by header at outPath. The target of the link
+// must resolve to a path within extractDir.
+func extractLink(header *tar.Header, extractDir, outPath string, overwrite, ignoreExisting bool, logger *output.ProgressLogger) error {
+	linkname := filepath.FromSlash(header.Linkname)
+	if linkname == "" || filepath.IsAbs(linkname) {
+		return fmt.Errorf("illegal link target for %s: %s", header.Name, header.Linkname)
+	}
+	// Symlink targets are relative to the directory containing the link, while hardlink targets are
+	// relative to the root of the archive. The directory containing the link has already been checked
+	// for symlinks, but components of the target may be symlinks and are resolved one at a time.
+	targetPath := linkname
+	if header.Typeflag == tar.TypeSymlink {
+		targetPath = filepath.Dir(filepath.FromSlash(header.Name)) + string(filepath.Separator) + linkname
+	}
+	resolvedTarget, err := filesystem.ResolveInRoot(extractDir, targetPath)
+	if err != nil {
+		return fmt.Errorf("illegal link target for %s: %s: %w", header.Name, header.Linkname, err)
+	}
+
+	if fi, err := os.Lstat(outPath); err == nil {
+		if ignoreExisting {
+			logger.Debugf("File %s already exists; skipping", outPath)
+			return nil
+		}
+		if !overwrite {
+			return fmt.Errorf("path '%s' already exists", outPath)
+		}
+		if fi.IsDir() {
+			return fmt.Errorf("path '%s' already exists and is a directory", outPath)
+		}
+		if err := os.Remove(outPath); err != nil {
+			return fmt.Errorf("failed to remove existing file %s: %w", outPath, err)
 		}
 	}
-	return -1
+
+	if header.Typeflag == tar.TypeSymlink {
+		logger.Debugf("Creating symlink %s -> %s", outPath, linkname)
+		if err := os.Symlink(linkname, outPath); err != nil {
+			return fmt.Errorf("failed to create symlink %s: %w", outPath, err)
+		}
+		return nil
+	}
+
+	linkTarget := filepath.Join(extractDir, resolvedTarget)
+	if fi, err := os.Lstat(linkTarget); err != nil {
+		return fmt.Errorf("failed to create hardlink %s: %w", outPath, err)
+	} else if !fi.Mode().IsRegular() {
+		return fmt.Errorf("failed to create hardlink %s: target %s is not a regular file", outPath, linkTarget)
+	}
+	logger.Debugf("Creating hardlink %s -> %s", outPath, linkTarget)
+	if err := os.Link(linkTarget, outPath); err != nil {
+		return fmt.Errorf("failed to create hardlink %s: %w", outPath, err)
+	}
+	return nil
 }
 
-// generateKitfileForModelPack generates a "Kitfile" for a manifest that otherwise does not contain one.
-// This is a minimal Kitfile suitable only for unpacking, containing a path for every layer. If a layer
-// does not use the 'org.cncf.model.filepath' annotation, an error is returned.
-func generateKitfileForModelPack(manifest *ocispec.Manifest) (*artifact.KitFile, error) {
-	if format, err := mediatype.ModelFormatForManifest(manifest); err != nil || format != mediatype.ModelPackFormat {
-		return nil, fmt.Errorf("not a modelpack artifact")
+// verifyNoSymlinkParents returns an error if any directory containing name, relative to extractDir,
+// is a symlink. Entries are never written through symlinks, even ones that point within extractDir, as
+// a chain of symlinks could otherwise be used to write files outside of it.
+func verifyNoSymlinkParents(extractDir, name string) error {
+	parent := filepath.Dir(filepath.Clean(filepath.FromSlash(name)))
+	resolved, err := filesystem.ResolveInRoot(extractDir, parent)
+	if err != nil {
+		return err
 	}
-	kf := &artifact.KitFile{
-		Model: &artifact.Model{},
+	if resolved != parent {
+		return fmt.Errorf("parent directory %s is a symlink", parent)
 	}
-	for _, desc := range manifest.Layers {
-		if desc.Annotations == nil || desc.Annotations[modelspecv1.AnnotationFilepath] == "" {
-			return nil, fmt.Errorf("unknown file path for layer: no %s annotation", modelspecv1.AnnotationFilepath)
-		}
-		filepath := desc.Annotations[modelspecv1.AnnotationFilepath]
-		mt, err := mediatype.ParseMediaType(desc.MediaType)
-		if err != nil {
-			return nil, err
-		}
-		switch mt.Base() {
-		case mediatype.ModelBaseType:
-			kf.Model.Path = filepath
-		case mediatype.ModelPartBaseType:
-			kf.Model.Parts = append(kf.Model.Parts, artifact.ModelPart{Path: filepath})
-		case mediatype.CodeBaseType:
-			kf.Code = append(kf.Code, artifact.Code{Path: filepath})
-		case mediatype.DatasetBaseType:
-			kf.DataSets = append(kf.DataSets, artifact.DataSet{Path: filepath})
-		case mediatype.DocsBaseType:
-			kf.Docs = append(kf.Docs, artifact.Docs{Path: filepath})
-		default:
-			return nil, fmt.Errorf("unknown layer type: %s", mt)
+	return nil
+}
+
+// linkUnchanged returns true if a symlink matching header already exists at outPath.
+func linkUnchanged(header *tar.Header, outPath string) bool {
+	if header.Typeflag != tar.TypeSymlink {
+		return false
+	}
+	target, err := os.Readlink(outPath)
+	return err == nil && target == filepath.FromSlash(header.Linkname)
+}
+
+func getIndex(list []string, s string) int {
+	for idx, item := range list {
+		if s == item {
+			return idx
 		}
 	}
-	return kf, nil
+	return -1
 }
@@ -0,0 +1,181 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package unpack
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/kitops-ml/kitops/pkg/artifact"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractTarLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Creating symlinks requires elevated privileges on Windows")
+	}
+	dir := func(name string) *tar.Header {
+		return &tar.Header{Typeflag: tar.TypeDir, Name: name, Mode: 0755}
+	}
+	file := func(name string) *tar.Header {
+		return &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644}
+	}
+	symlink := func(name, target string) *tar.Header {
+		return &tar.Header{Typeflag: tar.TypeSymlink, Name: name, Linkname: target}
+	}
+	hardlink := func(name, target string) *tar.Header {
+		return &tar.Header{Typeflag: tar.TypeLink, Name: name, Linkname: target}
+	}
+
+	tests := []struct {
+		name          string
+		headers       []*tar.Header
+		preserveLinks bool
+		errorContains string
+	}{
+		{
+			name:          "symlink and hardlink within directory",
+			headers:       []*tar.Header{dir("a"), file("a/file"), symlink("a/symlink", "file"), hardlink("a/hardlink", "a/file")},
+			preserveLinks: true,
+		},
+		{
+			name:          "links not enabled",
+			headers:       []*tar.Header{file("file"), symlink("symlink", "file")},
+			errorContains: "use --preserve-links",
+		},
+		{
+			name:          "symlink outside directory",
+			headers:       []*tar.Header{symlink("symlink", "../outside")},
+			preserveLinks: true,
+			errorContains: "illegal link target",
+		},
+		{
+			name:          "absolute symlink",
+			headers:       []*tar.Header{symlink("symlink", "/etc/passwd")},
+			preserveLinks: true,
+			errorContains: "illegal link target",
+		},
+		{
+			name:          "symlink outside directory via parent symlink",
+			headers:       []*tar.Header{dir("a"), symlink("a/b", "."), symlink("a/b/symlink", "../../outside")},
+			preserveLinks: true,
+			errorContains: "parent directory a/b is a symlink",
+		},
+		{
+			name:          "symlink outside directory via chained symlinks",
+			headers:       []*tar.Header{symlink("s", "."), symlink("l", "s/.."), file("l/evil")},
+			preserveLinks: true,
+			errorContains: "illegal link target",
+		},
+		{
+			name:          "symlink outside directory via symlink created later",
+			headers:       []*tar.Header{symlink("l", "s/.."), symlink("s", "."), file("l/evil")},
+			preserveLinks: true,
+			errorContains: "illegal link target",
+		},
+		{
+			name:          "file written through symlinked directory",
+			headers:       []*tar.Header{dir("a"), symlink("b", "a"), file("b/evil")},
+			preserveLinks: true,
+			errorContains: "parent directory b is a symlink",
+		},
+		{
+			name:          "hardlink outside directory via chained symlinks",
+			headers:       []*tar.Header{symlink("s", "."), hardlink("hardlink", "s/../outside")},
+			preserveLinks: true,
+			errorContains: "illegal link target",
+		},
+		{
+			name:          "hardlink outside directory",
+			headers:       []*tar.Header{hardlink("hardlink", "../outside")},
+			preserveLinks: true,
+			errorContains: "illegal link target",
+		},
+		{
+			name:          "hardlink to directory",
+			headers:       []*tar.Header{dir("a"), hardlink("hardlink", "a")},
+			preserveLinks: true,
+			errorContains: "not a regular file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			buf := &bytes.Buffer{}
+			tw := tar.NewWriter(buf)
+			for _, header := range tt.headers {
+				require.NoError(t, tw.WriteHeader(header))
+			}
+			require.NoError(t, tw.Close())
+
+			err := extractTar(tar.NewReader(buf), "", false, false, tt.preserveLinks, nil, nil, &output.ProgressLogger{})
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				_, err := os.Lstat(filepath.Join("..", "evil"))
+				assert.ErrorIs(t, err, fs.ErrNotExist, "File should not be written outside extract directory")
+				return
+			}
+			require.NoError(t, err)
+			for _, header := range tt.headers {
+				_, err := os.Lstat(header.Name)
+				assert.NoError(t, err, "Path %s should exist", header.Name)
+			}
+		})
+	}
+}
+
+func TestExtractTarSkipsHardlinksToFilteredFiles(t *testing.T) {
+	output.SetLogLevel(output.LogLevelError)
+	t.Cleanup(func() { output.SetLogLevel(output.LogLevelInfo) })
+	t.Chdir(t.TempDir())
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, header := range []*tar.Header{
+		{Typeflag: tar.TypeReg, Name: "weights.bin", Mode: 0644},
+		{Typeflag: tar.TypeLink, Name: "weights-link.json", Linkname: "weights.bin"},
+		{Typeflag: tar.TypeReg, Name: "config.json", Mode: 0644},
+		{Typeflag: tar.TypeLink, Name: "config-link.json", Linkname: "config.json"},
+	} {
+		require.NoError(t, tw.WriteHeader(header))
+	}
+	require.NoError(t, tw.Close())
+
+	filters := []FilterConf{{BaseTypes: []string{"code"}, Exclude: []string{"*.bin"}}}
+	selected, paths := layerFilter(artifact.Code{Path: "."}, filters)
+	require.True(t, selected)
+	require.NotNil(t, paths)
+
+	err := extractTar(tar.NewReader(buf), "", false, false, true, paths, nil, &output.ProgressLogger{})
+	require.NoError(t, err)
+	for _, name := range []string{"weights.bin", "weights-link.json"} {
+		_, err := os.Lstat(name)
+		assert.ErrorIs(t, err, fs.ErrNotExist, "Path %s should not be unpacked", name)
+	}
+	for _, name := range []string{"config.json", "config-link.json"} {
+		_, err := os.Lstat(name)
+		assert.NoError(t, err, "Path %s should exist", name)
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package unpack
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kitops-ml/kitops/pkg/artifact"
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	modelspecv1 "github.com/modelpack/model-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// PlanAction describes what unpacking would do with a file.
+type PlanAction string
+
+const (
+	// PlanCreate means the file does not exist and would be created
+	PlanCreate PlanAction = "create"
+	// PlanOverwrite means the file exists and would be overwritten
+	PlanOverwrite PlanAction = "overwrite"
+	// PlanSkip means the file exists and would be left unchanged because existing files are ignored
+	PlanSkip PlanAction = "skip"
+	// PlanExists means the directory (or identical Kitfile) already exists and would be reused
+	PlanExists PlanAction = "exists"
+	// PlanConflict means the file exists and unpacking would fail
+	PlanConflict PlanAction = "conflict"
+)
+
+// PlannedFile describes a single file, directory, or link that would be written when unpacking.
+type PlannedFile struct {
+	// Path is the path of the file relative to the unpack directory
+	Path string `json:"path"`
+	// Type is one of file, directory, symlink, or hardlink
+	Type   string     `json:"type"`
+	Size   int64      `json:"size"`
+	Action PlanAction `json:"action"`
+	// Layer is the digest of the layer containing the file. It is empty for the Kitfile
+	Layer string `json:"layer,omitempty"`
+}
+
+// UnpackPlan lists the files that would be written by unpacking a ModelKit, as determined by a dry run.
+type UnpackPlan struct {
+	UnpackDir string        `json:"unpackDir"`
+	Files     []PlannedFile `json:"files"`
+
+	mu sync.Mutex
+}
+
+// TotalSize returns the total size of files in the plan that would be written.
+func (p *UnpackPlan) TotalSize() int64 {
+	var total int64
+	for _, file := range p.Files {
+		if file.Action == PlanCreate || file.Action == PlanOverwrite {
+			total += file.Size
+		}
+	}
+	return total
+}
+
+// Conflicts returns the files in the plan that would cause unpacking to fail.
+func (p *UnpackPlan) Conflicts() []PlannedFile {
+	var conflicts []PlannedFile
+	for _, file := range p.Files {
+		if file.Action == PlanConflict {
+			conflicts = append(conflicts, file)
+		}
+	}
+	return conflicts
+}
+
+// PlanUnpack performs a dry run of unpacking a ModelKit with opts, returning the files that would be written
+// without modifying the filesystem. Layers are read in full to list their contents, but nothing is written to
+// disk. Sync and atomic modes are not supported.
+func PlanUnpack(ctx context.Context, opts *UnpackOptions) (*UnpackPlan, error) {
+	if opts.Sync || opts.Atomic {
+		return nil, fmt.Errorf("dry run cannot be used with sync or atomic unpack")
+	}
+	for _, conf := range opts.FilterConfs {
+		if err := conf.validate(); err != nil {
+			return nil, err
+		}
+	}
+	unpackDir, err := filepath.Abs(opts.UnpackDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path %s: %w", opts.UnpackDir, err)
+	}
+	planOpts := *opts
+	planOpts.DryRun = true
+	planOpts.plan = &UnpackPlan{UnpackDir: unpackDir, Files: []PlannedFile{}}
+	if err := unpackRecursive(ctx, &planOpts, []string{}); err != nil {
+		return nil, err
+	}
+	plan := planOpts.plan
+	// Layers are read concurrently, so sort files to produce consistent output
+	slices.SortStableFunc(plan.Files, func(a, b PlannedFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	plan.markDuplicates(opts.Overwrite, opts.IgnoreExisting)
+	return plan, nil
+}
+
+// markDuplicates updates the action for paths that would be written more than once (e.g. by two layers) to
+// reflect that the path would already exist when it is written again. A path that is a directory in one layer
+// and not in another is always a conflict. Files in the plan must be sorted by path.
+func (p *UnpackPlan) markDuplicates(overwrite, ignoreExisting bool) {
+	for start := 0; start < len(p.Files); {
+		end := start + 1
+		for end < len(p.Files) && p.Files[end].Path == p.Files[start].Path {
+			end++
+		}
+		group := p.Files[start:end]
+		start = end
+		if len(group) == 1 {
+			continue
+		}
+		numDirs := 0
+		for _, file := range group {
+			if file.Type == "directory" {
+				numDirs++
+			}
+		}
+		switch {
+		case numDirs == len(group):
+			continue
+		case numDirs > 0:
+			for idx := range group {
+				group[idx].Action = PlanConflict
+			}
+		default:
+			for idx := 1; idx < len(group); idx++ {
+				if group[idx].Action == PlanConflict {
+					continue
+				}
+				switch {
+				case ignoreExisting:
+					group[idx].Action = PlanSkip
+				case overwrite:
+					group[idx].Action = PlanOverwrite
+				default:
+					group[idx].Action = PlanConflict
+				}
+			}
+		}
+	}
+}
+
+// add records that path would be written when unpacking and determines what would happen to any existing
+// file at that path.
+func (p *UnpackPlan) add(path, fileType string, size int64, layer string, overwrite, ignoreExisting bool) {
+	path = filepath.Clean(path)
+	action := PlanCreate
+	if fi, err := os.Lstat(filepath.Join(p.UnpackDir, path)); err == nil {
+		switch {
+		case fileType == "directory" && fi.IsDir():
+			action = PlanExists
+		case fileType == "directory":
+			action = PlanConflict
+		case ignoreExisting:
+			action = PlanSkip
+		case !overwrite:
+			action = PlanConflict
+		case fileType == "file" && !fi.Mode().IsRegular():
+			action = PlanConflict
+		case fi.IsDir():
+			action = PlanConflict
+		default:
+			action = PlanOverwrite
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Files = append(p.Files, PlannedFile{
+		Path:   filepath.ToSlash(path),
+		Type:   fileType,
+		Size:   size,
+		Action: action,
+		Layer:  layer,
+	})
+}
+
+// addConfig records that the Kitfile would be written when unpacking, following the same rules as unpackConfig.
+func (p *UnpackPlan) addConfig(config *artifact.KitFile, overwrite bool) error {
+	configBytes, err := config.MarshalToYAML()
+	if err != nil {
+		return fmt.Errorf("failed to unpack config: %w", err)
+	}
+	action := PlanCreate
+	if fi, err := os.Lstat(filepath.Join(p.UnpackDir, constants.DefaultKitfileName)); err == nil {
+		existingBytes, _ := os.ReadFile(filepath.Join(p.UnpackDir, constants.DefaultKitfileName))
+		switch {
+		case !fi.Mode().IsRegular():
+			action = PlanConflict
+		case overwrite:
+			action = PlanOverwrite
+		case slices.Equal(configBytes, existingBytes):
+			action = PlanExists
+		default:
+			action = PlanConflict
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Files = append(p.Files, PlannedFile{
+		Path:   constants.DefaultKitfileName,
+		Type:   "file",
+		Size:   int64(len(configBytes)),
+		Action: action,
+	})
+	return nil
+}
+
+// planLayer adds the files that would be written by unpacking job to the plan. Tar layers are read in full
+// to list their contents; raw layers are planned from their descriptor.
+func planLayer(ctx context.Context, store content.Storage, job layerJob, opts *UnpackOptions, progress *output.UnpackProgress) error {
+	plan, desc := opts.plan, job.desc
+	if job.mediaType.Format() == mediatype.RawFormat {
+		layerPath := job.layerPath
+		if annotationPath := desc.Annotations[modelspecv1.AnnotationFilepath]; annotationPath != "" {
+			layerPath = annotationPath
+		}
+		layerPath = filepath.FromSlash(layerPath)
+		if layerPath == "" || !filepath.IsLocal(layerPath) {
+			return fmt.Errorf("illegal file path for raw layer %s: %s", desc.Digest, layerPath)
+		}
+		if job.paths.matches(layerPath) {
+			plan.add(layerPath, "file", desc.Size, desc.Digest.String(), opts.Overwrite, opts.IgnoreExisting)
+		}
+		return nil
+	}
+
+	rc, err := util.FetchLayer(ctx, store, desc, job.chunks)
+	if err != nil {
+		return fmt.Errorf("failed get layer %s: %w", desc.Digest, err)
+	}
+	rc = progress.ProxyReader(desc.Digest.Encoded(), desc.Size, newContextReader(ctx, rc))
+	defer rc.Close()
+	cr, err := newDecompressedReader(rc, job.mediaType.Compression())
+	if err != nil {
+		return fmt.Errorf("error setting up decompress: %w", err)
+	}
+	defer cr.Close()
+
+	extractDir := ""
+	if job.relPath != "" {
+		extractDir = filepath.Dir(job.relPath)
+	}
+	tr := tar.NewReader(cr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read layer %s: %w", desc.Digest, err)
+		}
+		outPath := filepath.Join(extractDir, filepath.FromSlash(header.Name))
+		if !filepath.IsLocal(outPath) {
+			return fmt.Errorf("illegal file path: %s", outPath)
+		}
+		if !job.paths.matches(outPath) {
+			continue
+		}
+		var fileType string
+		switch header.Typeflag {
+		case tar.TypeDir:
+			fileType = "directory"
+		case tar.TypeReg:
+			fileType = "file"
+		case tar.TypeSymlink, tar.TypeLink:
+			if !opts.PreserveLinks {
+				return fmt.Errorf("archive contains link %s: use --preserve-links to unpack links", header.Name)
+			}
+			fileType = "symlink"
+			if header.Typeflag == tar.TypeLink {
+				if !job.paths.matches(filepath.Join(extractDir, filepath.FromSlash(header.Linkname))) {
+					// Hardlinks to files excluded by filters are skipped when unpacking
+					continue
+				}
+				fileType = "hardlink"
+			}
+		default:
+			return fmt.Errorf("unrecognized type in archive: %s", header.Name)
+		}
+		plan.add(outPath, fileType, header.Size, desc.Digest.String(), opts.Overwrite, opts.IgnoreExisting)
+	}
+	// Read any remaining data so that the layer is read in full
+	if _, err := io.Copy(io.Discard, rc); err != nil {
+		return fmt.Errorf("failed to read layer %s: %w", desc.Digest, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package unpack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnpackPlanAdd(t *testing.T) {
+	unpackDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(unpackDir, "file.txt"), []byte("existing"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(unpackDir, "dir"), 0755))
+
+	tests := []struct {
+		name           string
+		path           string
+		fileType       string
+		overwrite      bool
+		ignoreExisting bool
+		expected       PlanAction
+	}{
+		{name: "new file", path: "new.txt", fileType: "file", expected: PlanCreate},
+		{name: "existing file", path: "file.txt", fileType: "file", expected: PlanConflict},
+		{name: "existing file with overwrite", path: "file.txt", fileType: "file", overwrite: true, expected: PlanOverwrite},
+		{name: "existing file with ignore existing", path: "file.txt", fileType: "file", ignoreExisting: true, expected: PlanSkip},
+		{name: "existing directory", path: "dir", fileType: "directory", expected: PlanExists},
+		{name: "directory over file", path: "file.txt", fileType: "directory", overwrite: true, expected: PlanConflict},
+		{name: "file over directory", path: "dir", fileType: "file", overwrite: true, expected: PlanConflict},
+		{name: "symlink over file", path: "file.txt", fileType: "symlink", overwrite: true, expected: PlanOverwrite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &UnpackPlan{UnpackDir: unpackDir}
+			plan.add(tt.path, tt.fileType, 10, "", tt.overwrite, tt.ignoreExisting)
+			require.Len(t, plan.Files, 1)
+			assert.Equal(t, tt.expected, plan.Files[0].Action)
+		})
+	}
+}
+
+func TestUnpackPlanMarkDuplicates(t *testing.T) {
+	tests := []struct {
+		name           string
+		types          []string
+		overwrite      bool
+		ignoreExisting bool
+		expected       []PlanAction
+	}{
+		{name: "same directory in two layers", types: []string{"directory", "directory"}, expected: []PlanAction{PlanCreate, PlanCreate}},
+		{name: "same file in two layers", types: []string{"file", "file"}, expected: []PlanAction{PlanCreate, PlanConflict}},
+		{name: "same file with overwrite", types: []string{"file", "file"}, overwrite: true, expected: []PlanAction{PlanCreate, PlanOverwrite}},
+		{name: "same file with ignore existing", types: []string{"file", "file"}, ignoreExisting: true, expected: []PlanAction{PlanCreate, PlanSkip}},
+		{name: "file and directory", types: []string{"file", "directory"}, overwrite: true, expected: []PlanAction{PlanConflict, PlanConflict}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &UnpackPlan{UnpackDir: t.TempDir()}
+			plan.add("other.txt", "file", 10, "layer-0", tt.overwrite, tt.ignoreExisting)
+			for idx, fileType := range tt.types {
+				plan.add("path", fileType, 10, fmt.Sprintf("layer-%d", idx+1), tt.overwrite, tt.ignoreExisting)
+			}
+			plan.markDuplicates(tt.overwrite, tt.ignoreExisting)
+			require.Len(t, plan.Files, len(tt.types)+1)
+			assert.Equal(t, PlanCreate, plan.Files[0].Action, "Unique paths should not be affected")
+			var actions []PlanAction
+			for _, file := range plan.Files[1:] {
+				actions = append(actions, file.Action)
+			}
+			assert.Equal(t, tt.expected, actions)
+		})
+	}
+}
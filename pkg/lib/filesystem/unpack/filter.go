@@ -18,11 +18,14 @@ package unpack
 
 import (
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"slices"
 	"strings"
 
 	"github.com/kitops-ml/kitops/pkg/artifact"
+
+	"github.com/moby/patternmatcher"
 )
 
 var validFilterTypes = []string{"kitfile", "model", "datasets", "code", "prompts", "docs"}
@@ -31,6 +34,13 @@ var validFilterTypes = []string{"kitfile", "model", "datasets", "code", "prompts
 type FilterConf struct {
 	BaseTypes []string
 	Filters   []string
+	// Include contains glob patterns for paths to unpack. Patterns without a '/' match the name of a file or
+	// directory at any depth; other patterns match paths relative to the unpack directory. If empty, all
+	// paths in matching layers are unpacked.
+	Include []string
+	// Exclude contains glob patterns for paths that should not be unpacked. Exclusions take precedence over
+	// Include and use the same syntax.
+	Exclude []string
 }
 
 func (fc *FilterConf) matches(baseType, field string) bool {
@@ -49,6 +59,49 @@ func (fc *FilterConf) matchesField(field string) bool {
 	return slices.Contains(fc.Filters, field)
 }
 
+// hasPatterns returns true if the filter includes or excludes paths within layers using glob patterns.
+func (fc *FilterConf) hasPatterns() bool {
+	return len(fc.Include) > 0 || len(fc.Exclude) > 0
+}
+
+// validate checks that the include and exclude patterns for the filter are valid.
+func (fc *FilterConf) validate() error {
+	if _, err := newPathMatcher(fc); err != nil {
+		return fmt.Errorf("invalid filter pattern: %w", err)
+	}
+	return nil
+}
+
+// layerMatcher checks the filter's patterns against the path for a layer. If the layer cannot contain any
+// paths that match the filter, it returns false. Otherwise, the returned pathMatcher selects files within
+// the layer; if it is nil, all files are selected.
+func (fc *FilterConf) layerMatcher(layerPath string) (*pathMatcher, bool) {
+	if !fc.hasPatterns() {
+		return nil, true
+	}
+	matcher, err := newPathMatcher(fc)
+	if err != nil {
+		// Filters are validated before unpacking
+		return nil, false
+	}
+	layerPath = filepath.Clean(layerPath)
+	if matcher.excludes(layerPath) {
+		return nil, false
+	}
+	if matcher.include != nil {
+		if matcher.includes(layerPath) {
+			// The entire layer is included; only exclusions apply within it
+			matcher.include = nil
+		} else if !couldMatchWithin(fc.Include, layerPath) {
+			return nil, false
+		}
+	}
+	if matcher.include == nil && matcher.exclude == nil {
+		return nil, true
+	}
+	return matcher, true
+}
+
 // ParseFilter parses a filter string and returns a FilterConf.
 func ParseFilter(filter string) (*FilterConf, error) {
 	typesAndIds := strings.Split(filter, ":")
@@ -71,8 +124,23 @@ func ParseFilter(filter string) (*FilterConf, error) {
 		return conf, nil
 	}
 
-	filters := strings.Split(typesAndIds[1], ",")
-	conf.Filters = filters
+	// Filters starting with '!' exclude paths, and filters containing glob characters include paths. Other
+	// filters are matched against the name or path of Kitfile entries. Filters starting with '=' are always
+	// matched literally, which allows selecting entries whose name or path contains glob characters.
+	for filter := range strings.SplitSeq(typesAndIds[1], ",") {
+		if literal, ok := strings.CutPrefix(filter, "="); ok {
+			conf.Filters = append(conf.Filters, literal)
+		} else if pattern, ok := strings.CutPrefix(filter, "!"); ok {
+			conf.Exclude = append(conf.Exclude, pattern)
+		} else if isGlobPattern(filter) {
+			conf.Include = append(conf.Include, filter)
+		} else {
+			conf.Filters = append(conf.Filters, filter)
+		}
+	}
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
 	return conf, nil
 }
 
@@ -80,8 +148,16 @@ func ParseFilter(filter string) (*FilterConf, error) {
 // fields against the filters. Matching is done against path and name (if present).
 // If filters is empty, we assume everything should be unpacked
 func shouldUnpackLayer(layer any, filters []FilterConf) bool {
+	selected, _ := layerFilter(layer, filters)
+	return selected
+}
+
+// layerFilter determines if a layer in a Kitfile should be unpacked, as in shouldUnpackLayer. If
+// only some paths within the layer should be unpacked, it also returns a pathFilter that selects them;
+// otherwise, the returned pathFilter is nil.
+func layerFilter(layer any, filters []FilterConf) (bool, *pathFilter) {
 	if len(filters) == 0 {
-		return true
+		return true, nil
 	}
 	// The type switch below checks for concrete (non-pointer) types. We need to use
 	// reflect to dereference the pointer and get a new interface{} (any) type.
@@ -93,39 +169,158 @@ func shouldUnpackLayer(layer any, filters []FilterConf) bool {
 	case artifact.KitFile:
 		for _, filter := range filters {
 			if filter.matchesBaseType("kitfile") {
-				return true
+				return true, nil
 			}
 		}
-		return false
+		return false, nil
 	case artifact.Model:
-		return matchesFilters("model", l.Name, filters) || matchesFilters("model", l.Path, filters)
+		return matchesFilters("model", l.Name, l.Path, filters)
 	case artifact.ModelPart:
-		return matchesFilters("model", l.Name, filters) || matchesFilters("model", l.Path, filters)
+		return matchesFilters("model", l.Name, l.Path, filters)
 	case artifact.Docs:
 		// Docs does not have an ID/name field so we can only match on path
-		return matchesFilters("docs", l.Path, filters)
+		return matchesFilters("docs", "", l.Path, filters)
 	case artifact.DataSet:
-		return matchesFilters("datasets", l.Name, filters) || matchesFilters("datasets", l.Path, filters)
+		return matchesFilters("datasets", l.Name, l.Path, filters)
 	case artifact.Code:
 		// Code does not have a ID/name field so we can only match on path
-		return matchesFilters("code", l.Path, filters)
+		return matchesFilters("code", "", l.Path, filters)
 	case artifact.Prompt:
 		// Prompts do not have a ID/name field so we can only match on path
-		return matchesFilters("prompts", l.Path, filters)
+		return matchesFilters("prompts", "", l.Path, filters)
 	default:
-		return false
+		return false, nil
 	}
 }
 
-func matchesFilters(baseType, field string, filterConfs []FilterConf) bool {
+// matchesFilters checks the name and path of a layer against filterConfs. The layer is selected if
+// any filter matches it; files within the layer are selected if they match any filter that selects
+// the layer.
+func matchesFilters(baseType, name, path string, filterConfs []FilterConf) (bool, *pathFilter) {
+	selected := false
+	var matchers []*pathMatcher
 	for _, filterConf := range filterConfs {
-		if filterConf.matches(baseType, field) {
+		if !(name != "" && filterConf.matches(baseType, name)) && !filterConf.matches(baseType, path) {
+			continue
+		}
+		matcher, ok := filterConf.layerMatcher(path)
+		if !ok {
+			continue
+		}
+		if matcher == nil {
+			// Everything in the layer is selected by this filter
+			return true, nil
+		}
+		selected = true
+		matchers = append(matchers, matcher)
+	}
+	if !selected {
+		return false, nil
+	}
+	return true, &pathFilter{matchers: matchers}
+}
+
+// pathFilter selects the paths within a layer that should be unpacked. A nil pathFilter selects all paths.
+type pathFilter struct {
+	matchers []*pathMatcher
+}
+
+// matches returns true if path (relative to the unpack directory) should be unpacked.
+func (pf *pathFilter) matches(path string) bool {
+	if pf == nil {
+		return true
+	}
+	path = filepath.Clean(path)
+	for _, matcher := range pf.matchers {
+		if (matcher.include == nil || matcher.includes(path)) && !matcher.excludes(path) {
 			return true
 		}
 	}
 	return false
 }
 
+// pathMatcher matches paths against the include and exclude patterns for a single filter. Either
+// matcher may be nil if the filter has no patterns of that type.
+type pathMatcher struct {
+	include *patternmatcher.PatternMatcher
+	exclude *patternmatcher.PatternMatcher
+}
+
+func newPathMatcher(fc *FilterConf) (*pathMatcher, error) {
+	var err error
+	matcher := &pathMatcher{}
+	if len(fc.Include) > 0 {
+		if matcher.include, err = compilePatterns(fc.Include); err != nil {
+			return nil, err
+		}
+	}
+	if len(fc.Exclude) > 0 {
+		if matcher.exclude, err = compilePatterns(fc.Exclude); err != nil {
+			return nil, err
+		}
+	}
+	return matcher, nil
+}
+
+// includes returns true if path or any of its parent directories match an include pattern.
+func (m *pathMatcher) includes(path string) bool {
+	matches, err := m.include.MatchesOrParentMatches(path)
+	return err == nil && matches
+}
+
+// excludes returns true if path or any of its parent directories match an exclude pattern.
+func (m *pathMatcher) excludes(path string) bool {
+	if m.exclude == nil {
+		return false
+	}
+	matches, err := m.exclude.MatchesOrParentMatches(path)
+	return err == nil && matches
+}
+
+func compilePatterns(patterns []string) (*patternmatcher.PatternMatcher, error) {
+	var normalized []string
+	for _, pattern := range patterns {
+		pattern = normalizePattern(pattern)
+		if pattern == "" || strings.HasPrefix(pattern, "!") {
+			return nil, fmt.Errorf("invalid pattern %q", pattern)
+		}
+		normalized = append(normalized, filepath.FromSlash(pattern))
+	}
+	return patternmatcher.New(normalized)
+}
+
+// normalizePattern converts a pattern to a slash-separated pattern relative to the unpack directory.
+// Patterns that do not contain a '/' are matched at any depth.
+func normalizePattern(pattern string) string {
+	pattern = strings.TrimPrefix(strings.TrimSpace(filepath.ToSlash(pattern)), "./")
+	if pattern != "" && !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return pattern
+}
+
+// couldMatchWithin returns true if any of patterns could match a path within layerPath, by comparing the
+// non-glob prefix of each pattern to layerPath.
+func couldMatchWithin(patterns []string, layerPath string) bool {
+	layerPrefix := filepath.ToSlash(filepath.Clean(layerPath)) + "/"
+	for _, pattern := range patterns {
+		pattern = normalizePattern(pattern)
+		prefix := pattern
+		if idx := strings.IndexAny(pattern, `*?[\`); idx != -1 {
+			prefix = pattern[:idx]
+		}
+		if strings.HasPrefix(layerPrefix, prefix) || strings.HasPrefix(prefix, layerPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGlobPattern returns true if filter contains glob characters.
+func isGlobPattern(filter string) bool {
+	return strings.ContainsAny(filter, `*?[`)
+}
+
 // FiltersFromUnpackConf converts a (deprecated) unpackConf to a set of filters to enable supporting the old flags
 func FiltersFromUnpackConf(unpackKitfile, unpackModels, unpackCode, unpackDatasets, unpackDocs bool) []FilterConf {
 	filter := FilterConf{}
@@ -17,8 +17,11 @@
 package unpack
 
 import (
+	"path/filepath"
 	"testing"
 
+	"github.com/kitops-ml/kitops/pkg/artifact"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -31,6 +34,8 @@ func TestParseFilter_EdgeCases(t *testing.T) {
 		errorContains   string
 		expectedTypes   []string
 		expectedFilters []string
+		expectedInclude []string
+		expectedExclude []string
 	}{
 		{
 			name:          "simple model filter",
@@ -54,6 +59,33 @@ func TestParseFilter_EdgeCases(t *testing.T) {
 			expectedTypes:   []string{"datasets"},
 			expectedFilters: []string{"training-data", "validation"},
 		},
+		{
+			name:            "glob patterns",
+			filter:          "model,datasets:validation,*.json,tokenizer*,!*.bin",
+			expectedTypes:   []string{"model", "datasets"},
+			expectedFilters: []string{"validation"},
+			expectedInclude: []string{"*.json", "tokenizer*"},
+			expectedExclude: []string{"*.bin"},
+		},
+		{
+			name:            "literal filters with glob characters",
+			filter:          "datasets:=data[v2],=*.csv,data*",
+			expectedTypes:   []string{"datasets"},
+			expectedFilters: []string{"data[v2]", "*.csv"},
+			expectedInclude: []string{"data*"},
+		},
+		{
+			name:          "invalid glob pattern",
+			filter:        "model:model/[a-",
+			expectError:   true,
+			errorContains: "invalid filter pattern",
+		},
+		{
+			name:          "empty exclusion",
+			filter:        "model:!",
+			expectError:   true,
+			errorContains: "invalid filter pattern",
+		},
 		{
 			name:          "too many colons",
 			filter:        "model:filter1:filter2:extra",
@@ -107,6 +139,126 @@ func TestParseFilter_EdgeCases(t *testing.T) {
 				// If no specific filters expected, should be empty
 				assert.Empty(t, result.Filters)
 			}
+			assert.Equal(t, tt.expectedInclude, result.Include)
+			assert.Equal(t, tt.expectedExclude, result.Exclude)
+		})
+	}
+}
+
+func TestLayerFilterPatterns(t *testing.T) {
+	model := &artifact.Model{Name: "my-model", Path: "model"}
+	dataset := &artifact.DataSet{Name: "shards", Path: "data"}
+	tests := []struct {
+		name            string
+		filters         []string
+		layer           any
+		expectSelected  bool
+		expectWhole     bool
+		expectMatches   []string
+		expectNoMatches []string
+	}{
+		{
+			name:           "no patterns",
+			filters:        []string{"model"},
+			layer:          model,
+			expectSelected: true,
+			expectWhole:    true,
+		},
+		{
+			name:            "include by file name",
+			filters:         []string{"model:*.json,tokenizer*"},
+			layer:           model,
+			expectSelected:  true,
+			expectMatches:   []string{"model/config.json", "model/sub/tokenizer.model", "model/tokenizer"},
+			expectNoMatches: []string{"model/weights.bin", "model/sub"},
+		},
+		{
+			name:            "exclude only",
+			filters:         []string{"model:!*.bin"},
+			layer:           model,
+			expectSelected:  true,
+			expectMatches:   []string{"model/config.json", "model/sub/tokenizer.model"},
+			expectNoMatches: []string{"model/weights.bin", "model/sub/weights.bin"},
+		},
+		{
+			name:            "exclusions take precedence",
+			filters:         []string{"datasets:data/shard-*,!data/shard-2*"},
+			layer:           dataset,
+			expectSelected:  true,
+			expectMatches:   []string{"data/shard-1/train.csv", "data/shard-10.csv"},
+			expectNoMatches: []string{"data/shard-2/train.csv", "data/other.csv"},
+		},
+		{
+			name:           "include matches layer path",
+			filters:        []string{"datasets:da*"},
+			layer:          dataset,
+			expectSelected: true,
+			expectWhole:    true,
+		},
+		{
+			name:           "include cannot match in layer",
+			filters:        []string{"datasets:other/*.csv"},
+			layer:          dataset,
+			expectSelected: false,
+		},
+		{
+			name:           "exclude matches layer path",
+			filters:        []string{"model:!mod*"},
+			layer:          model,
+			expectSelected: false,
+		},
+		{
+			name:           "name filter does not match",
+			filters:        []string{"model:other-model,*.json"},
+			layer:          model,
+			expectSelected: false,
+		},
+		{
+			name:           "type does not match",
+			filters:        []string{"datasets:*.json"},
+			layer:          model,
+			expectSelected: false,
+		},
+		{
+			name:            "multiple filters",
+			filters:         []string{"model:*.json", "model:my-model,weights-0*"},
+			layer:           model,
+			expectSelected:  true,
+			expectMatches:   []string{"model/config.json", "model/weights-01.bin"},
+			expectNoMatches: []string{"model/weights-11.bin"},
+		},
+		{
+			name:           "filter without patterns selects whole layer",
+			filters:        []string{"model:*.json", "model"},
+			layer:          model,
+			expectSelected: true,
+			expectWhole:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filterConfs []FilterConf
+			for _, filter := range tt.filters {
+				conf, err := ParseFilter(filter)
+				require.NoError(t, err)
+				filterConfs = append(filterConfs, *conf)
+			}
+			selected, paths := layerFilter(tt.layer, filterConfs)
+			assert.Equal(t, tt.expectSelected, selected)
+			if !tt.expectSelected {
+				return
+			}
+			if tt.expectWhole {
+				assert.Nil(t, paths)
+				return
+			}
+			require.NotNil(t, paths)
+			for _, path := range tt.expectMatches {
+				assert.True(t, paths.matches(filepath.FromSlash(path)), "Path %s should match", path)
+			}
+			for _, path := range tt.expectNoMatches {
+				assert.False(t, paths.matches(filepath.FromSlash(path)), "Path %s should not match", path)
+			}
 		})
 	}
 }
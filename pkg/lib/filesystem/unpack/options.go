@@ -32,4 +32,21 @@ type UnpackOptions struct {
 	ModelRef       *registry.Reference
 	Overwrite      bool
 	IgnoreExisting bool
+	PreserveLinks  bool
+	// Atomic unpacks to a staging directory and moves files into place only once all layers are unpacked
+	Atomic bool
+	// Sync updates existing files to match the ModelKit, rewriting only files that differ
+	Sync bool
+	// SyncDelete deletes files within unpacked layer paths that are not in the ModelKit. Requires Sync
+	SyncDelete bool
+	// Stream always reads the ModelKit from its remote registry, even if it is present in local storage.
+	// Layers are extracted as they are downloaded and nothing is stored locally
+	Stream bool
+	// DryRun lists the files that would be unpacked without writing anything to disk. See PlanUnpack
+	DryRun bool
+
+	// syncTracker records files unpacked in sync mode
+	syncTracker *syncTracker
+	// plan records files that would be unpacked in a dry run
+	plan *UnpackPlan
 }
@@ -30,8 +30,15 @@ import (
 	"oras.land/oras-go/v2/errdef"
 )
 
-// getStoreForRef returns the appropriate store (local or remote) for a ModelKit reference.
+// getStoreForRef returns the appropriate store (local or remote) for a ModelKit reference. If opts.Stream
+// is set, local storage is skipped and the remote repository is always used.
 func getStoreForRef(ctx context.Context, opts *UnpackOptions) (oras.Target, error) {
+	if opts.Stream {
+		if opts.ModelRef.Registry == util.DefaultRegistry {
+			return nil, fmt.Errorf("streaming requires a reference that includes a registry")
+		}
+		return getRemoteStore(ctx, opts)
+	}
 	storageHome := constants.StoragePath(opts.ConfigHome)
 	localRepo, err := local.NewLocalRepo(storageHome, opts.ModelRef)
 	if err != nil {
@@ -47,6 +54,10 @@ func getStoreForRef(ctx context.Context, opts *UnpackOptions) (oras.Target, erro
 		return nil, fmt.Errorf("not found")
 	}
 	// Not in local storage, check remote
+	return getRemoteStore(ctx, opts)
+}
+
+func getRemoteStore(ctx context.Context, opts *UnpackOptions) (oras.Target, error) {
 	repo, err := remote.NewRepository(ctx, opts.ModelRef.Registry, opts.ModelRef.Repository, &opts.NetworkOptions)
 	if err != nil {
 		return nil, fmt.Errorf("could not resolve repository %s in registry %s", opts.ModelRef.Repository, opts.ModelRef.Registry)
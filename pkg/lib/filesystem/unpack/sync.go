@@ -0,0 +1,272 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package unpack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kitops-ml/kitops/pkg/output"
+)
+
+// syncBufferSize is the size of the chunks compared between existing files and layer contents when syncing.
+const syncBufferSize = 1 << 20
+
+// syncTracker records the files unpacked in sync mode (UnpackOptions.Sync), so that files that are no longer
+// present in the ModelKit can be deleted once all layers are unpacked.
+type syncTracker struct {
+	mu sync.Mutex
+	// paths contains every path (relative to the unpack directory) that is present in an unpacked layer
+	paths map[string]struct{}
+	// roots contains the paths for unpacked tar layers, which are checked for files to delete
+	roots []string
+	// protected contains the paths for every entry in the Kitfile; these are never deleted when checking
+	// another layer's path, as they belong to a different (possibly filtered) layer
+	protected                   map[string]struct{}
+	updated, unchanged, deleted int
+}
+
+func newSyncTracker() *syncTracker {
+	return &syncTracker{
+		paths:     map[string]struct{}{},
+		protected: map[string]struct{}{},
+	}
+}
+
+// addRoot records that the tar layer for root is being unpacked.
+func (s *syncTracker) addRoot(root string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roots = append(s.roots, filepath.Clean(root))
+}
+
+// protect records paths that should not be deleted unless they are the root of an unpacked layer.
+func (s *syncTracker) protect(paths ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, path := range paths {
+		s.protected[filepath.Clean(path)] = struct{}{}
+	}
+}
+
+// record records that path is present in an unpacked layer, and whether it was changed by unpacking.
+// Directories are recorded with changed=false and are not counted.
+func (s *syncTracker) record(path string, isDir, changed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p := filepath.Clean(path); p != "." && p != string(filepath.Separator); p = filepath.Dir(p) {
+		s.paths[p] = struct{}{}
+	}
+	switch {
+	case isDir:
+	case changed:
+		s.updated++
+	default:
+		s.unchanged++
+	}
+}
+
+// deleteRemoved deletes files and directories within the root of each unpacked tar layer that are not
+// present in any unpacked layer.
+func (s *syncTracker) deleteRemoved() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, root := range s.roots {
+		if fi, err := os.Lstat(root); err != nil || !fi.IsDir() {
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			path = filepath.Clean(path)
+			if path == root {
+				return nil
+			}
+			if _, ok := s.protected[path]; ok {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if _, ok := s.paths[path]; ok {
+				return nil
+			}
+			if d.IsDir() && s.containsProtected(path) {
+				return nil
+			}
+			output.Debugf("Deleting %s: not present in modelkit", path)
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", path, err)
+			}
+			s.deleted++
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// containsProtected returns true if any protected path is within dir.
+func (s *syncTracker) containsProtected(dir string) bool {
+	prefix := dir + string(filepath.Separator)
+	for path := range s.protected {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncExistingFile updates the file at outPath to match the contents of r, which has the given size, and
+// mode. Only the parts of the file that differ from r are rewritten. If no regular file exists at outPath,
+// it returns handled=false and the file should be unpacked as usual.
+func syncExistingFile(outPath string, r io.Reader, size int64, mode fs.FileMode, tracker *syncTracker, logger *output.ProgressLogger) (handled bool, err error) {
+	fi, err := os.Lstat(outPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		tracker.record(outPath, false, true)
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to check path %s: %w", outPath, err)
+	}
+	if !fi.Mode().IsRegular() {
+		return false, nil
+	}
+
+	changed, err := syncFileContents(outPath, r, size)
+	if err != nil {
+		return false, err
+	}
+	if fi.Mode().Perm() != mode.Perm() {
+		if err := os.Chmod(outPath, mode.Perm()); err != nil {
+			return false, fmt.Errorf("failed to set permissions for %s: %w", outPath, err)
+		}
+		changed = true
+	}
+	if changed {
+		logger.Debugf("Updated file %s", outPath)
+	} else {
+		logger.Debugf("File %s is unchanged", outPath)
+	}
+	tracker.record(outPath, false, changed)
+	return true, nil
+}
+
+// syncFileContents compares the existing file at path with the contents of r. If they differ, the new contents
+// are written to a temporary file in the same directory, which replaces path only once all of r has been read
+// (and verified, if r verifies its contents). The existing file is never modified in place, so other hardlinks
+// to it and processes reading it are unaffected, and an interrupted sync leaves either the old or the new file.
+// Returns true if the file was replaced.
+func syncFileContents(path string, r io.Reader, size int64) (changed bool, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat file %s: %w", path, err)
+	}
+
+	var tempFile *os.File
+	defer func() {
+		// Only set if the file was not replaced successfully
+		if tempFile != nil {
+			tempFile.Close()
+			os.Remove(tempFile.Name())
+		}
+	}()
+
+	layerBuf := make([]byte, syncBufferSize)
+	fileBuf := make([]byte, syncBufferSize)
+	var offset int64
+	for {
+		n, readErr := io.ReadFull(r, layerBuf)
+		if n > 0 {
+			if tempFile == nil {
+				m, _ := io.ReadFull(file, fileBuf[:n])
+				if m != n || !bytes.Equal(layerBuf[:n], fileBuf[:n]) {
+					if tempFile, err = newSyncTempFile(path, file, offset); err != nil {
+						return false, err
+					}
+				}
+			}
+			if tempFile != nil {
+				if _, err := tempFile.Write(layerBuf[:n]); err != nil {
+					return false, fmt.Errorf("failed to update file %s: %w", path, err)
+				}
+			}
+			offset += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		} else if readErr != nil {
+			return false, fmt.Errorf("failed to read contents for %s: %w", path, readErr)
+		}
+	}
+	if offset != size {
+		return false, fmt.Errorf("could not unpack file %s", path)
+	}
+	if tempFile == nil {
+		if fi.Size() == size {
+			return false, nil
+		}
+		// The new contents are a prefix of the existing file
+		if tempFile, err = newSyncTempFile(path, file, size); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tempFile.Chmod(fi.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("failed to set permissions for %s: %w", path, err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return false, fmt.Errorf("failed to update file %s: %w", path, err)
+	}
+	if err := os.Rename(tempFile.Name(), path); err != nil {
+		return false, fmt.Errorf("failed to update file %s: %w", path, err)
+	}
+	tempFile = nil
+	return true, nil
+}
+
+// newSyncTempFile creates a temporary file in the same directory as path, containing the first n bytes of
+// existing, to which the remaining contents of a file being synced can be written.
+func newSyncTempFile(path string, existing *os.File, n int64) (*os.File, error) {
+	tempFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".kit-sync-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file for %s: %w", path, err)
+	}
+	if _, err := io.Copy(tempFile, io.NewSectionReader(existing, 0, n)); err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return nil, fmt.Errorf("failed to update file %s: %w", path, err)
+	}
+	return tempFile, nil
+}
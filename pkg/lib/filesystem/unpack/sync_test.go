@@ -0,0 +1,103 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package unpack
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncFileContents(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789"), syncBufferSize/5)
+	modified := bytes.Clone(large)
+	modified[len(modified)-3] = 'x'
+
+	tests := []struct {
+		name            string
+		existing        []byte
+		contents        []byte
+		expectedChanged bool
+	}{
+		{name: "identical", existing: []byte("contents"), contents: []byte("contents"), expectedChanged: false},
+		{name: "identical large", existing: large, contents: large, expectedChanged: false},
+		{name: "differs", existing: []byte("contents"), contents: []byte("modified"), expectedChanged: true},
+		{name: "differs in later chunk", existing: large, contents: modified, expectedChanged: true},
+		{name: "existing is shorter", existing: []byte("content"), contents: []byte("contents"), expectedChanged: true},
+		{name: "existing is longer", existing: large, contents: large[:syncBufferSize+10], expectedChanged: true},
+		{name: "empty", existing: []byte("contents"), contents: []byte{}, expectedChanged: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file")
+			require.NoError(t, os.WriteFile(path, tt.existing, 0644))
+
+			changed, err := syncFileContents(path, bytes.NewReader(tt.contents), int64(len(tt.contents)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedChanged, changed)
+			actual, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(tt.contents, actual), "File contents should match after sync")
+			entries, err := os.ReadDir(filepath.Dir(path))
+			require.NoError(t, err)
+			assert.Len(t, entries, 1, "Temporary files should be removed")
+		})
+	}
+}
+
+func TestSyncFileContentsReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	path, linkPath := filepath.Join(dir, "file"), filepath.Join(dir, "link")
+	require.NoError(t, os.WriteFile(path, []byte("original contents"), 0640))
+	require.NoError(t, os.Link(path, linkPath))
+
+	// A read error (e.g. a diffID mismatch) leaves the existing file unchanged
+	failing := io.MultiReader(strings.NewReader("modified"), iotest.ErrReader(ErrDiffIDMismatch))
+	_, err := syncFileContents(path, failing, int64(len("modified contents")))
+	assert.ErrorIs(t, err, ErrDiffIDMismatch)
+	actual, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "original contents", string(actual), "Existing file should not be modified on error")
+
+	changed, err := syncFileContents(path, strings.NewReader("modified contents"), int64(len("modified contents")))
+	require.NoError(t, err)
+	assert.True(t, changed)
+	actual, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "modified contents", string(actual))
+	linked, err := os.ReadFile(linkPath)
+	require.NoError(t, err)
+	assert.Equal(t, "original contents", string(linked), "Other hardlinks to the file should not be modified")
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, fs.FileMode(0640), fi.Mode().Perm(), "File mode should be preserved")
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "Temporary files should be removed")
+}
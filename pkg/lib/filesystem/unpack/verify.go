@@ -0,0 +1,114 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package unpack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
+	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+var (
+	// ErrDigestMismatch is returned when the contents of a layer do not match the layer's digest.
+	ErrDigestMismatch = errors.New("layer digest mismatch")
+	// ErrDiffIDMismatch is returned when the uncompressed contents of a layer do not match the layer's DiffID.
+	ErrDiffIDMismatch = errors.New("layer diffID mismatch")
+)
+
+// VerifyLayer checks that the layer described by desc matches its digest and, if diffID is not empty, that
+// its uncompressed contents match diffID. If the layer is split into multiple chunk blobs, chunks contains
+// the descriptors for each chunk. No files are written.
+func VerifyLayer(ctx context.Context, store content.Fetcher, desc ocispec.Descriptor, chunks []ocispec.Descriptor, diffID digest.Digest, progress *output.UnpackProgress) error {
+	if err := desc.Digest.Validate(); err != nil {
+		return fmt.Errorf("invalid digest for layer %s: %w", desc.Digest, err)
+	}
+	compression := mediatype.NoneCompression
+	if mediaType, err := mediatype.ParseMediaType(desc.MediaType); err == nil {
+		compression = mediaType.Compression()
+	} else {
+		// We don't know how to decompress layers with unknown media types, so can only verify their digest
+		diffID = ""
+	}
+
+	rc, err := util.FetchLayer(ctx, store, desc, chunks)
+	if err != nil {
+		return fmt.Errorf("failed get layer %s: %w", desc.Digest, err)
+	}
+	rc = progress.ProxyReader(desc.Digest.Encoded(), desc.Size, newContextReader(ctx, rc))
+	defer rc.Close()
+	// Chunked layers are already verified against the layer digest by FetchLayer
+	var r io.Reader = rc
+	if len(chunks) == 0 {
+		r = newVerifyReader(rc, desc.Digest, ErrDigestMismatch)
+	}
+
+	cr, err := newDecompressedReader(r, compression)
+	if err != nil {
+		return fmt.Errorf("error setting up decompress: %w", err)
+	}
+	defer cr.Close()
+	if _, err := io.Copy(io.Discard, newVerifyReader(cr, diffID, ErrDiffIDMismatch)); err != nil {
+		return fmt.Errorf("failed to verify layer %s: %w", desc.Digest, err)
+	}
+	// Read any remaining data so that the layer digest is verified
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return fmt.Errorf("failed to verify layer %s: %w", desc.Digest, err)
+	}
+	return nil
+}
+
+// verifyReader computes the digest of the data read through it, returning an error wrapping mismatchErr
+// once the underlying reader is fully read if the digest does not match the expected digest.
+type verifyReader struct {
+	reader      io.Reader
+	digester    digest.Digester
+	expected    digest.Digest
+	mismatchErr error
+}
+
+// newVerifyReader wraps r in a verifyReader for expected. If expected is empty, r is returned as-is.
+func newVerifyReader(r io.Reader, expected digest.Digest, mismatchErr error) io.Reader {
+	if expected == "" {
+		return r
+	}
+	return &verifyReader{
+		reader:      r,
+		digester:    expected.Algorithm().Digester(),
+		expected:    expected,
+		mismatchErr: mismatchErr,
+	}
+}
+
+func (r *verifyReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.digester.Hash().Write(p[:n])
+	if err == io.EOF {
+		if actual := r.digester.Digest(); actual != r.expected {
+			return n, fmt.Errorf("%w: expected %s, got %s", r.mismatchErr, r.expected, actual)
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package unpack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestVerifyLayer(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	progress := output.NewUnpackProgress(ctx)
+	defer progress.Done()
+
+	uncompressed := []byte("uncompressed layer contents")
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	_, err := gw.Write(uncompressed)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	mediaType := mediatype.New(mediatype.KitFormat, mediatype.DatasetBaseType, mediatype.TarFormat, mediatype.GzipCompression)
+	desc := ocispec.Descriptor{
+		MediaType: mediaType.String(),
+		Digest:    digest.FromBytes(buf.Bytes()),
+		Size:      int64(buf.Len()),
+	}
+	require.NoError(t, store.Push(ctx, desc, bytes.NewReader(buf.Bytes())))
+
+	tests := []struct {
+		name   string
+		diffID digest.Digest
+		err    error
+	}{
+		{name: "matching diffID", diffID: digest.FromBytes(uncompressed)},
+		{name: "unknown diffID", diffID: ""},
+		{name: "mismatched diffID", diffID: digest.FromString("other contents"), err: ErrDiffIDMismatch},
+		{name: "compressed digest used as diffID", diffID: desc.Digest, err: ErrDiffIDMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyLayer(ctx, store, desc, nil, tt.diffID, progress)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
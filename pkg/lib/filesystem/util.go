@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"time"
 
 	"github.com/kitops-ml/kitops/pkg/output"
 	modelspecv1 "github.com/modelpack/model-spec/specs-go/v1"
@@ -60,3 +61,30 @@ func fillDescAnnotations(desc *ocispec.Descriptor, path string, filemeta fs.File
 	}
 	return nil
 }
+
+// normalizeFileMode returns a normalized version of mode for reproducible packing: directories
+// and executable files use 0755, symlinks use 0777 and all other files use 0644.
+func normalizeFileMode(mode fs.FileMode) fs.FileMode {
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		return mode.Type() | 0777
+	case mode.IsDir(), mode&0111 != 0:
+		return mode.Type() | 0755
+	default:
+		return mode.Type() | 0644
+	}
+}
+
+// reproducibleFileInfo wraps an fs.FileInfo to use a fixed modification time and normalized mode.
+type reproducibleFileInfo struct {
+	fs.FileInfo
+	modTime time.Time
+}
+
+func (fi reproducibleFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+func (fi reproducibleFileInfo) Mode() fs.FileMode {
+	return normalizeFileMode(fi.FileInfo.Mode())
+}
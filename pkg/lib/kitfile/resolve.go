@@ -28,6 +28,7 @@ import (
 	"github.com/kitops-ml/kitops/pkg/lib/repo/local"
 	"github.com/kitops-ml/kitops/pkg/lib/repo/remote"
 	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
+	"github.com/kitops-ml/kitops/pkg/output"
 
 	"oras.land/oras-go/v2/registry"
 )
@@ -82,6 +83,9 @@ func ResolveKitfile(ctx context.Context, configHome, kitfileRef, baseRef string)
 			if err := ValidateKitfile(resolved); err != nil {
 				return nil, err
 			}
+			if err := ValidateModelPackConfig(resolved); err != nil {
+				output.Logf(output.LogLevelWarn, "%s", err)
+			}
 			return resolved, nil
 		}
 		if idx := getIndex(refChain, resolved.Model.Path); idx != -1 {
@@ -89,3 +89,21 @@ func ValidateKitfile(kf *artifact.KitFile) error {
 
 	return nil
 }
+
+// ValidateModelPackConfig returns an error if the model parameters in the Kitfile cannot be converted
+// into a ModelPack model config. Invalid parameters are skipped when generating the config, so this is
+// only fatal when packing in ModelPack format; elsewhere, it should be reported as a warning.
+func ValidateModelPackConfig(kf *artifact.KitFile) error {
+	if kf.Model == nil {
+		return nil
+	}
+	_, paramErrs := kf.Model.ModelPackConfig()
+	if len(paramErrs) == 0 {
+		return nil
+	}
+	var errs []string
+	for _, err := range paramErrs {
+		errs = append(errs, fmt.Sprintf("  * model %s has %s", kf.Model.Name, err))
+	}
+	return fmt.Errorf("invalid ModelPack parameters in Kitfile: \n%s", strings.Join(errs, "\n"))
+}
@@ -0,0 +1,300 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// StorageReport describes the result of checking local storage for corruption and inconsistencies.
+type StorageReport struct {
+	// BlobsChecked is the number of blobs that were rehashed.
+	BlobsChecked int
+	// Corrupt lists blobs whose contents do not match their digest.
+	Corrupt []digest.Digest
+	// Missing lists blobs that are referenced by an index or manifest but are not present in local storage.
+	Missing []digest.Digest
+	// Problems describes inconsistencies in per-repository index and tag files and in the shared index.json.
+	Problems []string
+	// References records, for each blob referenced by a manifest in a local repository, the repositories that
+	// refer to it and its descriptor, so that it can be fetched again from its source.
+	References map[digest.Digest][]BlobReference
+}
+
+// BlobReference describes a blob as referenced from a local repository.
+type BlobReference struct {
+	// Repo is the local repository (<registry>/<repository>) that refers to the blob.
+	Repo string
+	Desc ocispec.Descriptor
+}
+
+// OK returns true if no corruption or inconsistencies were found.
+func (r *StorageReport) OK() bool {
+	return len(r.Corrupt) == 0 && len(r.Missing) == 0 && len(r.Problems) == 0
+}
+
+func (r *StorageReport) addReference(repo string, desc ocispec.Descriptor) {
+	for _, ref := range r.References[desc.Digest] {
+		if ref.Repo == repo {
+			return
+		}
+	}
+	r.References[desc.Digest] = append(r.References[desc.Digest], BlobReference{Repo: repo, Desc: desc})
+}
+
+func (r *StorageReport) addMissing(dgst digest.Digest) {
+	if !slices.Contains(r.Missing, dgst) {
+		r.Missing = append(r.Missing, dgst)
+	}
+}
+
+// CheckStorage verifies local storage at storagePath. Every blob is rehashed and compared against its digest,
+// every manifest in each local repository is checked for missing config and layer blobs, and the per-repository
+// index and tag files and the shared index.json are checked for references to manifests that do not exist.
+// Repositories whose index or tag files cannot be read are reported as problems and are otherwise skipped.
+func CheckStorage(ctx context.Context, storagePath string) (*StorageReport, error) {
+	report := &StorageReport{
+		References: map[digest.Digest][]BlobReference{},
+	}
+	blobs, err := hashBlobs(ctx, storagePath, report)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := oci.NewStorage(storagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local storage: %w", err)
+	}
+
+	repoNames, err := listAllRepoNames(storagePath)
+	if err != nil {
+		return nil, err
+	}
+	repoManifests := map[digest.Digest][]string{}
+	for _, repoName := range repoNames {
+		repo, err := newLocalRepoForName(storagePath, repoName)
+		if err != nil {
+			report.Problems = append(report.Problems, fmt.Sprintf("failed to read repository %s: %s", repoName, err))
+			continue
+		}
+		lr, ok := repo.(*localRepo)
+		if !ok {
+			continue
+		}
+		for _, manifestDesc := range lr.localIndex.Manifests {
+			if slices.Contains(repoManifests[manifestDesc.Digest], lr.nameRef) {
+				continue
+			}
+			repoManifests[manifestDesc.Digest] = append(repoManifests[manifestDesc.Digest], lr.nameRef)
+			if err := checkManifest(ctx, storage, blobs, lr, manifestDesc, report); err != nil {
+				return nil, err
+			}
+		}
+		for tag, desc := range lr.localIndex.modelTags.tagToDigest {
+			if !lr.localIndex.exists(desc) {
+				report.Problems = append(report.Problems, fmt.Sprintf("tag %s in repository %s refers to manifest %s, which is not in the repository's index", tag, lr.nameRef, desc.Digest))
+			}
+		}
+	}
+
+	if err := checkTagFiles(storagePath, report); err != nil {
+		return nil, err
+	}
+
+	sharedIndex, err := parseIndex(constants.IndexJsonPath(storagePath))
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("shared index.json is invalid: %s", err))
+		return report, nil
+	}
+	sharedManifests := map[digest.Digest]bool{}
+	for _, desc := range sharedIndex.Manifests {
+		sharedManifests[desc.Digest] = true
+		if _, exists := blobs[desc.Digest]; !exists {
+			report.addMissing(desc.Digest)
+		}
+	}
+	for dgst, repoNames := range repoManifests {
+		if !sharedManifests[dgst] {
+			report.Problems = append(report.Problems, fmt.Sprintf("manifest %s in repository %s is not recorded in the shared index.json", dgst, strings.Join(repoNames, ", ")))
+		}
+	}
+
+	slices.Sort(report.Corrupt)
+	slices.Sort(report.Missing)
+	slices.Sort(report.Problems)
+	return report, nil
+}
+
+// QuarantineBlob moves the blob with digest dgst out of local storage and into the quarantine directory, so that
+// it can be inspected or fetched again.
+func QuarantineBlob(storagePath string, dgst digest.Digest) error {
+	blobPath := filepath.Join(storagePath, ocispec.ImageBlobsDir, dgst.Algorithm().String(), dgst.Encoded())
+	quarantineDir := filepath.Join(constants.QuarantinePath(storagePath), dgst.Algorithm().String())
+	if err := os.MkdirAll(quarantineDir, 0755); err != nil {
+		return fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+	quarantinePath := filepath.Join(quarantineDir, dgst.Encoded())
+	// Rename does not replace existing files on all platforms
+	if err := os.Remove(quarantinePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to quarantine blob %s: %w", dgst, err)
+	}
+	if err := os.Rename(blobPath, quarantinePath); err != nil {
+		return fmt.Errorf("failed to quarantine blob %s: %w", dgst, err)
+	}
+	return nil
+}
+
+// hashBlobs rehashes every blob in local storage, recording blobs that do not match their digest in report. It
+// returns the set of blobs present in local storage, mapped to whether they are intact.
+func hashBlobs(ctx context.Context, storagePath string, report *StorageReport) (map[digest.Digest]bool, error) {
+	blobs := map[digest.Digest]bool{}
+	blobsDir := filepath.Join(storagePath, ocispec.ImageBlobsDir)
+	var blobPaths []string
+	algDirs, err := os.ReadDir(blobsDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return blobs, nil
+		}
+		return nil, fmt.Errorf("failed to read local storage: %w", err)
+	}
+	for _, algDir := range algDirs {
+		if !algDir.IsDir() || !digest.Algorithm(algDir.Name()).Available() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(blobsDir, algDir.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read local storage: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				blobPaths = append(blobPaths, filepath.Join(blobsDir, algDir.Name(), entry.Name()))
+			}
+		}
+	}
+
+	pb := output.GenericProgressBar("Verifying", "Verified blobs", int64(len(blobPaths)))
+	defer pb.Done()
+	for _, blobPath := range blobPaths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		alg := digest.Algorithm(filepath.Base(filepath.Dir(blobPath)))
+		expected := digest.NewDigestFromEncoded(alg, filepath.Base(blobPath))
+		if expected.Validate() != nil {
+			pb.Increment()
+			continue
+		}
+		actual, err := digestBlob(alg, blobPath)
+		if err != nil {
+			return nil, err
+		}
+		intact := actual == expected
+		if !intact {
+			output.SafeLogf(output.LogLevelDebug, "Blob %s has digest %s", expected, actual)
+			report.Corrupt = append(report.Corrupt, expected)
+		}
+		blobs[expected] = intact
+		report.BlobsChecked++
+		pb.Increment()
+	}
+	return blobs, nil
+}
+
+func digestBlob(alg digest.Algorithm, blobPath string) (digest.Digest, error) {
+	f, err := os.Open(blobPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read blob: %w", err)
+	}
+	defer f.Close()
+	digester := alg.Digester()
+	if _, err := io.Copy(digester.Hash(), f); err != nil {
+		return "", fmt.Errorf("failed to read blob %s: %w", blobPath, err)
+	}
+	return digester.Digest(), nil
+}
+
+// checkManifest checks that the manifest described by desc, and each blob it refers to, exists in local storage.
+// Blobs that are missing from local storage but are present in shared storage for lr are not reported.
+func checkManifest(ctx context.Context, storage content.Fetcher, blobs map[digest.Digest]bool, lr *localRepo, desc ocispec.Descriptor, report *StorageReport) error {
+	repo := lr.nameRef
+	report.addReference(repo, desc)
+	intact, exists := blobs[desc.Digest]
+	if !exists {
+		report.addMissing(desc.Digest)
+		return nil
+	}
+	if !intact {
+		// The manifest cannot be trusted, so its references cannot be checked until it is repaired
+		return nil
+	}
+	successors, err := content.Successors(ctx, storage, desc)
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to read manifest %s in repository %s: %s", desc.Digest, repo, err))
+		return nil
+	}
+	for _, successor := range successors {
+		if successor.Data != nil {
+			// Content is embedded in the manifest
+			continue
+		}
+		report.addReference(repo, successor)
+		if _, exists := blobs[successor.Digest]; !exists {
+			if shared, err := lr.sharedBlobExists(ctx, successor); err == nil && shared {
+				continue
+			}
+			report.addMissing(successor.Digest)
+		}
+	}
+	return nil
+}
+
+// checkTagFiles checks for per-repository tag files that do not have a corresponding index file.
+func checkTagFiles(storagePath string, report *StorageReport) error {
+	entries, err := os.ReadDir(storagePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read local storage: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, "-tags.json") {
+			continue
+		}
+		indexName := strings.TrimSuffix(name, "-tags.json") + "-index.json"
+		if _, err := os.Stat(filepath.Join(storagePath, indexName)); errors.Is(err, fs.ErrNotExist) {
+			report.Problems = append(report.Problems, fmt.Sprintf("tag file %s has no corresponding repository index", name))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// maxTagHistoryEntries is the number of tag changes retained in each repository's history; older
+// entries are discarded.
+const maxTagHistoryEntries = 1000
+
+// TagHistoryEntry records a change to the modelkit a tag refers to in a local repository. OldDigest is
+// empty if the tag was created and NewDigest is empty if the tag was removed.
+type TagHistoryEntry struct {
+	Time      time.Time     `json:"time"`
+	Tag       string        `json:"tag"`
+	OldDigest digest.Digest `json:"oldDigest,omitempty"`
+	NewDigest digest.Digest `json:"newDigest,omitempty"`
+	Command   string        `json:"command,omitempty"`
+}
+
+func readTagHistory(historyPath string) ([]TagHistoryEntry, error) {
+	historyBytes, err := os.ReadFile(historyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read tag history: %w", err)
+	}
+	var history []TagHistoryEntry
+	if err := json.Unmarshal(historyBytes, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse tag history: %w", err)
+	}
+	return history, nil
+}
+
+// appendTagHistory adds entries to the tag history at historyPath. The caller must hold the lock for the
+// repository's index.
+func appendTagHistory(historyPath string, entries []TagHistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	history, err := readTagHistory(historyPath)
+	if err != nil {
+		return err
+	}
+	history = append(history, entries...)
+	if len(history) > maxTagHistoryEntries {
+		history = history[len(history)-maxTagHistoryEntries:]
+	}
+	historyBytes, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tag history: %w", err)
+	}
+	if err := writeFileAtomic(historyPath, historyBytes, 0644); err != nil {
+		return fmt.Errorf("failed to save tag history: %w", err)
+	}
+	return nil
+}
+
+// changes returns history entries for each tag whose target differs from when the tags index was last
+// loaded or saved.
+func (ti *tagsIndex) changes(command string) []TagHistoryEntry {
+	now := time.Now().UTC()
+	var entries []TagHistoryEntry
+	for tag, desc := range ti.tagToDigest {
+		if ti.savedTags[tag] != desc.Digest {
+			entries = append(entries, TagHistoryEntry{Time: now, Tag: tag, OldDigest: ti.savedTags[tag], NewDigest: desc.Digest, Command: command})
+		}
+	}
+	for tag, oldDigest := range ti.savedTags {
+		if _, ok := ti.tagToDigest[tag]; !ok {
+			entries = append(entries, TagHistoryEntry{Time: now, Tag: tag, OldDigest: oldDigest, Command: command})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Tag < entries[j].Tag
+	})
+	return entries
+}
+
+// markSaved records the current tags as the state that future changes are compared against.
+func (ti *tagsIndex) markSaved() {
+	ti.savedTags = map[string]digest.Digest{}
+	for tag, desc := range ti.tagToDigest {
+		ti.savedTags[tag] = desc.Digest
+	}
+}
+
+// commandFromContext returns the command line being run, if it is set on the context.
+func commandFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	command, _ := ctx.Value(constants.CommandLineKey{}).(string)
+	return command
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"fmt"
+	"os"
+)
+
+// fileLock is an advisory lock on a file, used to serialize read-modify-write updates of index files between
+// concurrent kit processes. Locks are not reentrant: a process must not acquire a lock it already holds.
+type fileLock struct {
+	file *os.File
+}
+
+// lockFile blocks until an exclusive lock is acquired on the file at path, creating it if necessary.
+func lockFile(path string) (*fileLock, error) {
+	return openAndLock(path, true)
+}
+
+// lockFileShared blocks until a shared lock is acquired on the file at path, creating it if necessary. Any
+// number of processes may hold a shared lock on the same file, but not while another holds an exclusive lock.
+func lockFileShared(path string) (*fileLock, error) {
+	return openAndLock(path, false)
+}
+
+func openAndLock(path string, exclusive bool) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockFd(f, exclusive); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	return &fileLock{file: f}, nil
+}
+
+// unlock releases the lock. The lock file itself is not removed, as another process may be waiting on it.
+func (l *fileLock) unlock() error {
+	unlockErr := unlockFd(l.file)
+	if err := l.file.Close(); err != nil && unlockErr == nil {
+		return err
+	}
+	return unlockErr
+}
+
+// lockPath returns the path of the lock file that guards the file at path.
+func lockPath(path string) string {
+	return path + ".lock"
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// PrunedBlob describes a blob in local storage that is not reachable from any stored manifest.
+type PrunedBlob struct {
+	Digest digest.Digest
+	Size   int64
+}
+
+// storageLockFile is the name of the lock file that guards blobs in local storage against being pruned while
+// they are being written. It is separate from the lock on the shared index.json, as writers need to update that
+// index while holding it.
+const storageLockFile = "storage.lock"
+
+// LockStorageForWrite blocks until Prune is not running in storagePath and prevents it from starting until the
+// returned function is called. Commands that write blobs to local storage must hold this lock until the manifest
+// that references those blobs is recorded in an index, as the blobs would otherwise appear to be unreferenced.
+// Any number of commands may hold the lock at once.
+func LockStorageForWrite(storagePath string) (unlock func(), err error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create local storage: %w", err)
+	}
+	lock, err := lockFileShared(filepath.Join(storagePath, storageLockFile))
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := lock.unlock(); err != nil {
+			output.Logf(output.LogLevelWarn, "Failed to unlock local storage: %s", err)
+		}
+	}, nil
+}
+
+// Prune removes blobs from local storage that are not reachable from any manifest in local storage. Manifests
+// listed in any per-repository index or in the shared index.json are treated as roots. Blobs modified within
+// gracePeriod are kept even if unreachable, as they may belong to an operation that is still in progress in a
+// version of kit that does not lock local storage. If dryRun is true, unreachable blobs are found but not
+// removed. Prune returns the blobs that were (or would be) removed.
+//
+// Prune holds an exclusive lock on local storage (see LockStorageForWrite) for the whole mark-and-sweep, so that
+// blobs written by a concurrent pack or pull are not removed before they are referenced.
+func Prune(ctx context.Context, storagePath string, dryRun bool, gracePeriod time.Duration) ([]PrunedBlob, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create local storage: %w", err)
+	}
+	lock, err := lockFile(filepath.Join(storagePath, storageLockFile))
+	if err != nil {
+		return nil, err
+	}
+	defer lock.unlock()
+
+	reachable, err := FindReachableBlobs(ctx, storagePath)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-gracePeriod)
+
+	blobsDir := filepath.Join(storagePath, ocispec.ImageBlobsDir)
+	algDirs, err := os.ReadDir(blobsDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read local storage: %w", err)
+	}
+	var pruned []PrunedBlob
+	for _, algDir := range algDirs {
+		alg := digest.Algorithm(algDir.Name())
+		if !algDir.IsDir() || !alg.Available() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(blobsDir, algDir.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read local storage: %w", err)
+		}
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			blobDigest := digest.NewDigestFromEncoded(alg, entry.Name())
+			if !entry.Type().IsRegular() || blobDigest.Validate() != nil {
+				continue
+			}
+			if reachable[blobDigest] {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read blob %s: %w", blobDigest, err)
+			}
+			if info.ModTime().After(cutoff) {
+				output.SafeDebugf("Skipping unreferenced blob %s: modified within grace period", blobDigest)
+				continue
+			}
+			pruned = append(pruned, PrunedBlob{Digest: blobDigest, Size: info.Size()})
+			if dryRun {
+				continue
+			}
+			output.SafeLogf(output.LogLevelTrace, "Removing unreferenced blob %s", blobDigest)
+			if err := os.Remove(filepath.Join(blobsDir, algDir.Name(), entry.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return pruned, fmt.Errorf("failed to remove blob %s: %w", blobDigest, err)
+			}
+		}
+	}
+	return pruned, nil
+}
+
+// FindReachableBlobs returns the set of digests for all blobs that are reachable from a manifest in local storage,
+// including manifests that are only recorded in the shared index.json. These are the blobs that Prune keeps.
+func FindReachableBlobs(ctx context.Context, storagePath string) (map[digest.Digest]bool, error) {
+	var roots []ocispec.Descriptor
+	repos, err := GetAllLocalRepos(storagePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range repos {
+		for _, desc := range repo.GetAllModels() {
+			if !repo.IsShared(desc) {
+				roots = append(roots, desc)
+			}
+		}
+	}
+	// PullModel also records manifests in the shared index.json; these must be kept even if no repository
+	// index refers to them.
+	sharedIndex, err := parseIndex(constants.IndexJsonPath(storagePath))
+	if err != nil {
+		return nil, err
+	}
+	roots = append(roots, sharedIndex.Manifests...)
+
+	storage, err := oci.NewStorage(storagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local storage: %w", err)
+	}
+	reachable := map[digest.Digest]bool{}
+	var mark func(desc ocispec.Descriptor) error
+	mark = func(desc ocispec.Descriptor) error {
+		if reachable[desc.Digest] {
+			return nil
+		}
+		reachable[desc.Digest] = true
+		successors, err := content.Successors(ctx, storage, desc)
+		if err != nil {
+			if errors.Is(err, errdef.ErrNotFound) {
+				output.Logf(output.LogLevelWarn, "Manifest %s is missing from local storage", desc.Digest)
+				return nil
+			}
+			return fmt.Errorf("failed to read manifest %s: %w", desc.Digest, err)
+		}
+		for _, successor := range successors {
+			if err := mark(successor); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, root := range roots {
+		if err := mark(root); err != nil {
+			return nil, err
+		}
+	}
+	return reachable, nil
+}
@@ -51,6 +51,12 @@ func (l *localRepo) PullModel(ctx context.Context, src oras.ReadOnlyTarget, ref
 	if err := l.ensurePullDirs(); err != nil {
 		return ocispec.DescriptorEmptyJSON, fmt.Errorf("failed to set up directories for pull: %w", err)
 	}
+	// Pulled blobs are not referenced until the manifest is added to the index below
+	unlock, err := LockStorageForWrite(l.storagePath)
+	if err != nil {
+		return ocispec.DescriptorEmptyJSON, err
+	}
+	defer unlock()
 
 	progress := output.NewPullProgress(ctx)
 
@@ -99,16 +105,16 @@ func (l *localRepo) PullModel(ctx context.Context, src oras.ReadOnlyTarget, ref
 	}
 
 	// Special handling to make sure local (scoped) repo contains the just-pulled manifest
-	if err := l.localIndex.addManifest(desc); err != nil {
+	if err := l.localIndex.addManifest(ctx, desc); err != nil {
 		return ocispec.DescriptorEmptyJSON, fmt.Errorf("failed to add manifest to index: %w", err)
 	}
-	// This is a workaround to add the manifest to the main index as well; this is necessary for garbage collection to work
-	if err := l.Store.Tag(ctx, desc, desc.Digest.String()); err != nil {
+	// Add the manifest to the shared index as well; this is necessary for garbage collection to work
+	if err := addToSharedIndex(l.storagePath, desc); err != nil {
 		return ocispec.DescriptorEmptyJSON, fmt.Errorf("failed to add manifest to shared index: %w", err)
 	}
 
 	if !util.ReferenceIsDigest(ref.Reference) {
-		if err := l.localIndex.tag(desc, ref.Reference); err != nil {
+		if err := l.localIndex.tag(ctx, desc, ref.Reference); err != nil {
 			return ocispec.DescriptorEmptyJSON, fmt.Errorf("failed to save tag: %w", err)
 		}
 	}
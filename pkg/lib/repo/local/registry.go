@@ -28,6 +28,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/kitops-ml/kitops/pkg/cmd/options"
 	"github.com/kitops-ml/kitops/pkg/lib/constants"
@@ -48,6 +49,10 @@ type LocalRepo interface {
 	GetTags(ocispec.Descriptor) []string
 	PullModel(context.Context, oras.ReadOnlyTarget, registry.Reference, *options.NetworkOptions) (ocispec.Descriptor, error)
 	EnsureDirs(ocispec.Descriptor) error
+	MarkUsed(context.Context, ocispec.Descriptor) error
+	GetTimestamps(ocispec.Descriptor) (added, lastUsed time.Time)
+	GetTagHistory(tag string) ([]TagHistoryEntry, error)
+	IsShared(ocispec.Descriptor) bool
 	oras.Target
 	content.Deleter
 	content.Untagger
@@ -57,6 +62,8 @@ type localRepo struct {
 	storagePath string
 	nameRef     string
 	localIndex  *localIndex
+	// shared contains this repository in each read-only shared storage directory, in order of precedence
+	shared []*sharedRepo
 	*oci.Store
 }
 
@@ -70,10 +77,16 @@ func newLocalRepoForName(storagePath, name string) (LocalRepo, error) {
 	repo.storagePath = storagePath
 	repo.nameRef = name
 
+	// The shared index.json is maintained by kit (see updateSharedIndex) so that it can be updated safely by
+	// concurrent processes; the store would otherwise create and overwrite it without locking.
+	if err := ensureStorageLayout(storagePath); err != nil {
+		return nil, err
+	}
 	store, err := oci.New(storagePath)
 	if err != nil {
 		return nil, err
 	}
+	store.AutoSaveIndex = false
 	repo.Store = store
 
 	// Initialize repo-specific index.json
@@ -83,10 +96,60 @@ func newLocalRepoForName(storagePath, name string) (LocalRepo, error) {
 	}
 	repo.localIndex = localIndex
 
+	shared, err := newSharedRepos(storagePath, name)
+	if err != nil {
+		return nil, err
+	}
+	repo.shared = shared
+
 	return repo, nil
 }
 
+// GetAllLocalRepos returns all repositories in local storage at storagePath, including repositories that are only
+// present in shared storage.
 func GetAllLocalRepos(storagePath string) ([]LocalRepo, error) {
+	repoNames, err := listAllRepoNames(storagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []LocalRepo
+	for _, repoName := range repoNames {
+		repo, err := newLocalRepoForName(storagePath, repoName)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+
+	// Sort alphabetically
+	slices.SortFunc(repos, func(a, b LocalRepo) int {
+		return strings.Compare(a.GetRepoName(), b.GetRepoName())
+	})
+
+	return repos, nil
+}
+
+// listAllRepoNames returns the names of repositories with an index in storagePath or in shared storage.
+func listAllRepoNames(storagePath string) ([]string, error) {
+	repoNames, err := listRepoNames(storagePath)
+	if err != nil {
+		return nil, err
+	}
+	sharedRepoNames, err := listSharedRepoNames(storagePath)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range sharedRepoNames {
+		if !slices.Contains(repoNames, name) {
+			repoNames = append(repoNames, name)
+		}
+	}
+	return repoNames, nil
+}
+
+// listRepoNames returns the names of repositories with an index in storagePath.
+func listRepoNames(storagePath string) ([]string, error) {
 	entries, err := os.ReadDir(storagePath)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
@@ -95,7 +158,7 @@ func GetAllLocalRepos(storagePath string) ([]LocalRepo, error) {
 		return nil, fmt.Errorf("failed to read local storage: %w", err)
 	}
 
-	var repos []LocalRepo
+	var names []string
 	for _, dirEntry := range entries {
 		if dirEntry.IsDir() {
 			continue
@@ -107,19 +170,9 @@ func GetAllLocalRepos(storagePath string) ([]LocalRepo, error) {
 		if err != nil {
 			return nil, err
 		}
-		repo, err := newLocalRepoForName(storagePath, repoName)
-		if err != nil {
-			return nil, err
-		}
-		repos = append(repos, repo)
+		names = append(names, repoName)
 	}
-
-	// Sort alphabetically
-	slices.SortFunc(repos, func(a, b LocalRepo) int {
-		return strings.Compare(a.GetRepoName(), b.GetRepoName())
-	})
-
-	return repos, nil
+	return names, nil
 }
 
 // GetRepoName returns the string representation of <registry>/<repository> for the current local repo.
@@ -127,6 +180,8 @@ func (lr *localRepo) GetRepoName() string {
 	return lr.nameRef
 }
 
+// BlobPath returns the path for the blob described by desc in writable storage. Blobs that are only present in
+// shared storage are not stored at this path.
 func (lr *localRepo) BlobPath(desc ocispec.Descriptor) string {
 	return filepath.Join(lr.storagePath, ocispec.ImageBlobsDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded())
 }
@@ -136,24 +191,76 @@ func (lr *localRepo) Delete(ctx context.Context, target ocispec.Descriptor) erro
 	if target.MediaType != ocispec.MediaTypeImageManifest {
 		return lr.Store.Delete(ctx, target)
 	}
+	if lr.IsShared(target) {
+		return fmt.Errorf("modelkit %s is in shared storage: %w", target.Digest, ErrSharedStorage)
+	}
 
 	canDelete, err := canSafelyDeleteManifest(ctx, lr.storagePath, target)
 	if err != nil {
 		return fmt.Errorf("failed to check if manifest can be deleted: %w", err)
 	}
 	if canDelete {
-		if err := lr.Store.Delete(ctx, target); err != nil {
+		err := updateSharedIndex(lr.storagePath, func(index *ocispec.Index) error {
+			// Reload the store so that blobs shared with manifests added by other processes since this repo
+			// was opened are not removed along with the manifest.
+			store, err := oci.New(lr.storagePath)
+			if err != nil {
+				return err
+			}
+			store.AutoSaveIndex = false
+			store.AutoGC = false
+			if err := deleteManifest(ctx, store, target); err != nil {
+				return err
+			}
+			removeFromSharedIndex(index, target.Digest)
+			return nil
+		})
+		if err != nil {
 			return err
 		}
 	}
-	return lr.localIndex.delete(target)
+	return lr.localIndex.delete(ctx, target)
+}
+
+// deleteManifest deletes the manifest described by target from store, along with any blobs it refers to that are
+// not referred to by another manifest. Unlike the store's automatic garbage collection, blobs that are not present
+// (e.g. because they are in shared storage) are skipped rather than treated as an error.
+func deleteManifest(ctx context.Context, store *oci.Store, target ocispec.Descriptor) error {
+	successors, err := content.Successors(ctx, store, target)
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(ctx, target); err != nil {
+		return err
+	}
+	for _, successor := range successors {
+		if successor.Data != nil {
+			continue
+		}
+		predecessors, err := store.Predecessors(ctx, successor)
+		if err != nil {
+			return err
+		}
+		if len(predecessors) > 0 {
+			continue
+		}
+		if err := store.Delete(ctx, successor); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
 }
 
 func (lr *localRepo) Exists(ctx context.Context, target ocispec.Descriptor) (exists bool, err error) {
 	if target.MediaType == ocispec.MediaTypeImageManifest {
+		// Manifests in shared storage are not considered to exist here, so that they are added to writable
+		// storage (e.g. when tagged) rather than skipped.
 		exists, err = lr.localIndex.exists(target), nil
 	} else {
 		exists, err = lr.Store.Exists(ctx, target)
+		if err == nil && !exists {
+			exists, err = lr.sharedBlobExists(ctx, target)
+		}
 	}
 	if err != nil {
 		return false, err
@@ -170,6 +277,9 @@ func (lr *localRepo) Fetch(ctx context.Context, target ocispec.Descriptor) (io.R
 	output.SafeLogf(output.LogLevelTrace, "Fetching digest %s in local repository %s", target.Digest.String(), lr.nameRef)
 	if target.MediaType == ocispec.MediaTypeImageManifest {
 		if exists := lr.localIndex.exists(target); !exists {
+			if lr.IsShared(target) {
+				return lr.fetchShared(ctx, target)
+			}
 			return nil, errdef.ErrNotFound
 		}
 	}
@@ -178,7 +288,11 @@ func (lr *localRepo) Fetch(ctx context.Context, target ocispec.Descriptor) (io.R
 		return io.NopCloser(bytes.NewReader(target.Data)), nil
 	}
 
-	return lr.Store.Fetch(ctx, target)
+	rc, err := lr.Store.Fetch(ctx, target)
+	if errors.Is(err, errdef.ErrNotFound) && len(lr.shared) > 0 {
+		return lr.fetchShared(ctx, target)
+	}
+	return rc, err
 }
 
 func (lr *localRepo) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
@@ -196,33 +310,108 @@ func (lr *localRepo) Push(ctx context.Context, expected ocispec.Descriptor, cont
 				return err
 			}
 		}
-		return lr.localIndex.addManifest(expected)
+		if err := addToSharedIndex(lr.storagePath, expected); err != nil {
+			return err
+		}
+		return lr.localIndex.addManifest(ctx, expected)
 	}
 	return lr.Store.Push(ctx, expected, content)
 }
 
 func (lr *localRepo) Resolve(_ context.Context, reference string) (ocispec.Descriptor, error) {
 	output.SafeLogf(output.LogLevelTrace, "Resolving reference %s in local repository %s", reference, lr.nameRef)
-	return lr.localIndex.resolve(reference)
+	desc, err := lr.localIndex.resolve(reference)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return lr.resolveShared(reference)
+	}
+	return desc, err
 }
 
-func (lr *localRepo) Tag(_ context.Context, desc ocispec.Descriptor, reference string) error {
+func (lr *localRepo) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
 	output.SafeLogf(output.LogLevelTrace, "Tagging digest %s with %s in local repository %s", desc.Digest.String(), reference, lr.nameRef)
 	// TODO: should we tag it in the general index.json too?
-	return lr.localIndex.tag(desc, reference)
+	if lr.IsShared(desc) {
+		if err := lr.copySharedManifest(ctx, desc); err != nil {
+			return err
+		}
+	}
+	return lr.localIndex.tag(ctx, desc, reference)
 }
 
-func (lr *localRepo) Untag(_ context.Context, reference string) error {
+func (lr *localRepo) Untag(ctx context.Context, reference string) error {
 	output.SafeLogf(output.LogLevelTrace, "Untagging reference %s in local repository %s", reference, lr.nameRef)
-	return lr.localIndex.untag(reference)
+	if _, err := lr.localIndex.resolve(reference); errors.Is(err, errdef.ErrNotFound) {
+		if _, err := lr.resolveShared(reference); err == nil {
+			return fmt.Errorf("tag %s is in shared storage: %w", reference, ErrSharedStorage)
+		}
+	}
+	return lr.localIndex.untag(ctx, reference)
 }
 
+// GetAllModels returns descriptors for all modelkits in this repository, including modelkits in shared storage.
 func (lr *localRepo) GetAllModels() []ocispec.Descriptor {
-	return lr.localIndex.Manifests
+	if len(lr.shared) == 0 {
+		return lr.localIndex.Manifests
+	}
+	return append(slices.Clone(lr.localIndex.Manifests), lr.sharedModels()...)
 }
 
 func (lr *localRepo) GetTags(desc ocispec.Descriptor) []string {
-	return lr.localIndex.listTags(desc)
+	tags := lr.localIndex.listTags(desc)
+	if len(lr.shared) == 0 {
+		return tags
+	}
+	tags = append(tags, lr.sharedTags(desc)...)
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// MarkUsed records that the modelkit described by desc was used (e.g. unpacked or pushed) now.
+func (lr *localRepo) MarkUsed(ctx context.Context, desc ocispec.Descriptor) error {
+	if lr.IsShared(desc) {
+		// Usage is not recorded for modelkits in read-only shared storage
+		return nil
+	}
+	output.SafeLogf(output.LogLevelTrace, "Marking digest %s as used in local repository %s", desc.Digest.String(), lr.nameRef)
+	return lr.localIndex.markUsed(ctx, desc, time.Now())
+}
+
+// GetTimestamps returns when the modelkit described by desc was added to local storage (by packing or pulling
+// it) and when it was last used. For modelkits stored by older versions of Kit, the modification time of the
+// manifest is used instead.
+func (lr *localRepo) GetTimestamps(desc ocispec.Descriptor) (added, lastUsed time.Time) {
+	added, lastUsed = lr.localIndex.timestamps(desc)
+	if lr.IsShared(desc) {
+		added, lastUsed = lr.sharedTimestamps(desc)
+	}
+	if added.IsZero() {
+		if fi, err := os.Stat(lr.BlobPath(desc)); err == nil {
+			added = fi.ModTime()
+		}
+	}
+	if lastUsed.Before(added) {
+		lastUsed = added
+	}
+	return added, lastUsed
+}
+
+// GetTagHistory returns the recorded changes to tag in this repository, oldest first. If tag is empty, changes
+// to all tags are returned.
+func (lr *localRepo) GetTagHistory(tag string) ([]TagHistoryEntry, error) {
+	history, err := readTagHistory(lr.localIndex.historyPath)
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		return history, nil
+	}
+	var tagHistory []TagHistoryEntry
+	for _, entry := range history {
+		if entry.Tag == tag {
+			tagHistory = append(tagHistory, entry)
+		}
+	}
+	return tagHistory, nil
 }
 
 func (lr *localRepo) EnsureDirs(desc ocispec.Descriptor) error {
@@ -17,12 +17,14 @@
 package local
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/kitops-ml/kitops/pkg/lib/constants"
 	"github.com/kitops-ml/kitops/pkg/lib/repo/util"
@@ -33,49 +35,84 @@ import (
 )
 
 type localIndex struct {
-	indexPath string
-	modelTags *tagsIndex
+	indexPath   string
+	historyPath string
+	modelTags   *tagsIndex
+	// command is the command line recorded in tag history for changes made during the current update
+	command string
 	ocispec.Index
 }
 
 func newLocalIndex(storagePath, repoName string) (*localIndex, error) {
-	li := &localIndex{}
-	indexPath := constants.IndexJsonPathForRepo(storagePath, repoName)
-	index, err := parseIndex(indexPath)
-	if err != nil {
+	li := &localIndex{
+		indexPath:   constants.IndexJsonPathForRepo(storagePath, repoName),
+		historyPath: constants.TagHistoryPathForRepo(storagePath, repoName),
+		modelTags:   emptyTagsIndex(constants.TagIndexPathForRepo(storagePath, repoName)),
+	}
+	if err := li.reload(); err != nil {
 		return nil, err
 	}
-	li.indexPath = indexPath
-	li.Index = *index
+	return li, nil
+}
 
-	tagsIndexPath := constants.TagIndexPathForRepo(storagePath, repoName)
-	tags, err := parseTagsIndex(tagsIndexPath)
+// reload reads the index and tags files from disk, replacing any in-memory state.
+func (li *localIndex) reload() error {
+	index, err := parseIndex(li.indexPath)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	tags, err := parseTagsIndex(li.modelTags.tagsIndexPath)
+	if err != nil {
+		return err
+	}
+	li.Index = *index
 	li.modelTags = tags
-
-	return li, nil
+	return nil
 }
 
-func (li *localIndex) addManifest(manifestDesc ocispec.Descriptor) error {
-	curTag := manifestDesc.Annotations[ocispec.AnnotationRefName]
-	delete(manifestDesc.Annotations, ocispec.AnnotationRefName)
-	if !li.exists(manifestDesc) {
-		li.Manifests = append(li.Manifests, manifestDesc)
-	}
-	if err := li.save(); err != nil {
+// update reloads the index and tags files and calls fn while holding the repository's lock, so that changes
+// made by other processes since the index was loaded are not lost. fn is responsible for saving any changes.
+// Changes to tags are recorded in the repository's tag history along with the command line stored in ctx.
+func (li *localIndex) update(ctx context.Context, fn func() error) error {
+	lock, err := lockFile(lockPath(li.indexPath))
+	if err != nil {
 		return err
 	}
-	if curTag != "" {
-		li.modelTags.tagToDigest[curTag] = manifestDesc
-		return li.modelTags.save()
+	defer lock.unlock()
+	if err := li.reload(); err != nil {
+		return err
 	}
-	return nil
+	li.command = commandFromContext(ctx)
+	return fn()
+}
+
+func (li *localIndex) addManifest(ctx context.Context, manifestDesc ocispec.Descriptor) error {
+	curTag := manifestDesc.Annotations[ocispec.AnnotationRefName]
+	delete(manifestDesc.Annotations, ocispec.AnnotationRefName)
+	return li.update(ctx, func() error {
+		// Packing or pulling a modelkit that is already present refreshes its timestamps
+		now := time.Now()
+		indexDesc := manifestDesc
+		indexDesc.Annotations = map[string]string{}
+		for k, v := range manifestDesc.Annotations {
+			indexDesc.Annotations[k] = v
+		}
+		setTimestamp(&indexDesc, constants.StorageAddedAnnotation, now)
+		setTimestamp(&indexDesc, constants.StorageLastUsedAnnotation, now)
+		if idx := li.indexOf(manifestDesc); idx >= 0 {
+			li.Manifests[idx] = indexDesc
+		} else {
+			li.Manifests = append(li.Manifests, indexDesc)
+		}
+		if curTag != "" {
+			li.modelTags.tagToDigest[curTag] = manifestDesc
+		}
+		return li.save()
+	})
 }
 
 func (li *localIndex) save() error {
-	if err := li.modelTags.save(); err != nil {
+	if err := li.saveTags(); err != nil {
 		return err
 	}
 
@@ -101,40 +138,81 @@ func (li *localIndex) save() error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal index: %w", err)
 	}
-	if err := os.WriteFile(li.indexPath, indexJson, 0666); err != nil {
+	if err := writeFileAtomic(li.indexPath, indexJson, 0644); err != nil {
 		return fmt.Errorf("failed to save index: %w", err)
 	}
 	return nil
 }
 
+// saveTags saves the tags index and records any tags that changed since it was loaded in the tag history.
+func (li *localIndex) saveTags() error {
+	changes := li.modelTags.changes(li.command)
+	if err := li.modelTags.save(); err != nil {
+		return err
+	}
+	li.modelTags.markSaved()
+	return appendTagHistory(li.historyPath, changes)
+}
+
 func (li *localIndex) exists(target ocispec.Descriptor) bool {
-	for _, manifestDesc := range li.Manifests {
+	return li.indexOf(target) >= 0
+}
+
+func (li *localIndex) indexOf(target ocispec.Descriptor) int {
+	for idx, manifestDesc := range li.Manifests {
 		if manifestDesc.Digest == target.Digest {
-			return true
+			return idx
 		}
 	}
-	return false
+	return -1
 }
 
-func (li *localIndex) delete(target ocispec.Descriptor) error {
-	tags := li.listTags(target)
-	for _, tag := range tags {
-		if err := li.untag(tag); err != nil {
-			return err
+// markUsed records that the modelkit described by target was used at time t.
+func (li *localIndex) markUsed(ctx context.Context, target ocispec.Descriptor, t time.Time) error {
+	return li.update(ctx, func() error {
+		idx := li.indexOf(target)
+		if idx < 0 {
+			return fmt.Errorf("%s: %s: %w", target.Digest, target.MediaType, errdef.ErrNotFound)
 		}
-	}
+		setTimestamp(&li.Manifests[idx], constants.StorageLastUsedAnnotation, t)
+		return li.save()
+	})
+}
 
-	var newManifests []ocispec.Descriptor
-	for _, manifestDesc := range li.Manifests {
-		if manifestDesc.Digest != target.Digest {
-			newManifests = append(newManifests, manifestDesc)
-		}
+// timestamps returns the times recorded for the modelkit described by target. Modelkits stored before these
+// times were recorded will return zero times.
+func (li *localIndex) timestamps(target ocispec.Descriptor) (added, lastUsed time.Time) {
+	idx := li.indexOf(target)
+	if idx < 0 {
+		return time.Time{}, time.Time{}
 	}
-	li.Manifests = newManifests
-	if err := li.save(); err != nil {
-		return err
+	annotations := li.Manifests[idx].Annotations
+	added, _ = time.Parse(time.RFC3339Nano, annotations[constants.StorageAddedAnnotation])
+	lastUsed, _ = time.Parse(time.RFC3339Nano, annotations[constants.StorageLastUsedAnnotation])
+	return added, lastUsed
+}
+
+func setTimestamp(desc *ocispec.Descriptor, annotation string, t time.Time) {
+	if desc.Annotations == nil {
+		desc.Annotations = map[string]string{}
 	}
-	return nil
+	desc.Annotations[annotation] = t.UTC().Format(time.RFC3339Nano)
+}
+
+func (li *localIndex) delete(ctx context.Context, target ocispec.Descriptor) error {
+	return li.update(ctx, func() error {
+		for _, tag := range li.listTags(target) {
+			delete(li.modelTags.tagToDigest, tag)
+		}
+		var newManifests []ocispec.Descriptor
+		for _, manifestDesc := range li.Manifests {
+			if manifestDesc.Digest != target.Digest {
+				newManifests = append(newManifests, manifestDesc)
+			}
+		}
+		li.Manifests = newManifests
+		return li.save()
+	})
 }
 
 func (li *localIndex) resolve(reference string) (ocispec.Descriptor, error) {
@@ -153,20 +231,24 @@ func (li *localIndex) resolve(reference string) (ocispec.Descriptor, error) {
 	}
 }
 
-func (li *localIndex) tag(desc ocispec.Descriptor, reference string) error {
-	if !li.hasManifest(desc) {
-		return fmt.Errorf("%s: %s: %w", desc.Digest, desc.MediaType, errdef.ErrNotFound)
-	}
-	li.modelTags.tagToDigest[reference] = desc
-	return li.modelTags.save()
+func (li *localIndex) tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
+	return li.update(ctx, func() error {
+		if !li.hasManifest(desc) {
+			return fmt.Errorf("%s: %s: %w", desc.Digest, desc.MediaType, errdef.ErrNotFound)
+		}
+		li.modelTags.tagToDigest[reference] = desc
+		return li.saveTags()
+	})
 }
 
-func (li *localIndex) untag(reference string) error {
-	if _, err := li.modelTags.get(reference); err != nil {
-		return err
-	}
-	delete(li.modelTags.tagToDigest, reference)
-	return li.modelTags.save()
+func (li *localIndex) untag(ctx context.Context, reference string) error {
+	return li.update(ctx, func() error {
+		if _, err := li.modelTags.get(reference); err != nil {
+			return err
+		}
+		delete(li.modelTags.tagToDigest, reference)
+		return li.saveTags()
+	})
 }
 
 func (li *localIndex) listTags(desc ocispec.Descriptor) []string {
@@ -192,12 +274,15 @@ func (li *localIndex) hasManifest(desc ocispec.Descriptor) bool {
 type tagsIndex struct {
 	tagsIndexPath string
 	tagToDigest   map[string]ocispec.Descriptor
+	// savedTags contains the digest for each tag as of when the index was last loaded or saved
+	savedTags map[string]digest.Digest
 }
 
 func emptyTagsIndex(tagsIndexPath string) *tagsIndex {
 	return &tagsIndex{
 		tagsIndexPath: tagsIndexPath,
 		tagToDigest:   map[string]ocispec.Descriptor{},
+		savedTags:     map[string]digest.Digest{},
 	}
 }
 
@@ -221,7 +306,7 @@ func (ti *tagsIndex) save() error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal tags index: %w", err)
 	}
-	if err := os.WriteFile(ti.tagsIndexPath, jsonBytes, 0666); err != nil {
+	if err := writeFileAtomic(ti.tagsIndexPath, jsonBytes, 0644); err != nil {
 		return fmt.Errorf("failed to save tags index: %w", err)
 	}
 	return nil
@@ -0,0 +1,204 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// ErrSharedStorage is returned when attempting to modify modelkits or tags in read-only shared storage.
+var ErrSharedStorage = errors.New("read-only shared storage cannot be modified")
+
+// sharedRepo is a repository in a read-only shared storage directory (see constants.SharedStorageEnvVar).
+// Its modelkits and blobs are available through a localRepo, but are never modified by it.
+type sharedRepo struct {
+	storagePath string
+	localIndex  *localIndex
+	storage     *oci.Storage
+}
+
+// sharedStoragePaths returns the shared storage directories configured in the environment, in order of
+// precedence. The writable storage directory storagePath is excluded if it is also listed.
+func sharedStoragePaths(storagePath string) []string {
+	var paths []string
+	for _, sharedPath := range filepath.SplitList(os.Getenv(constants.SharedStorageEnvVar)) {
+		if sharedPath == "" || filepath.Clean(sharedPath) == filepath.Clean(storagePath) {
+			continue
+		}
+		if _, err := os.Stat(sharedPath); err != nil {
+			output.SafeLogf(output.LogLevelDebug, "Skipping shared storage %s: %s", sharedPath, err)
+			continue
+		}
+		paths = append(paths, sharedPath)
+	}
+	return paths
+}
+
+func newSharedRepos(storagePath, name string) ([]*sharedRepo, error) {
+	var repos []*sharedRepo
+	for _, sharedPath := range sharedStoragePaths(storagePath) {
+		storage, err := oci.NewStorage(sharedPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read shared storage %s: %w", sharedPath, err)
+		}
+		index, err := newLocalIndex(sharedPath, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read shared storage %s: %w", sharedPath, err)
+		}
+		repos = append(repos, &sharedRepo{
+			storagePath: sharedPath,
+			localIndex:  index,
+			storage:     storage,
+		})
+	}
+	return repos, nil
+}
+
+// IsShared returns true if the modelkit described by desc is only available from read-only shared storage.
+func (lr *localRepo) IsShared(desc ocispec.Descriptor) bool {
+	return !lr.localIndex.exists(desc) && lr.sharedRepoForManifest(desc) != nil
+}
+
+func (lr *localRepo) sharedRepoForManifest(desc ocispec.Descriptor) *sharedRepo {
+	for _, shared := range lr.shared {
+		if shared.localIndex.exists(desc) {
+			return shared
+		}
+	}
+	return nil
+}
+
+// resolveShared resolves reference in shared storage. Tags in writable storage take precedence, so this
+// should only be used if reference is not found there.
+func (lr *localRepo) resolveShared(reference string) (ocispec.Descriptor, error) {
+	for _, shared := range lr.shared {
+		if desc, err := shared.localIndex.resolve(reference); err == nil {
+			return desc, nil
+		}
+	}
+	return ocispec.DescriptorEmptyJSON, errdef.ErrNotFound
+}
+
+// sharedBlobExists returns true if the blob described by desc is present in any shared storage directory.
+func (lr *localRepo) sharedBlobExists(ctx context.Context, desc ocispec.Descriptor) (bool, error) {
+	for _, shared := range lr.shared {
+		if exists, err := shared.storage.Exists(ctx, desc); err != nil {
+			return false, err
+		} else if exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (lr *localRepo) fetchShared(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	for _, shared := range lr.shared {
+		rc, err := shared.storage.Fetch(ctx, desc)
+		if err == nil {
+			output.SafeLogf(output.LogLevelTrace, "Fetching digest %s from shared storage %s", desc.Digest, shared.storagePath)
+			return rc, nil
+		} else if !errors.Is(err, errdef.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, errdef.ErrNotFound
+}
+
+// sharedModels returns modelkits in shared storage that are not also present in writable storage.
+func (lr *localRepo) sharedModels() []ocispec.Descriptor {
+	var models []ocispec.Descriptor
+	for _, shared := range lr.shared {
+		for _, desc := range shared.localIndex.Manifests {
+			if lr.localIndex.exists(desc) || slices.ContainsFunc(models, func(m ocispec.Descriptor) bool { return m.Digest == desc.Digest }) {
+				continue
+			}
+			models = append(models, desc)
+		}
+	}
+	return models
+}
+
+// sharedTags returns tags in shared storage that refer to desc and are not overridden by a tag with the same name
+// in writable storage or in a shared storage directory with higher precedence.
+func (lr *localRepo) sharedTags(desc ocispec.Descriptor) []string {
+	var tags []string
+	for idx, shared := range lr.shared {
+		for _, tag := range shared.localIndex.listTags(desc) {
+			if _, err := lr.localIndex.modelTags.get(tag); err == nil {
+				continue
+			}
+			overridden := slices.ContainsFunc(lr.shared[:idx], func(s *sharedRepo) bool {
+				_, err := s.localIndex.modelTags.get(tag)
+				return err == nil
+			})
+			if !overridden {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+func (lr *localRepo) sharedTimestamps(desc ocispec.Descriptor) (added, lastUsed time.Time) {
+	if shared := lr.sharedRepoForManifest(desc); shared != nil {
+		return shared.localIndex.timestamps(desc)
+	}
+	return time.Time{}, time.Time{}
+}
+
+// copySharedManifest adds a modelkit from shared storage to writable storage so that it can be tagged. Only the
+// manifest is copied; its config and layers continue to be read from shared storage.
+func (lr *localRepo) copySharedManifest(ctx context.Context, desc ocispec.Descriptor) error {
+	rc, err := lr.fetchShared(ctx, desc)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest from shared storage: %w", err)
+	}
+	defer rc.Close()
+	if err := lr.EnsureDirs(desc); err != nil {
+		return err
+	}
+	manifestDesc := desc
+	manifestDesc.Annotations = nil
+	return lr.Push(ctx, manifestDesc, rc)
+}
+
+// listSharedRepoNames returns the names of repositories in all shared storage directories.
+func listSharedRepoNames(storagePath string) ([]string, error) {
+	var names []string
+	for _, sharedPath := range sharedStoragePaths(storagePath) {
+		sharedNames, err := listRepoNames(sharedPath)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, sharedNames...)
+	}
+	return names, nil
+}
@@ -19,9 +19,14 @@ package local
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+
+	"github.com/opencontainers/go-digest"
 	"github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
@@ -58,6 +63,7 @@ func parseTagsIndex(tagsIndexPath string) (*tagsIndex, error) {
 	if err := json.Unmarshal(bytes, &tags.tagToDigest); err != nil {
 		return nil, fmt.Errorf("failed to parse tags index: %w", err)
 	}
+	tags.markSaved()
 	return tags, nil
 }
 
@@ -79,3 +85,125 @@ func canSafelyDeleteManifest(ctx context.Context, storagePath string, desc ocisp
 	}
 	return refCount <= 1, nil
 }
+
+// writeFileAtomic writes data to a temporary file in the same directory as path and renames it into place, so
+// that concurrent readers never observe a partially-written file. If path already exists, its permissions are
+// kept, limited to perm; otherwise the file is created with perm. Unlike os.WriteFile, the umask is not applied,
+// so perm should not grant write access to other users.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	mode := perm
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm() & perm
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	_, writeErr := tmpFile.Write(data)
+	// Flush the file to disk before renaming it so that a crash cannot leave an empty file in place of path
+	syncErr := tmpFile.Sync()
+	closeErr := tmpFile.Close()
+	if err := errors.Join(writeErr, syncErr, closeErr); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// updateSharedIndex applies fn to the shared index.json in storagePath while holding its lock, and saves the
+// result. The shared index lists every manifest in local storage, regardless of repository, and is used by the
+// underlying OCI store to determine which blobs are in use.
+func updateSharedIndex(storagePath string, fn func(index *ocispec.Index) error) error {
+	indexPath := constants.IndexJsonPath(storagePath)
+	lock, err := lockFile(lockPath(indexPath))
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	index, err := parseIndex(indexPath)
+	if err != nil {
+		return err
+	}
+	if index.MediaType == "" {
+		index.MediaType = ocispec.MediaTypeImageIndex
+	}
+	if index.Manifests == nil {
+		index.Manifests = []ocispec.Descriptor{}
+	}
+	if err := fn(index); err != nil {
+		return err
+	}
+	indexJson, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal shared index: %w", err)
+	}
+	if err := writeFileAtomic(indexPath, indexJson, 0644); err != nil {
+		return fmt.Errorf("failed to save shared index: %w", err)
+	}
+	return nil
+}
+
+// ensureStorageLayout creates the oci-layout file and an empty shared index.json in storagePath if they do not
+// exist. The underlying OCI store would otherwise create them without locking when it is first opened, which
+// can result in other processes reading partially-written files.
+func ensureStorageLayout(storagePath string) error {
+	layoutPath := filepath.Join(storagePath, ocispec.ImageLayoutFile)
+	_, layoutErr := os.Stat(layoutPath)
+	_, indexErr := os.Stat(constants.IndexJsonPath(storagePath))
+	if layoutErr == nil && indexErr == nil {
+		return nil
+	}
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return fmt.Errorf("failed to create local storage: %w", err)
+	}
+	return updateSharedIndex(storagePath, func(*ocispec.Index) error {
+		if _, err := os.Stat(layoutPath); err == nil {
+			return nil
+		}
+		layoutJson, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+		if err != nil {
+			return fmt.Errorf("failed to marshal OCI layout: %w", err)
+		}
+		return writeFileAtomic(layoutPath, layoutJson, 0644)
+	})
+}
+
+// addToSharedIndex records the manifest described by desc in the shared index.json, if it is not already present.
+func addToSharedIndex(storagePath string, desc ocispec.Descriptor) error {
+	return updateSharedIndex(storagePath, func(index *ocispec.Index) error {
+		for _, existing := range index.Manifests {
+			if existing.Digest == desc.Digest {
+				return nil
+			}
+		}
+		entry := ocispec.Descriptor{
+			MediaType:    desc.MediaType,
+			Digest:       desc.Digest,
+			Size:         desc.Size,
+			ArtifactType: desc.ArtifactType,
+		}
+		index.Manifests = append(index.Manifests, entry)
+		return nil
+	})
+}
+
+// removeFromSharedIndex removes all entries for the manifest with digest dgst from index.
+func removeFromSharedIndex(index *ocispec.Index, dgst digest.Digest) {
+	manifests := []ocispec.Descriptor{}
+	for _, desc := range index.Manifests {
+		if desc.Digest != dgst {
+			manifests = append(manifests, desc)
+		}
+	}
+	index.Manifests = manifests
+}
@@ -17,7 +17,9 @@
 package remote
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -29,6 +31,7 @@ import (
 
 	"github.com/kitops-ml/kitops/pkg/output"
 
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
@@ -207,77 +210,154 @@ func (r *Repository) uploadBlobChunked(ctx context.Context, location *url.URL, p
 	nextLocation := location
 	for i := 0; i < numChunks; i++ {
 		output.SafeDebugf("Uploading chunk %d/%d, range %d-%d", i+1, numChunks, rangeStart, rangeEnd)
-
 		bodyLength := rangeEnd - rangeStart + 1
-		lr := io.LimitReader(content, int64(bodyLength))
-
-		// Set up request reading from the LimitReader
-		req, err := http.NewRequestWithContext(ctx, http.MethodPatch, nextLocation.String(), lr)
+		respLocation, err := r.uploadChunk(ctx, nextLocation, authHeader, io.LimitReader(content, bodyLength), rangeStart, rangeEnd)
 		if err != nil {
 			return "", err
 		}
-		req.ContentLength = bodyLength
-		req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", rangeStart, rangeEnd))
-		req.Header.Set("Content-Type", "application/octet-stream")
-		if authHeader != "" {
-			req.Header.Set("Authorization", authHeader)
-		}
+		nextLocation = respLocation
 
-		// Submit the chunk as a PATCH
-		// TODO: Handle 416 response code (range not satisfiable)
-		resp, err := r.client().Do(req)
-		if err != nil {
-			return "", fmt.Errorf("failed to upload blob chunk: %w", err)
-		}
-		if resp.StatusCode != http.StatusAccepted {
-			defer resp.Body.Close()
-			return "", handleRemoteError(resp)
-		}
-		resp.Body.Close()
+		// Prepare next range
+		rangeStart = rangeEnd + 1
+		rangeEnd = min(expected.Size-1, rangeEnd+uploadChunkDefaultSize)
+	}
 
-		// Parse and verify data out of response
-		// Location should be the next upload location
-		respLocation, err := resp.Location()
-		if err != nil {
-			return "", fmt.Errorf("missing Location header in response")
-		}
-		nextLocation = respLocation
+	return r.finalizeUpload(ctx, nextLocation, authHeader, expected.Digest)
+}
+
+// CanStreamBlobs returns true if the registry supports pushing blobs whose size and digest are not known in advance
+// through PushBlobStream. This requires chunked uploads, which some registries do not support.
+func (r *Repository) CanStreamBlobs() bool {
+	return getUploadFormat(r.Reference.Host(), math.MaxInt64) == uploadChunkedPatch
+}
 
-		// Verify Range header in response matches what we expect
-		respRange := resp.Header.Get("Range")
-		if respRange == "" {
-			return "", fmt.Errorf("missing Range header in response")
+// PushBlobStream pushes a blob whose size and digest are not known in advance, reading content until EOF, and returns
+// a descriptor for the pushed blob. Content is held in memory one chunk at a time, so that it does not need to be
+// saved to disk before uploading. If content fits in a single chunk, it is uploaded in one PUT request; otherwise it
+// is uploaded through PATCH requests, which should only be used if CanStreamBlobs returns true.
+func (r *Repository) PushBlobStream(ctx context.Context, mediaType string, content io.Reader) (ocispec.Descriptor, error) {
+	ctx = auth.AppendRepositoryScope(ctx, r.Reference, auth.ActionPull, auth.ActionPush)
+	sessionURL, postResp, err := r.initiateUploadSession(ctx)
+	if err != nil {
+		return ocispec.DescriptorEmptyJSON, err
+	}
+
+	chunk := make([]byte, uploadStreamChunkSize)
+	n, err := io.ReadFull(content, chunk)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		desc := ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(chunk[:n]),
+			Size:      int64(n),
 		}
-		startEnd := strings.Split(respRange, "-")
-		if len(startEnd) != 2 || startEnd[0] != "0" {
-			return "", fmt.Errorf("server returned invalid Range header: %s", respRange)
+		blobUrl, err := r.uploadBlobMonolithic(ctx, sessionURL, postResp, desc, bytes.NewReader(chunk[:n]))
+		if err != nil {
+			return ocispec.DescriptorEmptyJSON, err
 		}
-		curEnd, err := strconv.ParseInt(startEnd[1], 10, 0)
+		output.SafeDebugf("Blob uploaded, available at url %s", blobUrl)
+		return desc, nil
+	} else if err != nil {
+		return ocispec.DescriptorEmptyJSON, fmt.Errorf("failed to read blob content: %w", err)
+	}
+
+	authHeader := postResp.Request.Header.Get("Authorization")
+	digester := digest.Canonical.Digester()
+	nextLocation := sessionURL
+	size := int64(0)
+	for n > 0 {
+		digester.Hash().Write(chunk[:n])
+		output.SafeDebugf("Uploading chunk, range %d-%d", size, size+int64(n)-1)
+		nextLocation, err = r.uploadChunk(ctx, nextLocation, authHeader, bytes.NewReader(chunk[:n]), size, size+int64(n)-1)
 		if err != nil {
-			return "", fmt.Errorf("server returned invalid Range header: %s", respRange)
+			return ocispec.DescriptorEmptyJSON, err
 		}
-		if curEnd != rangeEnd {
-			return "", fmt.Errorf("mismatch in range header: expected 0-%d, actual 0-%d", rangeEnd, curEnd)
+		size += int64(n)
+
+		n, err = io.ReadFull(content, chunk)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return ocispec.DescriptorEmptyJSON, fmt.Errorf("failed to read blob content: %w", err)
 		}
+	}
 
-		// Prepare next range
-		rangeStart = rangeEnd + 1
-		rangeEnd = min(expected.Size-1, rangeEnd+uploadChunkDefaultSize)
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digester.Digest(),
+		Size:      size,
+	}
+	blobUrl, err := r.finalizeUpload(ctx, nextLocation, authHeader, desc.Digest)
+	if err != nil {
+		return ocispec.DescriptorEmptyJSON, err
 	}
+	output.SafeDebugf("Blob uploaded, available at url %s", blobUrl)
+	return desc, nil
+}
 
-	// Final PUT request to mark upload as completed for server. Note that the final chunk _could_ be included in this
-	// PUT but isn't for simplicity
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, nextLocation.String(), nil)
+// uploadChunk uploads the bytes from rangeStart to rangeEnd (inclusive) of a blob, read from chunk, in a PATCH request to
+// location. It returns the location to use for the next request in the upload.
+func (r *Repository) uploadChunk(ctx context.Context, location *url.URL, authHeader string, chunk io.Reader, rangeStart, rangeEnd int64) (*url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, location.String(), chunk)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = rangeEnd - rangeStart + 1
+	req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", rangeStart, rangeEnd))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+
+	// Submit the chunk as a PATCH
+	// TODO: Handle 416 response code (range not satisfiable)
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload blob chunk: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, handleRemoteError(resp)
+	}
+
+	// Parse and verify data out of response
+	// Location should be the next upload location
+	respLocation, err := resp.Location()
+	if err != nil {
+		return nil, fmt.Errorf("missing Location header in response")
+	}
+
+	// Verify Range header in response matches what we expect
+	respRange := resp.Header.Get("Range")
+	if respRange == "" {
+		return nil, fmt.Errorf("missing Range header in response")
+	}
+	startEnd := strings.Split(respRange, "-")
+	if len(startEnd) != 2 || startEnd[0] != "0" {
+		return nil, fmt.Errorf("server returned invalid Range header: %s", respRange)
+	}
+	curEnd, err := strconv.ParseInt(startEnd[1], 10, 0)
+	if err != nil {
+		return nil, fmt.Errorf("server returned invalid Range header: %s", respRange)
+	}
+	if curEnd != rangeEnd {
+		return nil, fmt.Errorf("mismatch in range header: expected 0-%d, actual 0-%d", rangeEnd, curEnd)
+	}
+	return respLocation, nil
+}
+
+// finalizeUpload sends the final PUT request for a chunked upload at location, marking the upload of the blob with
+// digest blobDigest as complete. It returns the URL of the uploaded blob.
+func (r *Repository) finalizeUpload(ctx context.Context, location *url.URL, authHeader string, blobDigest digest.Digest) (string, error) {
+	// Note that the final chunk _could_ be included in this PUT but isn't for simplicity
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, location.String(), nil)
 	if err != nil {
 		return "", err
 	}
 	// Set digest query to mark this as completing the upload
 	q := req.URL.Query()
-	q.Set("digest", expected.Digest.String())
+	q.Set("digest", blobDigest.String())
 	req.URL.RawQuery = q.Encode()
 	// Reuse credentials from POST request that initiated upload
-	if auth := postResp.Request.Header.Get("Authorization"); auth != "" {
-		req.Header.Set("Authorization", auth)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
 	}
 
 	output.SafeDebugf("Finalizing upload")
@@ -294,6 +374,7 @@ func (r *Repository) uploadBlobChunked(ctx context.Context, location *url.URL, p
 	blobLocation, err := resp.Location()
 	if err != nil {
 		output.Errorf("Warning: remote registry did not return blob location")
+		return "", nil
 	}
 
 	return blobLocation.String(), nil
@@ -316,6 +397,14 @@ func buildRepositoryBlobUploadURL(plainHTTP bool, ref registry.Reference) string
 	return fmt.Sprintf("%s://%s/v2/%s/blobs/uploads/", scheme, ref.Host(), ref.Repository)
 }
 
+func buildRepositoryBlobURL(plainHTTP bool, ref registry.Reference, blobDigest string) string {
+	scheme := "https"
+	if plainHTTP {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/blobs/%s", scheme, ref.Host(), ref.Repository, blobDigest)
+}
+
 func buildRepositoryManifestsURL(plainHTTP bool, registryRef registry.Reference, manifestRef string) string {
 	scheme := "https"
 	if plainHTTP {
@@ -0,0 +1,200 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kitops-ml/kitops/pkg/output"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// maxFetchRetries is the maximum number of times reading content is resumed without making progress
+// before giving up.
+const maxFetchRetries = 5
+
+// fetchRetryDelay is multiplied by the number of the current retry to get the delay before resuming a read.
+var fetchRetryDelay = 500 * time.Millisecond
+
+// errRangeNotSupported is returned by fetchBlobRange if the registry does not return partial content
+// for a range request.
+var errRangeNotSupported = errors.New("registry does not support range requests")
+
+// Fetch fetches the content identified by target. If reading a blob fails partway through (e.g. due
+// to a dropped connection), the read is resumed from the current offset using a range request if the
+// registry supports it, or by fetching the content again and skipping the data that was already read
+// otherwise.
+func (r *Repository) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	rc, err := r.Repository.Fetch(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	reader := &resumableReader{
+		ctx:   ctx,
+		fetch: r.Repository.Fetch,
+		desc:  target,
+		rc:    rc,
+	}
+	// Manifests are small and are not served from the blobs endpoint, so they are always fetched again in full
+	if !isManifestMediaType(target.MediaType) {
+		reader.fetchRange = r.fetchBlobRange
+	}
+	return reader, nil
+}
+
+// fetchBlobRange fetches the contents of the blob described by desc, starting at offset, using a range
+// request. If the registry responds with anything other than the requested range, an error wrapping
+// errRangeNotSupported is returned.
+func (r *Repository) fetchBlobRange(ctx context.Context, desc ocispec.Descriptor, offset int64) (io.ReadCloser, error) {
+	ctx = auth.AppendRepositoryScope(ctx, r.Reference, auth.ActionPull)
+	blobURL := buildRepositoryBlobURL(r.PlainHttp, r.Reference, desc.Digest.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, blobURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error generating request: %w", err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", desc.Digest, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		expectedRange := fmt.Sprintf("bytes %d-%d/%d", offset, desc.Size-1, desc.Size)
+		if contentRange := resp.Header.Get("Content-Range"); contentRange != expectedRange {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w: expected range %q, got %q", errRangeNotSupported, expectedRange, contentRange)
+		}
+		return resp.Body, nil
+	case http.StatusOK:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: registry returned full content", errRangeNotSupported)
+	default:
+		defer resp.Body.Close()
+		return nil, handleRemoteError(resp)
+	}
+}
+
+// resumableReader reads content fetched from a registry, resuming the read if it fails partway through.
+type resumableReader struct {
+	ctx   context.Context
+	fetch func(context.Context, ocispec.Descriptor) (io.ReadCloser, error)
+	// fetchRange fetches content starting at an offset. If nil, resuming always fetches the content again
+	fetchRange func(context.Context, ocispec.Descriptor, int64) (io.ReadCloser, error)
+	desc       ocispec.Descriptor
+	rc         io.ReadCloser
+	offset     int64
+	retries    int
+}
+
+func (r *resumableReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.rc.Read(p)
+		r.offset += int64(n)
+		if n > 0 {
+			r.retries = 0
+		}
+		if err == nil || errors.Is(err, io.EOF) || r.ctx.Err() != nil {
+			return n, err
+		}
+		if r.retries >= maxFetchRetries {
+			return n, fmt.Errorf("failed to read %s after %d retries: %w", r.desc.Digest, r.retries, err)
+		}
+		r.retries++
+		output.Debugf("Failed to read %s at offset %d: %s. Resuming (attempt %d of %d)", r.desc.Digest, r.offset, err, r.retries, maxFetchRetries)
+		if resumeErr := r.resume(); resumeErr != nil {
+			if r.ctx.Err() != nil {
+				return n, r.ctx.Err()
+			}
+			output.Debugf("Failed to resume reading %s: %s", r.desc.Digest, resumeErr)
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// resume restarts reading content from the current offset, after waiting for the retry delay.
+func (r *resumableReader) resume() error {
+	select {
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	case <-time.After(fetchRetryDelay * time.Duration(r.retries)):
+	}
+
+	r.rc.Close()
+	if r.fetchRange != nil && r.offset > 0 {
+		rc, err := r.fetchRange(r.ctx, r.desc, r.offset)
+		if err == nil {
+			r.rc = rc
+			return nil
+		}
+		if !errors.Is(err, errRangeNotSupported) {
+			r.rc = errReader{err}
+			return err
+		}
+		output.Debugf("Range request for %s failed: %s. Fetching it again from the start", r.desc.Digest, err)
+	}
+	rc, err := r.fetch(r.ctx, r.desc)
+	if err != nil {
+		r.rc = errReader{err}
+		return err
+	}
+	if _, err := io.CopyN(io.Discard, rc, r.offset); err != nil {
+		rc.Close()
+		r.rc = errReader{err}
+		return err
+	}
+	r.rc = rc
+	return nil
+}
+
+func (r *resumableReader) Close() error {
+	return r.rc.Close()
+}
+
+// errReader is a ReadCloser that always returns err. It is used in place of content that could not be fetched
+// so that the failure is retried on the next read.
+type errReader struct {
+	err error
+}
+
+func (e errReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
+
+func (e errReader) Close() error {
+	return nil
+}
+
+// isManifestMediaType returns whether mediaType is a manifest or index media type, which are fetched from the
+// manifests endpoint rather than the blobs endpoint.
+func isManifestMediaType(mediaType string) bool {
+	switch mediaType {
+	case ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageIndex,
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json":
+		return true
+	}
+	return false
+}
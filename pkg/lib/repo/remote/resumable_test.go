@@ -0,0 +1,221 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"oras.land/oras-go/v2/registry"
+)
+
+// failingReader returns up to remaining bytes of its data and then fails with errConnReset.
+type failingReader struct {
+	r         io.Reader
+	remaining int
+}
+
+var errConnReset = errors.New("connection reset")
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.remaining <= 0 {
+		return 0, errConnReset
+	}
+	if len(p) > f.remaining {
+		p = p[:f.remaining]
+	}
+	n, err := f.r.Read(p)
+	f.remaining -= n
+	return n, err
+}
+
+func (f *failingReader) Close() error {
+	return nil
+}
+
+func TestResumableReader(t *testing.T) {
+	defaultDelay := fetchRetryDelay
+	fetchRetryDelay = 0
+	t.Cleanup(func() { fetchRetryDelay = defaultDelay })
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	desc := ocispec.Descriptor{Digest: digest.FromBytes(data), Size: int64(len(data))}
+
+	tests := []struct {
+		name        string
+		failAfter   []int
+		expectError bool
+	}{
+		{name: "no failures", failAfter: []int{len(data) + 1}},
+		{name: "resumes after failures", failAfter: []int{5, 10, 30, len(data) + 1}},
+		{name: "fails without progress", failAfter: []int{5, 0, 5, 5, 5, 5, 5, 5}, expectError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetches := 0
+			fetch := func(context.Context, ocispec.Descriptor) (io.ReadCloser, error) {
+				if fetches >= len(tt.failAfter) {
+					return nil, errConnReset
+				}
+				rc := &failingReader{r: bytes.NewReader(data), remaining: tt.failAfter[fetches]}
+				fetches++
+				return rc, nil
+			}
+			rc, _ := fetch(context.Background(), desc)
+			r := &resumableReader{ctx: context.Background(), fetch: fetch, desc: desc, rc: rc}
+			got, err := io.ReadAll(r)
+			if tt.expectError {
+				assert.ErrorIs(t, err, errConnReset)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, data, got)
+			}
+		})
+	}
+}
+
+func TestResumableReaderRangeRequests(t *testing.T) {
+	defaultDelay := fetchRetryDelay
+	fetchRetryDelay = 0
+	t.Cleanup(func() { fetchRetryDelay = defaultDelay })
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	desc := ocispec.Descriptor{Digest: digest.FromBytes(data), Size: int64(len(data))}
+
+	tests := []struct {
+		name            string
+		rangeErr        error
+		expectedFetches int
+	}{
+		{name: "resumes with range requests", expectedFetches: 1},
+		{name: "falls back to full fetch", rangeErr: errRangeNotSupported, expectedFetches: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetches := 0
+			fetch := func(context.Context, ocispec.Descriptor) (io.ReadCloser, error) {
+				fetches++
+				return &failingReader{r: bytes.NewReader(data), remaining: 10 * fetches}, nil
+			}
+			var offsets []int64
+			fetchRange := func(_ context.Context, _ ocispec.Descriptor, offset int64) (io.ReadCloser, error) {
+				offsets = append(offsets, offset)
+				if tt.rangeErr != nil {
+					return nil, tt.rangeErr
+				}
+				return &failingReader{r: bytes.NewReader(data[offset:]), remaining: 10}, nil
+			}
+			rc, _ := fetch(context.Background(), desc)
+			r := &resumableReader{ctx: context.Background(), fetch: fetch, fetchRange: fetchRange, desc: desc, rc: rc}
+			got, err := io.ReadAll(r)
+			if assert.NoError(t, err) {
+				assert.Equal(t, data, got)
+			}
+			assert.Equal(t, tt.expectedFetches, fetches)
+			if tt.rangeErr == nil {
+				assert.Equal(t, []int64{10, 20, 30}, offsets)
+			}
+		})
+	}
+}
+
+func TestFetchBlobRange(t *testing.T) {
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	desc := ocispec.Descriptor{Digest: digest.FromBytes(data), Size: int64(len(data))}
+
+	tests := []struct {
+		name        string
+		handler     func(w http.ResponseWriter, offset int)
+		expectErrIs error
+		expectError bool
+	}{
+		{
+			name: "partial content",
+			handler: func(w http.ResponseWriter, offset int) {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(data)-1, len(data)))
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write(data[offset:])
+			},
+		},
+		{
+			name: "range ignored",
+			handler: func(w http.ResponseWriter, _ int) {
+				w.WriteHeader(http.StatusOK)
+				w.Write(data)
+			},
+			expectErrIs: errRangeNotSupported,
+		},
+		{
+			name: "wrong range",
+			handler: func(w http.ResponseWriter, _ int) {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(data)-1, len(data)))
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write(data)
+			},
+			expectErrIs: errRangeNotSupported,
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, _ int) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const offset = 10
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				assert.Equal(t, "/v2/test/repo/blobs/"+desc.Digest.String(), req.URL.Path)
+				assert.Equal(t, fmt.Sprintf("bytes=%d-", offset), req.Header.Get("Range"))
+				tt.handler(w, offset)
+			}))
+			defer server.Close()
+			serverURL, _ := url.Parse(server.URL)
+			repo := &Repository{
+				Reference: registry.Reference{Registry: serverURL.Host, Repository: "test/repo"},
+				PlainHttp: true,
+				Client:    server.Client(),
+			}
+
+			rc, err := repo.fetchBlobRange(context.Background(), desc, offset)
+			switch {
+			case tt.expectErrIs != nil:
+				assert.ErrorIs(t, err, tt.expectErrIs)
+			case tt.expectError:
+				assert.Error(t, err)
+				assert.False(t, errors.Is(err, errRangeNotSupported))
+			default:
+				if assert.NoError(t, err) {
+					defer rc.Close()
+					got, err := io.ReadAll(rc)
+					assert.NoError(t, err)
+					assert.Equal(t, data[offset:], got)
+				}
+			}
+		})
+	}
+}
@@ -33,6 +33,9 @@ const (
 
 const (
 	uploadChunkDefaultSize int64 = 100 << 20
+	// uploadStreamChunkSize is the size of chunks used when pushing blobs through PushBlobStream. Each chunk is held
+	// in memory until it is uploaded, so this is smaller than uploadChunkDefaultSize.
+	uploadStreamChunkSize = 32 << 20
 )
 
 var (
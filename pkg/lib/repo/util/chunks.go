@@ -0,0 +1,158 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// IsLayerChunk returns true if desc is one chunk of a layer that is split into multiple blobs.
+func IsLayerChunk(desc ocispec.Descriptor) bool {
+	return desc.MediaType == mediatype.LayerChunkMediaType
+}
+
+// GroupLayerChunks groups the layers in a manifest into the layers packed from a Kitfile. Layers that are
+// split into multiple chunk blobs are returned as a single descriptor for the reassembled layer, with the
+// descriptors for its chunks stored in chunks at the same index. For layers that are not split into chunks,
+// the corresponding element of chunks is nil.
+func GroupLayerChunks(manifestLayers []ocispec.Descriptor) (layers []ocispec.Descriptor, chunks [][]ocispec.Descriptor, err error) {
+	for idx := 0; idx < len(manifestLayers); {
+		desc := manifestLayers[idx]
+		if !IsLayerChunk(desc) {
+			layers = append(layers, desc)
+			chunks = append(chunks, nil)
+			idx++
+			continue
+		}
+
+		count, err := strconv.Atoi(desc.Annotations[constants.LayerChunkCountAnnotation])
+		if err != nil || count < 1 {
+			return nil, nil, fmt.Errorf("invalid chunk count for layer %s", desc.Digest)
+		}
+		if idx+count > len(manifestLayers) {
+			return nil, nil, fmt.Errorf("missing chunks for layer %s", desc.Annotations[constants.LayerChunkDigestAnnotation])
+		}
+		layerDigest, err := digest.Parse(desc.Annotations[constants.LayerChunkDigestAnnotation])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid layer digest for chunk %s: %w", desc.Digest, err)
+		}
+		layerMediaType := desc.Annotations[constants.LayerChunkMediaTypeAnnotation]
+		if _, err := mediatype.ParseMediaType(layerMediaType); err != nil {
+			return nil, nil, fmt.Errorf("invalid layer media type for chunk %s: %w", desc.Digest, err)
+		}
+		layerChunks := manifestLayers[idx : idx+count]
+		layer := ocispec.Descriptor{
+			MediaType:   layerMediaType,
+			Digest:      layerDigest,
+			Annotations: maps.Clone(desc.Annotations),
+		}
+		delete(layer.Annotations, constants.LayerChunkIndexAnnotation)
+		delete(layer.Annotations, constants.LayerChunkCountAnnotation)
+		delete(layer.Annotations, constants.LayerChunkDigestAnnotation)
+		delete(layer.Annotations, constants.LayerChunkMediaTypeAnnotation)
+		for chunkIdx, chunk := range layerChunks {
+			if chunk.Annotations[constants.LayerChunkIndexAnnotation] != strconv.Itoa(chunkIdx) ||
+				chunk.Annotations[constants.LayerChunkDigestAnnotation] != layerDigest.String() ||
+				chunk.Annotations[constants.LayerChunkMediaTypeAnnotation] != layerMediaType ||
+				chunk.MediaType != mediatype.LayerChunkMediaType {
+				return nil, nil, fmt.Errorf("chunks for layer %s are invalid or out of order", layerDigest)
+			}
+			layer.Size += chunk.Size
+		}
+		layers = append(layers, layer)
+		chunks = append(chunks, layerChunks)
+		idx += count
+	}
+	return layers, chunks, nil
+}
+
+// FetchLayer returns a reader for the content of layer. If chunks is not empty, the layer is read by
+// concatenating its chunks in order, and the digest of the reassembled layer is verified once it is
+// fully read.
+func FetchLayer(ctx context.Context, fetcher content.Fetcher, layer ocispec.Descriptor, chunks []ocispec.Descriptor) (io.ReadCloser, error) {
+	if len(chunks) == 0 {
+		return fetcher.Fetch(ctx, layer)
+	}
+	return &chunkedLayerReader{
+		ctx:      ctx,
+		fetcher:  fetcher,
+		layer:    layer,
+		chunks:   chunks,
+		verifier: layer.Digest.Verifier(),
+	}, nil
+}
+
+// chunkedLayerReader reads a layer stored as multiple chunk blobs as a single stream.
+type chunkedLayerReader struct {
+	ctx      context.Context
+	fetcher  content.Fetcher
+	layer    ocispec.Descriptor
+	chunks   []ocispec.Descriptor
+	current  io.ReadCloser
+	verifier digest.Verifier
+}
+
+func (r *chunkedLayerReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.chunks) == 0 {
+				if !r.verifier.Verified() {
+					return 0, fmt.Errorf("digest mismatch for reassembled layer %s", r.layer.Digest)
+				}
+				return 0, io.EOF
+			}
+			rc, err := r.fetcher.Fetch(r.ctx, r.chunks[0])
+			if err != nil {
+				return 0, fmt.Errorf("failed to get chunk %s: %w", r.chunks[0].Digest, err)
+			}
+			r.current = rc
+			r.chunks = r.chunks[1:]
+		}
+		n, err := r.current.Read(p)
+		r.verifier.Write(p[:n])
+		if err == io.EOF {
+			closeErr := r.current.Close()
+			r.current = nil
+			if closeErr != nil {
+				return n, closeErr
+			}
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunkedLayerReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
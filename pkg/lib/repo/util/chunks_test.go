@@ -0,0 +1,116 @@
+// Copyright 2024 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+const testMediaType = "application/vnd.kitops.modelkit.model.v1.tar"
+
+// chunkLayer splits data into chunks of chunkSize bytes, pushes them to store, and returns their descriptors
+func chunkLayer(t *testing.T, store *memory.Store, data []byte, chunkSize int) []ocispec.Descriptor {
+	layerDigest := digest.FromBytes(data)
+	var chunks []ocispec.Descriptor
+	numChunks := (len(data) + chunkSize - 1) / chunkSize
+	for idx := 0; idx < numChunks; idx++ {
+		chunkData := data[idx*chunkSize : min((idx+1)*chunkSize, len(data))]
+		desc := ocispec.Descriptor{
+			MediaType: mediatype.LayerChunkMediaType,
+			Digest:    digest.FromBytes(chunkData),
+			Size:      int64(len(chunkData)),
+			Annotations: map[string]string{
+				constants.LayerChunkIndexAnnotation:     strconv.Itoa(idx),
+				constants.LayerChunkCountAnnotation:     strconv.Itoa(numChunks),
+				constants.LayerChunkDigestAnnotation:    layerDigest.String(),
+				constants.LayerChunkMediaTypeAnnotation: testMediaType,
+			},
+		}
+		require.NoError(t, store.Push(context.Background(), desc, bytes.NewReader(chunkData)))
+		chunks = append(chunks, desc)
+	}
+	return chunks
+}
+
+func TestGroupLayerChunks(t *testing.T) {
+	store := memory.New()
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	chunks := chunkLayer(t, store, data, 10)
+	plainLayer := ocispec.Descriptor{MediaType: testMediaType, Digest: digest.FromString("plain"), Size: 5}
+
+	layers, layerChunks, err := GroupLayerChunks(append([]ocispec.Descriptor{plainLayer}, chunks...))
+	require.NoError(t, err)
+	require.Len(t, layers, 2)
+	assert.Equal(t, plainLayer, layers[0])
+	assert.Nil(t, layerChunks[0])
+	assert.Equal(t, digest.FromBytes(data), layers[1].Digest)
+	assert.Equal(t, int64(len(data)), layers[1].Size)
+	assert.Equal(t, testMediaType, layers[1].MediaType)
+	assert.NotContains(t, layers[1].Annotations, constants.LayerChunkIndexAnnotation)
+	assert.NotContains(t, layers[1].Annotations, constants.LayerChunkMediaTypeAnnotation)
+	assert.Equal(t, chunks, layerChunks[1])
+
+	rc, err := FetchLayer(context.Background(), store, layers[1], layerChunks[1])
+	require.NoError(t, err)
+	defer rc.Close()
+	reassembled, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, data, reassembled)
+
+	_, _, err = GroupLayerChunks(chunks[:3])
+	assert.ErrorContains(t, err, "missing chunks")
+	_, _, err = GroupLayerChunks([]ocispec.Descriptor{chunks[1], chunks[0], chunks[2], chunks[3]})
+	assert.ErrorContains(t, err, "out of order")
+
+	badMediaType := slices.Clone(chunks)
+	badMediaType[0].Annotations = maps.Clone(chunks[0].Annotations)
+	badMediaType[0].Annotations[constants.LayerChunkMediaTypeAnnotation] = "application/octet-stream"
+	_, _, err = GroupLayerChunks(badMediaType)
+	assert.ErrorContains(t, err, "invalid layer media type")
+}
+
+func TestFetchLayerDigestMismatch(t *testing.T) {
+	store := memory.New()
+	chunks := chunkLayer(t, store, []byte("0123456789abcdefghijklmnopqrstuvwxyz"), 10)
+	layers, layerChunks, err := GroupLayerChunks(chunks)
+	require.NoError(t, err)
+
+	// Swap a chunk for different data with the same index
+	otherChunks := chunkLayer(t, store, []byte("9876543210"), 10)
+	layerChunks[0][0] = otherChunks[0]
+
+	rc, err := FetchLayer(context.Background(), store, layers[0], layerChunks[0])
+	require.NoError(t, err)
+	defer rc.Close()
+	_, err = io.ReadAll(rc)
+	assert.ErrorContains(t, err, "digest mismatch")
+}
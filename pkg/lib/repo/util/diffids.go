@@ -0,0 +1,102 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kitops-ml/kitops/pkg/artifact"
+	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
+
+	modelspecv1 "github.com/modelpack/model-spec/specs-go/v1"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+)
+
+// GetLayerDiffIDs returns the expected DiffID (digest of the uncompressed contents) for each layer in layers,
+// as returned by GroupLayerChunks(manifest.Layers). DiffIDs are read from the LayerInfo for the layer's entry
+// in kitfile, falling back to the DiffIDs in the manifest's ModelPack config, if present. If the DiffID for a
+// layer is unknown (e.g. for ModelKits packed by older versions of Kit), the corresponding element is empty.
+func GetLayerDiffIDs(ctx context.Context, store oras.ReadOnlyTarget, manifest *ocispec.Manifest, kitfile *artifact.KitFile, layers []ocispec.Descriptor) ([]digest.Digest, error) {
+	kitfileDiffIDs := map[string]string{}
+	if kitfile != nil {
+		for _, info := range kitfileLayerInfos(kitfile) {
+			if info != nil && info.DiffId != "" {
+				kitfileDiffIDs[info.Digest] = info.DiffId
+			}
+		}
+	}
+	var configDiffIDs []digest.Digest
+	if manifest.Config.MediaType == mediatype.ModelPackConfigMediaType.String() {
+		configBytes, err := content.FetchAll(ctx, store, manifest.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		config := &modelspecv1.Model{}
+		if err := json.Unmarshal(configBytes, config); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+		// DiffIDs in the config are listed in the same order as layers in the manifest
+		if len(config.ModelFS.DiffIDs) == len(layers) {
+			configDiffIDs = config.ModelFS.DiffIDs
+		}
+	}
+
+	diffIDs := make([]digest.Digest, len(layers))
+	for idx, layer := range layers {
+		diffID := digest.Digest(kitfileDiffIDs[layer.Digest.String()])
+		if diffID == "" && configDiffIDs != nil {
+			diffID = configDiffIDs[idx]
+		}
+		if diffID == "" {
+			continue
+		}
+		if err := diffID.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid diffID for layer %s: %w", layer.Digest, err)
+		}
+		diffIDs[idx] = diffID
+	}
+	return diffIDs, nil
+}
+
+// kitfileLayerInfos returns the LayerInfo for every entry in kitfile that is stored as a layer.
+func kitfileLayerInfos(kitfile *artifact.KitFile) []*artifact.LayerInfo {
+	var infos []*artifact.LayerInfo
+	if kitfile.Model != nil {
+		infos = append(infos, kitfile.Model.LayerInfo)
+		for _, part := range kitfile.Model.Parts {
+			infos = append(infos, part.LayerInfo)
+		}
+	}
+	for _, code := range kitfile.Code {
+		infos = append(infos, code.LayerInfo)
+	}
+	for _, dataset := range kitfile.DataSets {
+		infos = append(infos, dataset.LayerInfo)
+	}
+	for _, docs := range kitfile.Docs {
+		infos = append(infos, docs.LayerInfo)
+	}
+	for _, prompt := range kitfile.Prompts {
+		infos = append(infos, prompt.LayerInfo)
+	}
+	return infos
+}
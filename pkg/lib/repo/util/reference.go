@@ -28,6 +28,7 @@ import (
 	"github.com/kitops-ml/kitops/pkg/lib/constants"
 	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
 
+	modelspecv1 "github.com/modelpack/model-spec/specs-go/v1"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2"
@@ -210,8 +211,8 @@ func GetManifest(ctx context.Context, store oras.ReadOnlyTarget, manifestDesc oc
 
 // GetKitfileForManifest returns the Kitfile for a given manifest, either by retrieving it from an
 // OCI store or by reading it from manifest annotations. If manifest type is unrecognized, returns
-// ErrNotAModelKit. If the manifest is recognized but does not contain a Kitfile (e.g. it was not
-// created by Kit), returns ErrNoKitfile.
+// ErrNotAModelKit. If a ModelPack manifest does not contain a Kitfile (e.g. it was not created by Kit),
+// a Kitfile is generated from its config and layers; if this is not possible, returns ErrNoKitfile.
 func GetKitfileForManifest(ctx context.Context, store oras.ReadOnlyTarget, manifest *ocispec.Manifest) (*artifact.KitFile, error) {
 	modelFormat, err := mediatype.ModelFormatForManifest(manifest)
 	if err != nil {
@@ -221,9 +222,13 @@ func GetKitfileForManifest(ctx context.Context, store oras.ReadOnlyTarget, manif
 	case mediatype.KitFormat:
 		return GetConfig(ctx, store, manifest.Config)
 	case mediatype.ModelPackFormat:
-		// TODO: can we (try to) generate a Kitfile from a ModelPack manifest?
 		if manifest.Annotations == nil || manifest.Annotations[constants.KitfileJsonAnnotation] == "" {
-			return nil, ErrNoKitfile
+			// Not packed by Kit; fall back to generating a Kitfile from the config and layers
+			kitfile, err := generateKitfileForModelPack(ctx, store, manifest)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrNoKitfile, err)
+			}
+			return kitfile, nil
 		}
 		kfstring := manifest.Annotations[constants.KitfileJsonAnnotation]
 		kitfile := &artifact.KitFile{}
@@ -237,19 +242,28 @@ func GetKitfileForManifest(ctx context.Context, store oras.ReadOnlyTarget, manif
 	}
 }
 
-// GetConfig returns the config (Kitfile) described by a descriptor. Returns an error if the config blob cannot
-// be resolved or if the descriptor does not describe a Kitfile.
+// GetConfig returns the config (Kitfile) described by a descriptor. If the descriptor describes a ModelPack
+// config, the returned Kitfile contains the package and model metadata from the config, but no layer paths.
+// Returns an error if the config blob cannot be resolved or if the descriptor does not describe a Kitfile
+// or ModelPack config.
 func GetConfig(ctx context.Context, store oras.ReadOnlyTarget, configDesc ocispec.Descriptor) (*artifact.KitFile, error) {
 	if configDesc.MediaType == "" || configDesc.MediaType == ocispec.MediaTypeEmptyJSON {
 		return nil, fmt.Errorf("manifest does not have a config section")
 	}
-	if configDesc.MediaType != mediatype.KitConfigMediaType.String() {
+	if configDesc.MediaType != mediatype.KitConfigMediaType.String() && configDesc.MediaType != mediatype.ModelPackConfigMediaType.String() {
 		return nil, fmt.Errorf("configuration descriptor does not describe a Kitfile")
 	}
 	configBytes, err := content.FetchAll(ctx, store, configDesc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
+	if configDesc.MediaType == mediatype.ModelPackConfigMediaType.String() {
+		modelpackConfig := &modelspecv1.Model{}
+		if err := json.Unmarshal(configBytes, modelpackConfig); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+		return artifact.KitFileFromModelPackConfig(modelpackConfig)
+	}
 	config := &artifact.KitFile{}
 	if err := json.Unmarshal(configBytes, config); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
@@ -21,10 +21,15 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 
 	"github.com/vbauerster/mpb/v8"
 )
 
+// writeMu serializes writes of log lines, which may be logged concurrently (e.g. when packing
+// or pulling layers in parallel).
+var writeMu sync.Mutex
+
 func Infoln(s any) {
 	Logln(LogLevelInfo, s)
 }
@@ -95,6 +100,8 @@ func loglnTo(output io.Writer, level LogLevel, s any) {
 		// Capitalize first letter in string for nicer output, in case it's not already capitalized
 		str = strings.ToUpper(str[:1]) + str[1:]
 		str = level.getPrefix() + str
+		writeMu.Lock()
+		defer writeMu.Unlock()
 		fmt.Fprint(output, str)
 	}
 }
@@ -113,6 +120,8 @@ func logfTo(output io.Writer, level LogLevel, s string, args ...any) {
 		// Capitalize first letter in string for nicer output, in case it's not already capitalized
 		str = strings.ToUpper(str[:1]) + str[1:]
 		str = level.getPrefix() + str
+		writeMu.Lock()
+		defer writeMu.Unlock()
 		fmt.Fprint(output, str)
 	}
 }
@@ -138,31 +138,6 @@ func WrapTarget(wrap oras.Target) (oras.Target, *ProgressLogger) {
 	}, &ProgressLogger{p}
 }
 
-func WrapUnpackReadCloser(size int64, rc io.ReadCloser) (io.ReadCloser, *ProgressLogger) {
-	if !progressEnabled {
-		return rc, &ProgressLogger{stdout}
-	}
-
-	p := mpb.New(
-		mpb.WithWidth(60),
-		mpb.WithRefreshRate(150*time.Millisecond),
-	)
-	bar := p.New(size,
-		barStyle(),
-		mpb.PrependDecorators(
-			decor.Name("Unpacking"),
-		),
-		mpb.AppendDecorators(
-			decor.Counters(decor.SizeB1024(0), "% .1f / % .1f"),
-			decor.Name(" | "),
-			decor.AverageSpeed(decor.SizeB1024(0), "% .2f"),
-		),
-		mpb.BarRemoveOnComplete(),
-	)
-
-	return bar.ProxyReader(rc), &ProgressLogger{p}
-}
-
 type ProgressTar struct {
 	tw  *tar.Writer
 	pw  io.WriteCloser
@@ -180,26 +155,57 @@ func (t *ProgressTar) WriteHeader(hdr *tar.Header) error {
 	return t.tw.WriteHeader(hdr)
 }
 
+// Abort stops displaying the progress bar for t, e.g. if writing the tar failed before completion.
+func (t *ProgressTar) Abort() {
+	if t.bar != nil {
+		t.bar.Abort(true)
+	}
+}
+
 func (t *ProgressTar) Close() error {
+	if t.bar != nil {
+		// The total size may be overestimated (e.g. for hardlinked files); mark the bar as complete
+		t.bar.SetTotal(-1, true)
+	}
 	if t.pw != nil {
 		return t.pw.Close()
 	}
 	return nil
 }
 
-func TarProgress(total int64, tw *tar.Writer) (*ProgressTar, *ProgressLogger) {
-	if !progressEnabled || total == 0 {
-		return &ProgressTar{tw: tw}, &ProgressLogger{stdout}
-	}
+// PackProgress displays progress bars for layers that are packed concurrently.
+type PackProgress struct {
+	progress *mpb.Progress
+	ProgressLogger
+}
 
-	p := mpb.New(
+// NewPackProgress returns a PackProgress for displaying progress while packing layers. Done must be
+// called once all layers are packed.
+func NewPackProgress(ctx context.Context) *PackProgress {
+	if !progressEnabled {
+		return &PackProgress{
+			ProgressLogger: ProgressLogger{stdout},
+		}
+	}
+	p := mpb.NewWithContext(ctx,
 		mpb.WithWidth(60),
 		mpb.WithRefreshRate(150*time.Millisecond),
 	)
-	bar := p.New(total,
+	return &PackProgress{
+		progress:       p,
+		ProgressLogger: ProgressLogger{p},
+	}
+}
+
+// TarProgress wraps tw to show a progress bar for a layer with the given name and total size.
+func (p *PackProgress) TarProgress(name string, total int64, tw *tar.Writer) *ProgressTar {
+	if p.progress == nil || total == 0 {
+		return &ProgressTar{tw: tw}
+	}
+	bar := p.progress.New(total,
 		barStyle(),
 		mpb.PrependDecorators(
-			decor.Name("Packing"),
+			decor.Name("Packing "+name),
 		),
 		mpb.AppendDecorators(
 			decor.Counters(decor.SizeB1024(0), "% .1f / % .1f"),
@@ -209,7 +215,78 @@ func TarProgress(total int64, tw *tar.Writer) (*ProgressTar, *ProgressLogger) {
 		mpb.BarRemoveOnComplete(),
 	)
 	pw := bar.ProxyWriter(tw)
-	return &ProgressTar{tw: tw, pw: pw, bar: bar}, &ProgressLogger{p}
+	return &ProgressTar{tw: tw, pw: pw, bar: bar}
+}
+
+func (p *PackProgress) Done() {
+	if p.progress != nil {
+		p.progress.Wait()
+	}
+}
+
+// UnpackProgress displays progress bars for layers that are unpacked concurrently.
+type UnpackProgress struct {
+	progress *mpb.Progress
+	ProgressLogger
+}
+
+// NewUnpackProgress returns an UnpackProgress for displaying progress while unpacking layers. Done must
+// be called once all layers are unpacked.
+func NewUnpackProgress(ctx context.Context) *UnpackProgress {
+	if !progressEnabled {
+		return &UnpackProgress{
+			ProgressLogger: ProgressLogger{stdout},
+		}
+	}
+	p := mpb.NewWithContext(ctx,
+		mpb.WithWidth(60),
+		mpb.WithRefreshRate(150*time.Millisecond),
+	)
+	return &UnpackProgress{
+		progress:       p,
+		ProgressLogger: ProgressLogger{p},
+	}
+}
+
+// ProxyReader wraps rc to show a progress bar for a layer with the given digest and total size.
+func (p *UnpackProgress) ProxyReader(digest string, size int64, rc io.ReadCloser) io.ReadCloser {
+	if p.progress == nil || size == 0 {
+		return rc
+	}
+	shortDigest := digest[0:min(8, len(digest))]
+	bar := p.progress.New(size,
+		barStyle(),
+		mpb.PrependDecorators(
+			decor.Name("Unpacking "+shortDigest),
+		),
+		mpb.AppendDecorators(
+			decor.Counters(decor.SizeB1024(0), "% .1f / % .1f"),
+			decor.Name(" | "),
+			decor.AverageSpeed(decor.SizeB1024(0), "% .2f"),
+		),
+		mpb.BarRemoveOnComplete(),
+	)
+	return &unpackProgressReader{ReadCloser: bar.ProxyReader(rc), bar: bar}
+}
+
+func (p *UnpackProgress) Done() {
+	if p.progress != nil {
+		p.progress.Wait()
+	}
+}
+
+// unpackProgressReader aborts its progress bar if it is closed before the layer is fully read,
+// e.g. if unpacking the layer failed or was cancelled.
+type unpackProgressReader struct {
+	io.ReadCloser
+	bar *mpb.Bar
+}
+
+func (r *unpackProgressReader) Close() error {
+	if !r.bar.Completed() {
+		r.bar.Abort(true)
+	}
+	return r.ReadCloser.Close()
 }
 
 type PullProgress struct {
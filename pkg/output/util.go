@@ -19,6 +19,8 @@ package output
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 )
 
 func FormatBytes(i int64) string {
@@ -47,3 +49,38 @@ func FormatBytes(i int64) string {
 	// Fall back to printing whatever's left as PiB
 	return fmt.Sprintf("%.1f %s", size, "PiB")
 }
+
+// ParseBytes parses a human-readable size (e.g. "512", "500MiB" or "2GB") into a number of bytes. Units are
+// case-insensitive and are interpreted as powers of 1024, i.e. "1KB" and "1KiB" are both 1024 bytes.
+func ParseBytes(s string) (int64, error) {
+	trimmed := strings.TrimSpace(s)
+	numEnd := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	numStr, unitStr := trimmed, ""
+	if numEnd >= 0 {
+		numStr, unitStr = trimmed[:numEnd], strings.TrimSpace(trimmed[numEnd:])
+	}
+	num, err := strconv.ParseFloat(numStr, 64)
+	if err != nil || num < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	multipliers := map[string]float64{
+		"":  1,
+		"B": 1,
+		"K": 1 << 10, "KB": 1 << 10, "KIB": 1 << 10,
+		"M": 1 << 20, "MB": 1 << 20, "MIB": 1 << 20,
+		"G": 1 << 30, "GB": 1 << 30, "GIB": 1 << 30,
+		"T": 1 << 40, "TB": 1 << 40, "TIB": 1 << 40,
+	}
+	multiplier, ok := multipliers[strings.ToUpper(unitStr)]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, unitStr)
+	}
+	size := num * multiplier
+	if size > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return int64(size), nil
+}
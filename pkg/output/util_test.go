@@ -50,3 +50,40 @@ func TestFormatBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		input     string
+		output    int64
+		expectErr bool
+	}{
+		{input: "0", output: 0},
+		{input: "512", output: 512},
+		{input: "512B", output: 512},
+		{input: "4k", output: 4 << 10},
+		{input: "1.5KiB", output: 1536},
+		{input: "500MB", output: 500 << 20},
+		{input: "500 MiB", output: 500 << 20},
+		{input: "2G", output: 2 << 30},
+		{input: "2gb", output: 2 << 30},
+		{input: "1TiB", output: 1 << 40},
+		{input: "", expectErr: true},
+		{input: "GB", expectErr: true},
+		{input: "-1GB", expectErr: true},
+		{input: "10 parsecs", expectErr: true},
+		{input: "1.2.3MB", expectErr: true},
+		{input: "99999999999TB", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			output, err := ParseBytes(tt.input)
+			if tt.expectErr {
+				assert.Error(t, err, "Should fail to parse %q", tt.input)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equalf(t, tt.output, output, "Should convert %q to %d", tt.input, tt.output)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testing
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kitops-ml/kitops/cmd"
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+)
+
+const (
+	concurrencyWorkerEnvVar = "KITOPS_TEST_STORAGE_WORKER"
+	concurrencyWorkers      = 6
+	concurrencyTagsPerKit   = 10
+)
+
+// TestConcurrentStorageUpdates runs multiple kit processes that pack, tag and prune modelkits in the same repository
+// concurrently, and checks that no updates to the repository's index and tags are lost and no blobs are pruned
+// while they are being packed.
+func TestConcurrentStorageUpdates(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	_, _, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-concurrency
+model:
+  path: model
+`
+	var wg sync.WaitGroup
+	errs := make([]error, concurrencyWorkers)
+	for worker := range concurrencyWorkers {
+		modelKitPath := filepath.Join(tmpDir, fmt.Sprintf("modelkit-%d", worker))
+		if err := os.MkdirAll(modelKitPath, 0755); err != nil {
+			t.Fatal(err)
+		}
+		setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+		setupFiles(t, modelKitPath, []string{fmt.Sprintf("model/weights-%d.bin", worker)})
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workerCmd := exec.Command(os.Args[0], "-test.run=^TestConcurrentStorageWorker$")
+			workerCmd.Env = append(os.Environ(),
+				fmt.Sprintf("%s=%d:%s", concurrencyWorkerEnvVar, worker, modelKitPath),
+				fmt.Sprintf("%s=%s", constants.KitopsHomeEnvVar, contextPath))
+			if out, err := workerCmd.CombinedOutput(); err != nil {
+				errs[worker] = fmt.Errorf("worker %d failed: %w\n%s", worker, err, out)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	listOut := runCommand(t, expectNoError, "list")
+	for worker := range concurrencyWorkers {
+		assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+worker-%d\s`, worker), true)
+		for tag := range concurrencyTagsPerKit {
+			assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+worker-%d-%d\s`, worker, tag), true)
+		}
+	}
+	runCommand(t, expectNoError, "storage", "verify")
+
+	if runtime.GOOS != "windows" {
+		// Index and tag files are replaced atomically and must not become writable by other users
+		storagePath := constants.StoragePath(contextPath)
+		entries, err := os.ReadDir(storagePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm()&0022 != 0 {
+				t.Errorf("File %s should not be writable by group or others (mode %s)", entry.Name(), info.Mode().Perm())
+			}
+		}
+	}
+}
+
+// TestConcurrentStorageWorker is run in a separate process by TestConcurrentStorageUpdates. It packs a modelkit and
+// tags it repeatedly in the shared local storage, pruning after each tag to race against other workers' packs.
+func TestConcurrentStorageWorker(t *testing.T) {
+	workerConf := os.Getenv(concurrencyWorkerEnvVar)
+	if workerConf == "" {
+		t.Skip("Only run as a subprocess of TestConcurrentStorageUpdates")
+	}
+	workerStr, modelKitPath, _ := strings.Cut(workerConf, ":")
+	worker, err := strconv.Atoi(workerStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runWorkerCommand := func(args ...string) {
+		runCmd := cmd.RunCommand()
+		runCmd.SetArgs(args)
+		if err := runCmd.Execute(); err != nil {
+			t.Fatalf("Command 'kit %s' failed: %s", strings.Join(args, " "), err)
+		}
+	}
+	kitTag := fmt.Sprintf("test:worker-%d", worker)
+	runWorkerCommand("pack", modelKitPath, "-t", kitTag, "--compression", "none")
+	for tag := range concurrencyTagsPerKit {
+		runWorkerCommand("tag", kitTag, fmt.Sprintf("test:worker-%d-%d", worker, tag))
+		runWorkerCommand("prune", "--grace-period", "0")
+	}
+}
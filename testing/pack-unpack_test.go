@@ -17,14 +17,27 @@
 package testing
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/kitops-ml/kitops/pkg/lib/constants/mediatype"
 
+	modelspecv1 "github.com/modelpack/model-spec/specs-go/v1"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -75,6 +88,7 @@ func TestPackUnpack(t *testing.T) {
 }
 
 func TestPackReproducibility(t *testing.T) {
+	testPreflight(t)
 	tmpDir := setupTempDir(t)
 
 	modelKitPath, _, contextPath := setupTestDirs(t, tmpDir)
@@ -115,3 +129,1060 @@ datasets:
 
 	assert.Equal(t, digestOne, digestTwo, "Digests should be the same")
 }
+
+func TestPackUnpackCompression(t *testing.T) {
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-compression
+model:
+  path: model
+datasets:
+  - path: data/dataset.csv
+`
+	files := []string{"model/weights.bin", "model/config.json", "data/dataset.csv"}
+
+	tests := []struct {
+		compression  string
+		level        string
+		parallelGzip bool
+		useModelPack bool
+	}{
+		{compression: "none"},
+		{compression: "gzip"},
+		{compression: "gzip", level: "9"},
+		{compression: "gzip-fastest"},
+		{compression: "gzip", parallelGzip: true},
+		{compression: "zstd"},
+		{compression: "zstd", level: "19"},
+		{compression: "gzip", useModelPack: true},
+		{compression: "zstd", useModelPack: true},
+	}
+	for _, tt := range tests {
+		name := fmt.Sprintf("compression %s (level %q, parallel %t, modelpack %t)", tt.compression, tt.level, tt.parallelGzip, tt.useModelPack)
+		t.Run(name, func(t *testing.T) {
+			testPreflight(t)
+			tmpDir := setupTempDir(t)
+			modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+			t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+			setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+			setupFiles(t, modelKitPath, files)
+
+			packArgs := []string{"pack", modelKitPath, "-t", modelKitTag, "--compression", tt.compression}
+			if tt.level != "" {
+				packArgs = append(packArgs, "--compression-level", tt.level)
+			}
+			if tt.parallelGzip {
+				packArgs = append(packArgs, "--parallel-gzip")
+			}
+			if tt.useModelPack {
+				packArgs = append(packArgs, "--use-model-pack")
+			}
+			runCommand(t, expectNoError, packArgs...)
+			runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackPath)
+
+			checkFilesExist(t, unpackPath, files)
+			for _, file := range files {
+				contents, err := os.ReadFile(filepath.Join(unpackPath, file))
+				if !assert.NoError(t, err) {
+					continue
+				}
+				assert.Equal(t, "testing: "+file, string(contents), "Unpacked file %s should match packed file", file)
+			}
+		})
+	}
+}
+
+func TestPackInvalidCompressionLevel(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, _, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+	setupKitfileAndKitignore(t, modelKitPath, "manifestVersion: 1.0.0\n", "")
+
+	runCommand(t, expectError, "pack", modelKitPath, "--compression", "gzip", "--compression-level", "12")
+	runCommand(t, expectError, "pack", modelKitPath, "--compression", "none", "--compression-level", "3")
+	runCommand(t, expectError, "pack", modelKitPath, "--compression", "zstd", "--parallel-gzip")
+}
+
+// TestPackGzipMatchesStandardGzip checks that gzip layers are compressed with compress/gzip by default, so that
+// their digests match layers packed by earlier versions of Kit, and differ only when --parallel-gzip is used.
+func TestPackGzipMatchesStandardGzip(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, _, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-gzip
+model:
+  path: model
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin"})
+	// Parallel gzip output only differs from standard gzip output for layers larger than a single block
+	if err := os.WriteFile(filepath.Join(modelKitPath, "model/large.bin"), bytes.Repeat([]byte("0123456789abcdef"), 1<<17), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	modelLayerDigest := func(tag string) (digest.Digest, digest.Digest) {
+		inspectOut := runCommand(t, expectNoError, "inspect", tag)
+		info := struct {
+			Manifest ocispec.Manifest `json:"manifest"`
+		}{}
+		start := strings.Index(inspectOut, "{")
+		end := strings.LastIndex(inspectOut, "}")
+		if start < 0 || end < start {
+			t.Fatalf("No JSON object in output")
+		}
+		if err := json.Unmarshal([]byte(inspectOut[start:end+1]), &info); err != nil {
+			t.Fatalf("Invalid JSON output: %s", err)
+		}
+		if len(info.Manifest.Layers) != 1 {
+			t.Fatalf("Expected one layer, got %d", len(info.Manifest.Layers))
+		}
+		layer := info.Manifest.Layers[0]
+		blob, err := os.ReadFile(filepath.Join(constants.StoragePath(contextPath), "blobs", "sha256", layer.Digest.Encoded()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		gzr, err := gzip.NewReader(bytes.NewReader(blob))
+		if err != nil {
+			t.Fatal(err)
+		}
+		uncompressed, err := io.ReadAll(gzr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		standard := &bytes.Buffer{}
+		gzw := gzip.NewWriter(standard)
+		if _, err := gzw.Write(uncompressed); err != nil {
+			t.Fatal(err)
+		}
+		if err := gzw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return layer.Digest, digest.FromBytes(standard.Bytes())
+	}
+
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:standard", "--compression", "gzip")
+	layerDigest, standardDigest := modelLayerDigest("test:standard")
+	assert.Equal(t, standardDigest, layerDigest, "Gzip layers should match standard gzip output by default")
+
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:parallel", "--compression", "gzip", "--parallel-gzip")
+	layerDigest, standardDigest = modelLayerDigest("test:parallel")
+	assert.NotEqual(t, standardDigest, layerDigest, "Gzip layers should differ from standard gzip output with --parallel-gzip")
+}
+
+func TestPackUnpackRawLayers(t *testing.T) {
+	testPreflight(t)
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-raw-layers
+model:
+  path: model/weights.bin
+  parts:
+    - path: model/config
+datasets:
+  - path: data/dataset.csv
+`
+	files := []string{"model/weights.bin", "model/config/config.json", "model/config/tokenizer.json", "data/dataset.csv"}
+
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, files)
+
+	runCommand(t, expectError, "pack", modelKitPath, "-t", modelKitTag, "--layer-format", "raw")
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", modelKitTag, "--use-model-pack", "--layer-format", "raw")
+	manifest := runCommand(t, expectNoError, "inspect", modelKitTag)
+	assert.Contains(t, manifest, "application/vnd.cncf.model.weight.v1.raw", "Single-file model should be stored as raw layer")
+	assert.Contains(t, manifest, "application/vnd.cncf.model.dataset.v1.raw", "Single-file dataset should be stored as raw layer")
+	assert.Contains(t, manifest, "application/vnd.cncf.model.weight.config.v1.tar", "Directory should be stored as tar layer")
+
+	runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackPath)
+	checkFilesExist(t, unpackPath, files)
+	for _, file := range files {
+		contents, err := os.ReadFile(filepath.Join(unpackPath, file))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, "testing: "+file, string(contents), "Unpacked file %s should match packed file", file)
+	}
+}
+
+func TestPackConcurrency(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	kitfile := &strings.Builder{}
+	kitfile.WriteString("manifestVersion: 1.0.0\npackage:\n  name: test-concurrency\nmodel:\n  path: model/model.txt\n  parts:\n")
+	files := []string{"model/model.txt", "code/code.py", "data/dataset.csv", "docs/README.md", "prompts/prompt.txt"}
+	for i := 0; i < 12; i++ {
+		partPath := fmt.Sprintf("model/shard-%02d.bin", i)
+		fmt.Fprintf(kitfile, "    - path: %s\n", partPath)
+		files = append(files, partPath)
+	}
+	kitfile.WriteString(`code:
+  - path: code
+datasets:
+  - path: data
+docs:
+  - path: docs
+prompts:
+  - path: prompts
+`)
+	setupKitfileAndKitignore(t, modelKitPath, kitfile.String(), "")
+	setupFiles(t, modelKitPath, files)
+
+	packOut := runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:sequential", "--compression", "gzip", "--concurrency", "1")
+	sequentialDigest := digestFromPack(t, packOut)
+	for i := 0; i < 3; i++ {
+		packOut = runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:concurrent", "--compression", "gzip", "--concurrency", "8")
+		assert.Equal(t, sequentialDigest, digestFromPack(t, packOut), "Packing concurrently should produce the same manifest as packing sequentially")
+	}
+
+	runCommand(t, expectNoError, "unpack", "test:concurrent", "-d", unpackPath)
+	checkFilesExist(t, unpackPath, files)
+	runCommand(t, expectError, "pack", modelKitPath, "--concurrency", "0")
+}
+
+func TestPackUnpackLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Creating symlinks requires elevated privileges on Windows")
+	}
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-links
+model:
+  path: model
+datasets:
+  - path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/blobs/weights.bin", "model/snapshot/README.md", "data/dataset.csv"})
+	if err := os.Symlink("../blobs/weights.bin", filepath.Join(modelKitPath, "model/snapshot/weights.bin")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("blobs", filepath.Join(modelKitPath, "model/blobs-link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(modelKitPath, "data/dataset.csv"), filepath.Join(modelKitPath, "data/dataset-copy.csv")); err != nil {
+		t.Fatal(err)
+	}
+
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:links", "--preserve-links")
+	runCommand(t, expectError, "unpack", "test:links", "-d", unpackPath)
+	runCommand(t, expectNoError, "unpack", "test:links", "-d", unpackPath, "--preserve-links", "-o")
+
+	target, err := os.Readlink(filepath.Join(unpackPath, "model/snapshot/weights.bin"))
+	if assert.NoError(t, err, "Symlink should be unpacked") {
+		assert.Equal(t, "../blobs/weights.bin", target)
+	}
+	contents, err := os.ReadFile(filepath.Join(unpackPath, "model/blobs-link/weights.bin"))
+	if assert.NoError(t, err, "Directory symlink should be unpacked") {
+		assert.Equal(t, "testing: model/blobs/weights.bin", string(contents))
+	}
+	fi1, err1 := os.Stat(filepath.Join(unpackPath, "data/dataset.csv"))
+	fi2, err2 := os.Stat(filepath.Join(unpackPath, "data/dataset-copy.csv"))
+	if assert.NoError(t, err1) && assert.NoError(t, err2) {
+		assert.True(t, os.SameFile(fi1, fi2), "Hardlinked files should be unpacked as hardlinks")
+	}
+
+	// Without --preserve-links, symlinks are skipped and hardlinks are stored as regular files
+	noLinksUnpackPath := filepath.Join(tmpDir, "no-links-out")
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:no-links")
+	runCommand(t, expectNoError, "unpack", "test:no-links", "-d", noLinksUnpackPath)
+	checkFilesExist(t, noLinksUnpackPath, []string{"model/blobs/weights.bin", "data/dataset.csv", "data/dataset-copy.csv"})
+	checkFilesDoNotExist(t, noLinksUnpackPath, []string{"model/snapshot/weights.bin", "model/blobs-link"})
+
+	// Symlinks that point outside the layer cannot be packed
+	if err := os.Symlink("../../data/dataset.csv", filepath.Join(modelKitPath, "model/snapshot/dataset.csv")); err != nil {
+		t.Fatal(err)
+	}
+	runCommand(t, expectError, "pack", modelKitPath, "-t", "test:bad-links", "--preserve-links")
+
+	// Chains of symlinks that resolve outside the layer cannot be packed either
+	if err := os.Remove(filepath.Join(modelKitPath, "model/snapshot/dataset.csv")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(".", filepath.Join(modelKitPath, "model/self")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("self/..", filepath.Join(modelKitPath, "model/parent")); err != nil {
+		t.Fatal(err)
+	}
+	runCommand(t, expectError, "pack", modelKitPath, "-t", "test:chained-links", "--preserve-links")
+}
+
+func TestPackReproducibleModelPack(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, _, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+	t.Setenv(constants.SourceDateEpochEnvVar, "1700000000")
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-reproducible
+model:
+  path: model/weights.bin
+datasets:
+  - path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin", "data/train.csv", "data/test.csv"})
+
+	packArgs := []string{"pack", modelKitPath, "--use-model-pack", "--layer-format", "raw"}
+	reproArgs := append(packArgs, "--reproducible", "--omit-version-annotation")
+
+	// Without --reproducible, the ModelPack config includes the current time
+	t.Setenv(constants.SourceDateEpochEnvVar, "")
+	runCommand(t, expectError, append(packArgs, "--check-reproducible", "-t", "test:not-reproducible")...)
+
+	t.Setenv(constants.SourceDateEpochEnvVar, "1700000000")
+	packOut := runCommand(t, expectNoError, append(reproArgs, "--check-reproducible", "-t", "test:reproducible1")...)
+	digestOne := digestFromPack(t, packOut)
+
+	// Modes and timestamps of files should not affect the digest
+	futureTime := time.Now().Add(time.Hour)
+	for _, file := range []string{"model/weights.bin", "data/train.csv"} {
+		path := filepath.Join(modelKitPath, file)
+		if err := os.Chmod(path, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, futureTime, futureTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	packOut = runCommand(t, expectNoError, append(reproArgs, "-t", "test:reproducible2")...)
+	assert.Equal(t, digestOne, digestFromPack(t, packOut), "Digests should be the same")
+
+	inspectOut := runCommand(t, expectNoError, "inspect", "test:reproducible2")
+	assert.NotContains(t, inspectOut, constants.CliVersionAnnotation, "Manifest should not include version annotation")
+	assert.Contains(t, inspectOut, "2023-11-14T22:13:20Z", "Timestamps should be set from SOURCE_DATE_EPOCH")
+
+	t.Setenv(constants.SourceDateEpochEnvVar, "1800000000")
+	packOut = runCommand(t, expectNoError, append(reproArgs, "-t", "test:reproducible3")...)
+	assert.NotEqual(t, digestOne, digestFromPack(t, packOut), "Digest should depend on SOURCE_DATE_EPOCH")
+
+	t.Setenv(constants.SourceDateEpochEnvVar, "yesterday")
+	runCommand(t, expectError, reproArgs...)
+}
+
+func TestPackModelPackDiffIDs(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, _, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-diffids
+model:
+  path: model
+datasets:
+  - path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin", "data/train.csv"})
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", modelKitTag, "--use-model-pack", "--compression", "gzip")
+
+	inspectOut := runCommand(t, expectNoError, "inspect", modelKitTag)
+	info := struct {
+		Manifest ocispec.Manifest `json:"manifest"`
+	}{}
+	start := strings.Index(inspectOut, "{")
+	end := strings.LastIndex(inspectOut, "}")
+	if start < 0 || end < start {
+		t.Fatalf("No JSON object in output")
+	}
+	if err := json.Unmarshal([]byte(inspectOut[start:end+1]), &info); err != nil {
+		t.Fatalf("Invalid JSON output: %s", err)
+	}
+	readBlob := func(desc ocispec.Descriptor) []byte {
+		blob, err := os.ReadFile(filepath.Join(constants.StoragePath(contextPath), "blobs", "sha256", desc.Digest.Encoded()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return blob
+	}
+	config := modelspecv1.Model{}
+	if err := json.Unmarshal(readBlob(info.Manifest.Config), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	// DiffIDs in the config must be the digests of the uncompressed layers, as defined by the ModelPack spec
+	if !assert.Len(t, config.ModelFS.DiffIDs, len(info.Manifest.Layers)) {
+		return
+	}
+	for idx, layer := range info.Manifest.Layers {
+		gzr, err := gzip.NewReader(bytes.NewReader(readBlob(layer)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		diffID, err := digest.FromReader(gzr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, diffID, config.ModelFS.DiffIDs[idx], "DiffID for layer %s should be digest of uncompressed layer", layer.Digest)
+	}
+}
+
+func TestPackUnpackChunkedLayers(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-chunks
+model:
+  path: model
+datasets:
+  - path: data/small.csv
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"data/small.csv"})
+	// Random data so that layers are not compressed below the chunk size
+	modelFiles := map[string][]byte{}
+	for _, file := range []string{"model/shard-1.bin", "model/shard-2.bin"} {
+		data := make([]byte, 6*1024)
+		rand.Read(data)
+		modelFiles[file] = data
+		path := filepath.Join(modelKitPath, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runCommand(t, expectError, "pack", modelKitPath, "--chunk-size", "4KiB", "--use-model-pack")
+	runCommand(t, expectError, "pack", modelKitPath, "--chunk-size", "lots")
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", modelKitTag, "--chunk-size", "4KiB", "--compression", "gzip")
+
+	manifest := runCommand(t, expectNoError, "inspect", modelKitTag)
+	assert.Contains(t, manifest, constants.LayerChunkIndexAnnotation, "Large layers should be split into chunks")
+	assert.Contains(t, manifest, mediatype.LayerChunkMediaType, "Chunks should use the layer chunk media type")
+
+	runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackPath)
+	checkFilesExist(t, unpackPath, []string{"data/small.csv"})
+	for file, expected := range modelFiles {
+		contents, err := os.ReadFile(filepath.Join(unpackPath, file))
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, contents, "Unpacked file %s should match packed file", file)
+		}
+	}
+}
+
+func TestPackLayerCache(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-layer-cache
+model:
+  path: model
+datasets:
+  - path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin", "data/train.csv", "data/test.csv"})
+
+	packArgs := []string{"pack", modelKitPath, "-t", modelKitTag, "--compression", "gzip"}
+	packOut := runCommand(t, expectNoError, packArgs...)
+	assert.NotContains(t, packOut, "Reusing cached", "First pack should not reuse layers")
+	digestOne := digestFromPack(t, packOut)
+
+	packOut = runCommand(t, expectNoError, packArgs...)
+	assert.Equal(t, 2, strings.Count(packOut, "Reusing cached"), "Unchanged layers should be reused")
+	assert.Equal(t, digestOne, digestFromPack(t, packOut), "Reusing layers should not change digest")
+
+	packOut = runCommand(t, expectNoError, append(packArgs, "--no-layer-cache")...)
+	assert.NotContains(t, packOut, "Reusing cached", "Layers should not be reused with --no-layer-cache")
+	assert.Equal(t, digestOne, digestFromPack(t, packOut), "Digest should not depend on layer cache")
+
+	// Layers packed with a different compression type should not be reused, even if the media type is the same
+	fastestArgs := []string{"pack", modelKitPath, "-t", modelKitTag, "--compression", "gzip-fastest"}
+	packOut = runCommand(t, expectNoError, fastestArgs...)
+	assert.NotContains(t, packOut, "Reusing cached", "Layers packed with different compression should not be reused")
+	fastestDigest := digestFromPack(t, packOut)
+	assert.NotEqual(t, digestOne, fastestDigest, "Compression type should change the digest")
+	packOut = runCommand(t, expectNoError, append(fastestArgs, "--no-layer-cache")...)
+	assert.Equal(t, fastestDigest, digestFromPack(t, packOut), "Digest should not depend on layer cache")
+	packOut = runCommand(t, expectNoError, packArgs...)
+	assert.Equal(t, 2, strings.Count(packOut, "Reusing cached"), "Layers for the original compression should still be cached")
+	assert.Equal(t, digestOne, digestFromPack(t, packOut))
+
+	// Changing a file without changing its size or modification time should still invalidate its layer
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		testCsvPath := filepath.Join(modelKitPath, "data/test.csv")
+		fi, err := os.Stat(testCsvPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(testCsvPath, []byte("TESTING: data/test.csv"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(testCsvPath, fi.ModTime(), fi.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+		packOut = runCommand(t, expectNoError, packArgs...)
+		assert.Equal(t, 1, strings.Count(packOut, "Reusing cached"), "Layer with changed file should not be reused")
+		assert.NotEqual(t, digestOne, digestFromPack(t, packOut), "Changing a file should change the digest")
+		if err := os.WriteFile(testCsvPath, []byte("testing: data/test.csv"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Changing a file should invalidate only the layer that contains it
+	if err := os.WriteFile(filepath.Join(modelKitPath, "data/train.csv"), []byte("updated training data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	packOut = runCommand(t, expectNoError, packArgs...)
+	assert.Equal(t, 1, strings.Count(packOut, "Reusing cached"), "Only unchanged layers should be reused")
+	assert.NotEqual(t, digestOne, digestFromPack(t, packOut), "Changing a file should change the digest")
+
+	runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackPath)
+	contents, err := os.ReadFile(filepath.Join(unpackPath, "data/train.csv"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "updated training data", string(contents), "Unpacked file should include changes")
+	}
+	checkFilesExist(t, unpackPath, []string{"model/weights.bin", "data/test.csv"})
+}
+
+func TestUnpackConcurrent(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-unpack-concurrent
+model:
+  path: model
+  parts:
+    - path: parts/part1.bin
+    - path: parts/part2.bin
+    - path: parts/part3.bin
+code:
+  - path: src
+datasets:
+  - path: data/train
+  - path: data/test
+docs:
+  - path: README.md
+`
+	files := []string{
+		"model/weights.bin",
+		"parts/part1.bin", "parts/part2.bin", "parts/part3.bin",
+		"src/main.py", "src/lib/util.py",
+		"data/train/a.csv", "data/train/b.csv", "data/test/c.csv",
+		"README.md",
+	}
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, files)
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", modelKitTag)
+
+	for _, concurrency := range []string{"1", "8"} {
+		t.Run("concurrency "+concurrency, func(t *testing.T) {
+			unpackDir := filepath.Join(unpackPath, concurrency)
+			runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackDir, "--concurrency", concurrency)
+			checkFilesExist(t, unpackDir, append(files, constants.DefaultKitfileName))
+		})
+	}
+
+	// A conflict in one layer should fail the unpack
+	conflictDir := filepath.Join(unpackPath, "conflict")
+	setupFiles(t, conflictDir, []string{"data/test/c.csv"})
+	runCommand(t, expectError, "unpack", modelKitTag, "-d", conflictDir, "--filter", "model,datasets")
+}
+
+func TestUnpackAtomic(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-unpack-atomic
+model:
+  path: model
+datasets:
+  - path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin", "data/train.csv"})
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", modelKitTag)
+
+	t.Run("new directory", func(t *testing.T) {
+		unpackDir := filepath.Join(unpackPath, "new")
+		runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackDir, "--atomic")
+		checkFilesExist(t, unpackDir, []string{constants.DefaultKitfileName, "model/weights.bin", "data/train.csv"})
+	})
+
+	t.Run("conflict leaves directory unchanged", func(t *testing.T) {
+		unpackDir := filepath.Join(unpackPath, "conflict")
+		setupFiles(t, unpackDir, []string{"data/train.csv", "notes.txt"})
+		if err := os.WriteFile(filepath.Join(unpackDir, "data/train.csv"), []byte("existing data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runCommand(t, expectError, "unpack", modelKitTag, "-d", unpackDir, "--atomic")
+		checkFilesExist(t, unpackDir, []string{"data/train.csv", "notes.txt"})
+		checkFilesDoNotExist(t, unpackDir, []string{constants.DefaultKitfileName, "model/weights.bin"})
+		contents, err := os.ReadFile(filepath.Join(unpackDir, "data/train.csv"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "existing data", string(contents), "Existing file should not be modified")
+		}
+
+		runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackDir, "--atomic", "--overwrite")
+		checkFilesExist(t, unpackDir, []string{constants.DefaultKitfileName, "model/weights.bin", "data/train.csv", "notes.txt"})
+		contents, err = os.ReadFile(filepath.Join(unpackDir, "data/train.csv"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "testing: data/train.csv", string(contents))
+		}
+	})
+
+	t.Run("read-only parent directory", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("Directory permissions are not supported on Windows")
+		}
+		parentDir := filepath.Join(unpackPath, "read-only")
+		unpackDir := filepath.Join(parentDir, "target")
+		if err := os.MkdirAll(unpackDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(parentDir, 0555); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Chmod(parentDir, 0755) })
+
+		runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackDir, "--atomic")
+		checkFilesExist(t, unpackDir, []string{constants.DefaultKitfileName, "model/weights.bin", "data/train.csv"})
+		entries, err := os.ReadDir(parentDir)
+		if assert.NoError(t, err) && assert.Len(t, entries, 1, "Nothing should be created next to the target directory") {
+			assert.Equal(t, "target", entries[0].Name())
+		}
+	})
+
+	for _, dir := range []string{"new", "conflict", "read-only/target"} {
+		entries, err := os.ReadDir(filepath.Join(unpackPath, dir))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		for _, entry := range entries {
+			assert.False(t, strings.HasPrefix(entry.Name(), ".kit-unpack-"), "Staging directory %s should be removed", entry.Name())
+		}
+	}
+}
+
+func TestUnpackSync(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-unpack-sync
+model:
+  path: model
+datasets:
+  - path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin", "model/tokenizer.json", "data/train.csv", "data/old.csv"})
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:v1")
+	runCommand(t, expectNoError, "unpack", "test:v1", "-d", unpackPath)
+
+	// Set a known modification time so that we can check whether files are rewritten
+	oldTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, file := range []string{"model/weights.bin", "model/tokenizer.json", "data/train.csv"} {
+		if err := os.Chtimes(filepath.Join(unpackPath, file), oldTime, oldTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(modelKitPath, "model/tokenizer.json"), []byte("updated tokenizer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(modelKitPath, "data/old.csv")); err != nil {
+		t.Fatal(err)
+	}
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:v2")
+
+	runCommand(t, expectError, "unpack", "test:v2", "-d", unpackPath, "--delete")
+	runCommand(t, expectError, "unpack", "test:v2", "-d", unpackPath, "--sync", "--overwrite")
+
+	unpackOut := runCommand(t, expectNoError, "unpack", "test:v2", "-d", unpackPath, "--sync", "--delete")
+	assert.Contains(t, unpackOut, "Synced files: 1 updated, 2 unchanged, 1 deleted")
+	for _, file := range []string{"model/weights.bin", "data/train.csv"} {
+		fi, err := os.Stat(filepath.Join(unpackPath, file))
+		if assert.NoError(t, err) {
+			assert.True(t, fi.ModTime().Equal(oldTime), "Unchanged file %s should not be rewritten", file)
+		}
+	}
+	contents, err := os.ReadFile(filepath.Join(unpackPath, "model/tokenizer.json"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "updated tokenizer", string(contents))
+	}
+	checkFilesDoNotExist(t, unpackPath, []string{"data/old.csv"})
+	checkFilesExist(t, unpackPath, []string{constants.DefaultKitfileName})
+}
+
+func TestUnpackFilterPatterns(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-filter-patterns
+model:
+  path: model
+datasets:
+  - name: shards
+    path: data
+docs:
+  - path: README.md
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{
+		"model/config.json", "model/tokenizer.model", "model/weights.bin", "model/extra/tokenizer.json", "model/extra/tokenizer.bin",
+		"data/shard-1/train.csv", "data/shard-2/train.csv", "data/other.csv",
+		"README.md",
+	})
+	modelKitTag := "test:test-filter-patterns"
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", modelKitTag)
+
+	runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackPath,
+		"--filter", "model:*.json,tokenizer*,!*.bin", "--filter", "datasets:data/shard-*,!data/shard-2")
+	checkFilesExist(t, unpackPath, []string{"model/config.json", "model/tokenizer.model", "model/extra/tokenizer.json", "data/shard-1/train.csv"})
+	checkFilesDoNotExist(t, unpackPath, []string{"model/weights.bin", "model/extra/tokenizer.bin", "data/shard-2", "data/other.csv", "README.md", constants.DefaultKitfileName})
+
+	// Patterns that match a layer's path select the entire layer
+	runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackPath, "--filter", "docs:*.md", "--filter", "datasets:!data/shard-*")
+	checkFilesExist(t, unpackPath, []string{"README.md", "data/other.csv"})
+	checkFilesDoNotExist(t, unpackPath, []string{"data/shard-2"})
+
+	runCommand(t, expectError, "unpack", modelKitTag, "-d", unpackPath, "--filter", "model:[a-")
+}
+
+func TestUnpackDryRun(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	baseKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-dry-run-base
+model:
+  name: base
+  path: base
+`
+	baseModelKitPath := filepath.Join(tmpDir, "base-modelkit")
+	if err := os.MkdirAll(baseModelKitPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	setupKitfileAndKitignore(t, baseModelKitPath, baseKitfile, "")
+	setupFiles(t, baseModelKitPath, []string{"base/weights.bin"})
+	runCommand(t, expectNoError, "pack", baseModelKitPath, "-t", "test:dry-run-base")
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-dry-run
+model:
+  name: child
+  path: test:dry-run-base
+  parts:
+    - path: extra.txt
+datasets:
+  - name: train
+    path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"extra.txt", "data/train.csv", "data/test.csv"})
+	modelKitTag := "test:dry-run"
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", modelKitTag)
+
+	// An existing file that conflicts with the modelkit
+	setupFiles(t, unpackPath, []string{"data/train.csv"})
+	before, err := os.ReadDir(unpackPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indexPath := constants.IndexJsonPathForRepo(constants.StoragePath(contextPath), "localhost/test")
+	indexBefore, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableOut := runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackPath, "--dry-run")
+	assertContainsLineRegexp(t, tableOut, `^conflict\s+file\s+.*\s+data/train.csv$`, true)
+	assertContainsLineRegexp(t, tableOut, `^create\s+file\s+.*\s+data/test.csv$`, true)
+	assertContainsLineRegexp(t, tableOut, `^create\s+file\s+.*\s+base/weights.bin$`, true)
+	assertContainsLineRegexp(t, tableOut, `^create\s+file\s+.*\s+extra.txt$`, true)
+	assert.Contains(t, tableOut, "1 conflicts")
+
+	after, err := os.ReadDir(unpackPath)
+	if assert.NoError(t, err) {
+		assert.Equal(t, len(before), len(after), "Dry run should not write to the unpack directory")
+	}
+	checkFilesDoNotExist(t, unpackPath, []string{"data/test.csv", "base", "extra.txt", constants.DefaultKitfileName})
+	indexAfter, err := os.ReadFile(indexPath)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(indexBefore), string(indexAfter), "Dry run should not record usage in local storage")
+	}
+
+	jsonOut := runCommand(t, expectNoError, "unpack", modelKitTag, "-d", unpackPath, "--dry-run", "--format", "json", "--overwrite", "--filter", "datasets")
+	start := strings.Index(jsonOut, "\n{")
+	end := strings.LastIndex(jsonOut, "}")
+	if start < 0 || end < start {
+		t.Fatalf("No JSON object in output")
+	}
+	var plan struct {
+		UnpackDir string `json:"unpackDir"`
+		Files     []struct {
+			Path   string `json:"path"`
+			Type   string `json:"type"`
+			Size   int64  `json:"size"`
+			Action string `json:"action"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(jsonOut[start+1:end+1]), &plan); err != nil {
+		t.Fatalf("Invalid JSON output: %s", err)
+	}
+	assert.Equal(t, unpackPath, plan.UnpackDir)
+	actions := map[string]string{}
+	for _, file := range plan.Files {
+		actions[file.Path] = file.Action
+	}
+	assert.Equal(t, map[string]string{
+		"data":           "exists",
+		"data/test.csv":  "create",
+		"data/train.csv": "overwrite",
+	}, actions, "Dry run should respect filters and overwrite")
+
+	runCommand(t, expectError, "unpack", modelKitTag, "-d", unpackPath, "--dry-run", "--sync")
+}
+
+func TestPackPush(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+	reg, host := setupTestRegistry(t)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-push
+model:
+  path: model
+datasets:
+  - path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin", "data/train.csv", "data/test.csv"})
+	// Layers larger than a single upload chunk are streamed to the registry in multiple requests
+	if err := os.WriteFile(filepath.Join(modelKitPath, "model/large.bin"), bytes.Repeat([]byte("0123456789abcdef"), 3<<20), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runCommand(t, expectError, "pack", modelKitPath, "--no-local-copy")
+	runCommand(t, expectError, "pack", modelKitPath, "-t", "test:latest", "--push")
+
+	remoteRef := host + "/test/push"
+	runCommand(t, expectError, "pack", modelKitPath, "-t", remoteRef+":v1", "--push", "--no-local-copy", "--check-reproducible", "--plain-http")
+	packOut := runCommand(t, expectNoError, "pack", modelKitPath, "-t", remoteRef+":v1,v2", "--push", "--no-local-copy", "--plain-http")
+	packDigest := digestFromPack(t, packOut)
+	_, err := os.Stat(filepath.Join(constants.CachePath(contextPath), "pack"))
+	assert.ErrorIs(t, err, fs.ErrNotExist, "Layers should be streamed to the registry without writing temporary files")
+	for _, tag := range []string{"v1", "v2"} {
+		remoteDigest, ok := reg.resolveTag("test/push", tag)
+		if assert.True(t, ok, "Tag %s should be pushed", tag) {
+			assert.Equal(t, packDigest, remoteDigest.String(), "Remote tag should match packed modelkit")
+		}
+	}
+	listOut := runCommand(t, expectNoError, "list")
+	assert.NotContains(t, listOut, remoteRef, "Modelkit should not be saved locally with --no-local-copy")
+
+	packOut = runCommand(t, expectNoError, "pack", modelKitPath, "-t", remoteRef+":v3", "--push", "--plain-http")
+	assert.Equal(t, packDigest, digestFromPack(t, packOut), "Packing with a local copy should produce the same modelkit")
+	if remoteDigest, ok := reg.resolveTag("test/push", "v3"); assert.True(t, ok, "Tag v3 should be pushed") {
+		assert.Equal(t, packDigest, remoteDigest.String(), "Remote tag should match packed modelkit")
+	}
+	listOut = runCommand(t, expectNoError, "list")
+	assert.Contains(t, listOut, remoteRef, "Modelkit should be saved locally without --no-local-copy")
+
+	runCommand(t, expectNoError, "unpack", remoteRef+":v1", "-d", unpackPath, "--plain-http")
+	checkFilesExist(t, unpackPath, []string{"model/weights.bin", "model/large.bin", "data/train.csv", "data/test.csv"})
+}
+
+func TestUnpackForeignModelPack(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	_, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+	reg, host := setupTestRegistry(t)
+
+	// Simulate a ModelPack artifact created by another tool: no Kitfile annotation and raw layers
+	weights := []byte("model weights")
+	weightsDesc := reg.addBlob("application/vnd.cncf.model.weight.v1.raw", weights)
+	weightsDesc.Annotations = map[string]string{modelspecv1.AnnotationFilepath: "model.safetensors"}
+	readme := []byte("model readme")
+	readmeDesc := reg.addBlob("application/vnd.cncf.model.doc.v1.raw", readme)
+	readmeDesc.Annotations = map[string]string{modelspecv1.AnnotationFilepath: "README.md"}
+	reasoning := true
+	configBytes, err := json.Marshal(modelspecv1.Model{
+		Descriptor: modelspecv1.ModelDescriptor{
+			Name:        "foreign-model",
+			Version:     "2.0",
+			Authors:     []string{"Someone Else"},
+			Licenses:    []string{"Apache-2.0"},
+			Description: "A model packed by another tool",
+		},
+		ModelFS: modelspecv1.ModelFS{Type: "layers", DiffIDs: []digest.Digest{weightsDesc.Digest, readmeDesc.Digest}},
+		Config: modelspecv1.ModelConfig{
+			Format:       "safetensors",
+			Architecture: "transformer",
+			Precision:    "bf16",
+			Capabilities: &modelspecv1.ModelCapabilities{Reasoning: &reasoning},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	configDesc := reg.addBlob("application/vnd.cncf.model.config.v1+json", configBytes)
+	reg.addManifest(t, "test/foreign", "latest", ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.cncf.model.manifest.v1+json",
+		Config:       configDesc,
+		Layers:       []ocispec.Descriptor{weightsDesc, readmeDesc},
+	})
+	remoteRef := host + "/test/foreign:latest"
+
+	runCommand(t, expectNoError, "info", "--remote", remoteRef, "--plain-http")
+
+	runCommand(t, expectNoError, "unpack", remoteRef, "-d", unpackPath, "--filter", "kitfile", "--plain-http")
+	kitfileBytes, err := os.ReadFile(filepath.Join(unpackPath, constants.DefaultKitfileName))
+	if assert.NoError(t, err, "Kitfile should be unpacked") {
+		for _, expected := range []string{"name: foreign-model", "version: \"2.0\"", "license: Apache-2.0", "format: safetensors", "architecture: transformer", "reasoning: true", "path: model.safetensors"} {
+			assert.Contains(t, string(kitfileBytes), expected, "Kitfile should be generated from ModelPack config")
+		}
+	}
+	checkFilesDoNotExist(t, unpackPath, []string{"model.safetensors", "README.md"})
+
+	runCommand(t, expectNoError, "unpack", remoteRef, "-d", unpackPath, "--plain-http", "--overwrite")
+	contents, err := os.ReadFile(filepath.Join(unpackPath, "model.safetensors"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, weights, contents)
+	}
+	checkFilesExist(t, unpackPath, []string{"README.md"})
+
+	listOut := runCommand(t, expectNoError, "list", host+"/test/foreign", "--plain-http")
+	assert.Contains(t, listOut, "foreign-model", "List should include metadata from generated Kitfile")
+}
+
+func TestUnpackStream(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+	reg, host := setupTestRegistry(t)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-stream
+model:
+  path: model
+datasets:
+  - path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin", "data/train.csv", "data/test.csv"})
+
+	remoteRef := host + "/test/stream:latest"
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", remoteRef, "--push", "--no-local-copy", "--plain-http")
+
+	runCommand(t, expectError, "unpack", "test/stream:latest", "--stream", "-d", unpackPath)
+
+	// Drop the connection partway through the first blob downloads; unpacking should resume them
+	reg.mu.Lock()
+	reg.failBlobReads = 2
+	reg.mu.Unlock()
+	runCommand(t, expectNoError, "unpack", remoteRef, "--stream", "-d", unpackPath, "--plain-http")
+	checkFilesExist(t, unpackPath, []string{"model/weights.bin", "data/train.csv", "data/test.csv", constants.DefaultKitfileName})
+	reg.mu.Lock()
+	assert.Equal(t, 0, reg.failBlobReads, "Blob fetches should have been interrupted")
+	assert.Greater(t, reg.rangePulls, 0, "Interrupted blob fetches should be resumed with range requests")
+	reg.mu.Unlock()
+
+	blobs, err := filepath.Glob(filepath.Join(constants.StoragePath(contextPath), "blobs", "*", "*"))
+	if assert.NoError(t, err) {
+		assert.Empty(t, blobs, "Streaming unpack should not add blobs to local storage")
+	}
+	listOut := runCommand(t, expectNoError, "list")
+	assert.NotContains(t, listOut, "test/stream", "Streaming unpack should not add the modelkit to local storage")
+}
+
+func TestPackInvalidModelPackParameters(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, _, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-parameters
+model:
+  path: model
+  parameters:
+    architecture: [transformer]
+    arbitrary-parameter: value
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin"})
+
+	// Parameters that don't fit the ModelPack config are only an error when packing in ModelPack format
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:modelkit")
+	runCommand(t, expectError, "pack", modelKitPath, "-t", "test:modelpack", "--use-model-pack")
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrune(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+	_, host := setupTestRegistry(t)
+
+	removedKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-prune-removed
+model:
+  path: model
+datasets:
+  - path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, removedKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin", "data/train.csv"})
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:prune-removed")
+
+	keptModelKitPath := filepath.Join(tmpDir, "kept-modelkit")
+	if err := os.MkdirAll(keptModelKitPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	keptKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-prune-kept
+model:
+  path: model
+`
+	setupKitfileAndKitignore(t, keptModelKitPath, keptKitfile, "")
+	setupFiles(t, keptModelKitPath, []string{"model/weights.bin"})
+	runCommand(t, expectNoError, "pack", keptModelKitPath, "-t", "test:prune-kept")
+
+	// A modelkit that is only present in local storage via pull
+	pulledModelKitPath := filepath.Join(tmpDir, "pulled-modelkit")
+	if err := os.MkdirAll(pulledModelKitPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pulledKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-prune-pulled
+datasets:
+  - path: pulled.csv
+`
+	setupKitfileAndKitignore(t, pulledModelKitPath, pulledKitfile, "")
+	setupFiles(t, pulledModelKitPath, []string{"pulled.csv"})
+	remoteRef := host + "/test/prune:latest"
+	runCommand(t, expectNoError, "pack", pulledModelKitPath, "-t", remoteRef, "--push", "--no-local-copy", "--plain-http")
+	runCommand(t, expectNoError, "pull", remoteRef, "--plain-http")
+
+	// Nothing is unreferenced yet
+	pruneOut := runCommand(t, expectNoError, "prune", "--dry-run")
+	assert.Contains(t, pruneOut, "Would remove 0 blobs")
+
+	runCommand(t, expectNoError, "remove", "test:prune-removed")
+	// Blobs left behind by e.g. an interrupted pack or pull are not referenced by any manifest
+	orphan := []byte("orphaned blob contents")
+	orphanDigest := digest.FromBytes(orphan)
+	orphanPath := filepath.Join(constants.StoragePath(contextPath), "blobs", "sha256", orphanDigest.Encoded())
+	if err := os.WriteFile(orphanPath, orphan, 0644); err != nil {
+		t.Fatal(err)
+	}
+	blobsBefore := listBlobs(t, contextPath)
+
+	// Recently written blobs are kept by default, as they may belong to a pack or pull that is in progress
+	pruneOut = runCommand(t, expectNoError, "prune")
+	assert.Contains(t, pruneOut, "Removed 0 blobs")
+	assert.FileExists(t, orphanPath)
+
+	pruneOut = runCommand(t, expectNoError, "prune", "--dry-run", "--grace-period", "0")
+	assertContainsLineRegexp(t, pruneOut, `Would remove [1-9][0-9]* blobs, reclaiming`, true)
+	assert.Equal(t, blobsBefore, listBlobs(t, contextPath), "Dry run should not remove blobs")
+
+	pruneOut = runCommand(t, expectNoError, "prune", "--grace-period", "0")
+	assertContainsLineRegexp(t, pruneOut, `Removed [1-9][0-9]* blobs, reclaimed`, true)
+	assert.Less(t, len(listBlobs(t, contextPath)), len(blobsBefore))
+	assert.NoFileExists(t, orphanPath)
+
+	pruneOut = runCommand(t, expectNoError, "prune", "--grace-period", "0")
+	assert.Contains(t, pruneOut, "Removed 0 blobs")
+
+	// Remaining modelkits should be intact
+	runCommand(t, expectNoError, "verify", "test:prune-kept")
+	runCommand(t, expectNoError, "unpack", "test:prune-kept", "-d", filepath.Join(unpackPath, "kept"))
+	checkFilesExist(t, filepath.Join(unpackPath, "kept"), []string{"model/weights.bin"})
+	runCommand(t, expectNoError, "unpack", remoteRef, "-d", filepath.Join(unpackPath, "pulled"))
+	checkFilesExist(t, filepath.Join(unpackPath, "pulled"), []string{"pulled.csv"})
+}
+
+func listBlobs(t *testing.T, contextPath string) []string {
+	blobs, err := filepath.Glob(filepath.Join(constants.StoragePath(contextPath), "blobs", "*", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blobs
+}
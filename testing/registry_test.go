@@ -0,0 +1,249 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var (
+	registryBlobPath     = regexp.MustCompile(`^/v2/(.+)/blobs/(sha256:[a-f0-9]{64})$`)
+	registryUploadsPath  = regexp.MustCompile(`^/v2/(.+)/blobs/uploads/$`)
+	registryUploadPath   = regexp.MustCompile(`^/v2/(.+)/blobs/uploads/([0-9]+)$`)
+	registryManifestPath = regexp.MustCompile(`^/v2/(.+)/manifests/([^/]+)$`)
+	registryTagsPath     = regexp.MustCompile(`^/v2/(.+)/tags/list$`)
+)
+
+// testRegistry is a minimal in-memory implementation of the OCI distribution spec, supporting
+// blob uploads (monolithic and chunked), blob and manifest fetches (including range requests)
+// manifest pushes and listing tags. It does not support authentication. Setting failBlobReads causes
+// that many blob fetches to drop the connection after sending half of the blob.
+type testRegistry struct {
+	mu        sync.Mutex
+	blobs     map[digest.Digest][]byte
+	uploads   map[string]*bytes.Buffer
+	manifests map[string][]byte
+	// tags maps repository:tag to a manifest digest
+	tags      map[string]digest.Digest
+	nextID    int
+	blobPulls int
+	// rangePulls counts blob fetches that request a range of the blob
+	rangePulls    int
+	failBlobReads int
+	server        *httptest.Server
+}
+
+// setupTestRegistry starts a testRegistry that is shut down when the test completes and returns it
+// along with its host (e.g. 127.0.0.1:12345), for use in references.
+func setupTestRegistry(t *testing.T) (reg *testRegistry, host string) {
+	reg = &testRegistry{
+		blobs:     map[digest.Digest][]byte{},
+		uploads:   map[string]*bytes.Buffer{},
+		manifests: map[string][]byte{},
+		tags:      map[string]digest.Digest{},
+	}
+	reg.server = httptest.NewServer(reg)
+	t.Cleanup(reg.server.Close)
+	return reg, strings.TrimPrefix(reg.server.URL, "http://")
+}
+
+// hasBlob returns true if the registry contains a blob with digest dgst
+func (r *testRegistry) hasBlob(dgst digest.Digest) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.blobs[dgst]
+	return ok
+}
+
+// resolveTag returns the digest of the manifest tagged tag in repository, if it exists.
+func (r *testRegistry) resolveTag(repository, tag string) (digest.Digest, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dgst, ok := r.tags[repository+":"+tag]
+	return dgst, ok
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := req.URL.Path
+	switch {
+	case path == "/v2/":
+		w.WriteHeader(http.StatusOK)
+	case registryUploadsPath.MatchString(path) && req.Method == http.MethodPost:
+		repository := registryUploadsPath.FindStringSubmatch(path)[1]
+		r.nextID++
+		id := fmt.Sprintf("%d", r.nextID)
+		r.uploads[id] = &bytes.Buffer{}
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s", repository, id))
+		w.WriteHeader(http.StatusAccepted)
+	case registryUploadPath.MatchString(path):
+		r.handleUpload(w, req, registryUploadPath.FindStringSubmatch(path))
+	case registryBlobPath.MatchString(path):
+		dgst := digest.Digest(registryBlobPath.FindStringSubmatch(path)[2])
+		blob, ok := r.blobs[dgst]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method == http.MethodGet {
+			r.blobPulls++
+			if req.Header.Get("Range") != "" {
+				r.rangePulls++
+			}
+		}
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		if req.Method == http.MethodGet && req.Header.Get("Range") == "" && r.failBlobReads > 0 && len(blob) > 1 {
+			r.failBlobReads--
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(blob)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(blob[:len(blob)/2])
+			w.(http.Flusher).Flush()
+			// Abort the response so that the client sees the connection drop partway through the blob
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(blob))
+	case registryManifestPath.MatchString(path):
+		r.handleManifest(w, req, registryManifestPath.FindStringSubmatch(path))
+	case registryTagsPath.MatchString(path) && req.Method == http.MethodGet:
+		repository := registryTagsPath.FindStringSubmatch(path)[1]
+		tags := []string{}
+		for repoTag := range r.tags {
+			if tag, ok := strings.CutPrefix(repoTag, repository+":"); ok {
+				tags = append(tags, tag)
+			}
+		}
+		sort.Strings(tags)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"name": repository, "tags": tags})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *testRegistry) handleUpload(w http.ResponseWriter, req *http.Request, matches []string) {
+	repository, id := matches[1], matches[2]
+	buf, ok := r.uploads[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if _, err := io.Copy(buf, req.Body); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	switch req.Method {
+	case http.MethodPatch:
+		w.Header().Set("Location", req.URL.Path)
+		w.Header().Set("Range", fmt.Sprintf("0-%d", buf.Len()-1))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		dgst := digest.Digest(req.URL.Query().Get("digest"))
+		if err := dgst.Validate(); err != nil || digest.FromBytes(buf.Bytes()) != dgst {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.blobs[dgst] = buf.Bytes()
+		delete(r.uploads, id)
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/%s", repository, dgst))
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *testRegistry) handleManifest(w http.ResponseWriter, req *http.Request, matches []string) {
+	repository, reference := matches[1], matches[2]
+	dgst := digest.Digest(reference)
+	if dgst.Validate() != nil {
+		dgst = r.tags[repository+":"+reference]
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		manifest, ok := r.manifests[dgst.String()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(manifest)))
+		w.WriteHeader(http.StatusOK)
+		if req.Method == http.MethodGet {
+			w.Write(manifest)
+		}
+	case http.MethodPut:
+		manifest, err := io.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		dgst = digest.FromBytes(manifest)
+		r.manifests[dgst.String()] = manifest
+		if digest.Digest(reference).Validate() != nil {
+			r.tags[repository+":"+reference] = dgst
+		}
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/manifests/%s", repository, dgst))
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// addBlob stores data in the registry and returns a descriptor for it with the given media type.
+func (r *testRegistry) addBlob(mediaType string, data []byte) ocispec.Descriptor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	r.blobs[desc.Digest] = data
+	return desc
+}
+
+// addManifest stores manifest in the registry, tagged tag in repository, and returns its digest.
+func (r *testRegistry) addManifest(t *testing.T, repository, tag string, manifest ocispec.Manifest) digest.Digest {
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dgst := digest.FromBytes(manifestBytes)
+	r.manifests[dgst.String()] = manifestBytes
+	r.tags[repository+":"+tag] = dgst
+	return dgst
+}
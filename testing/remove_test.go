@@ -17,6 +17,7 @@
 package testing
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -25,6 +26,7 @@ import (
 	"testing"
 
 	"github.com/kitops-ml/kitops/pkg/lib/constants"
+	"github.com/stretchr/testify/assert"
 )
 
 const (
@@ -38,6 +40,7 @@ model:
 )
 
 func TestRemoveSingleModelkitTag(t *testing.T) {
+	testPreflight(t)
 	// Set up temporary directory for work
 	tmpDir := setupTempDir(t)
 
@@ -65,6 +68,7 @@ func TestRemoveSingleModelkitTag(t *testing.T) {
 }
 
 func TestRemoveSingleModelkitDigest(t *testing.T) {
+	testPreflight(t)
 	// Set up temporary directory for work
 	tmpDir := setupTempDir(t)
 
@@ -93,6 +97,7 @@ func TestRemoveSingleModelkitDigest(t *testing.T) {
 }
 
 func TestRemoveSingleModelkitNoTag(t *testing.T) {
+	testPreflight(t)
 	// Set up temporary directory for work
 	tmpDir := setupTempDir(t)
 
@@ -120,6 +125,7 @@ func TestRemoveSingleModelkitNoTag(t *testing.T) {
 }
 
 func TestRemoveModelkitUntagsWhenMultiple(t *testing.T) {
+	testPreflight(t)
 	// Set up temporary directory for work
 	tmpDir := setupTempDir(t)
 
@@ -152,6 +158,7 @@ func TestRemoveModelkitUntagsWhenMultiple(t *testing.T) {
 }
 
 func TestRemoveModelkitUntagsAllWhenDigest(t *testing.T) {
+	testPreflight(t)
 	// Set up temporary directory for work
 	tmpDir := setupTempDir(t)
 
@@ -185,6 +192,7 @@ func TestRemoveModelkitUntagsAllWhenDigest(t *testing.T) {
 }
 
 func TestRemoveModelkitUntagged(t *testing.T) {
+	testPreflight(t)
 	// Set up temporary directory for work
 	tmpDir := setupTempDir(t)
 
@@ -218,8 +226,27 @@ func TestRemoveModelkitUntagged(t *testing.T) {
 	assertContainsLineRegexp(t, listOut, regexpTwo, true)
 	assertContainsLineRegexp(t, listOut, regexpThree, true)
 
-	// Remove modelkit and verify it's no longer in 'kit list'
-	runCommand(t, expectNoError, "remove", "--all")
+	// Previous targets of tags are kept by --all, and can still be restored
+	removeOut := runCommand(t, expectNoError, "remove", "--all")
+	assert.Contains(t, removeOut, digestOne)
+	assert.Contains(t, removeOut, digestTwo)
+	listOut = runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, regexpOne, true)
+	assertContainsLineRegexp(t, listOut, regexpTwo, true)
+	assertContainsLineRegexp(t, listOut, regexpThree, true)
+	runCommand(t, expectNoError, "tag", "--restore", "test:testing-tag")
+	listOut = runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+testing-tag.*%s$`, digestTwo), true)
+	runCommand(t, expectNoError, "tag", "--restore", "test:testing-tag")
+	// Retention policies also keep previous targets
+	runCommand(t, expectNoError, "remove", "--keep-last", "1")
+	listOut = runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, regexpOne, true)
+	assertContainsLineRegexp(t, listOut, regexpTwo, true)
+
+	// Remove modelkits including previous targets and verify they're no longer in 'kit list'
+	runCommand(t, expectError, "remove", "--include-history", "test:testing-tag")
+	runCommand(t, expectNoError, "remove", "--all", "--include-history")
 	listOut = runCommand(t, expectNoError, "list")
 	assertContainsLineRegexp(t, listOut, regexpOne, false)
 	assertContainsLineRegexp(t, listOut, regexpTwo, false)
@@ -227,6 +254,7 @@ func TestRemoveModelkitUntagged(t *testing.T) {
 }
 
 func TestRemoveModelkitAll(t *testing.T) {
+	testPreflight(t)
 	// Set up temporary directory for work
 	tmpDir := setupTempDir(t)
 
@@ -268,6 +296,71 @@ func TestRemoveModelkitAll(t *testing.T) {
 	assertContainsLineRegexp(t, listOut, regexpThree, false)
 }
 
+func TestRemoveRetentionPolicies(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	kitfilePath := filepath.Join(modelKitPath, constants.DefaultKitfileName)
+	if err := os.WriteFile(kitfilePath, []byte(testKitfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var digests []string
+	for i := range 3 {
+		setupFiles(t, modelKitPath, []string{fmt.Sprintf("testfile-%d", i)})
+		packOut := runCommand(t, expectNoError, "pack", modelKitPath, "-t", fmt.Sprintf("test:retention-%d", i))
+		digests = append(digests, digestFromPack(t, packOut))
+	}
+	// Modelkits in other repositories are not affected by --keep-last
+	runCommand(t, expectNoError, "tag", "test:retention-0", "other:retention")
+
+	// Only the most recently packed modelkits in each repository are kept
+	runCommand(t, expectNoError, "remove", "--keep-last", "2")
+	listOut := runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+retention-0.*%s$`, digests[0]), false)
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^other\s+retention.*%s$`, digests[0]), true)
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+retention-1.*%s$`, digests[1]), true)
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+retention-2.*%s$`, digests[2]), true)
+	runCommand(t, expectNoError, "remove", "other:retention")
+
+	// --keep-last 0 is rejected rather than ignored
+	runCommand(t, expectError, "remove", "--keep-last", "0", "test:retention-2")
+	listOut = runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+retention-2.*%s$`, digests[2]), true)
+
+	// Unpacking a modelkit makes it the most recently used, so the other is evicted first
+	runCommand(t, expectNoError, "unpack", "test:retention-1", "-d", unpackPath)
+	var usage struct {
+		TotalSize int64 `json:"totalSize"`
+	}
+	dfOut := runCommand(t, expectNoError, "storage", "df", "--format", "json")
+	if err := json.Unmarshal([]byte(strings.Join(filterNonDebugLines(dfOut), "\n")), &usage); err != nil {
+		t.Fatalf("Failed to parse df output: %s", err)
+	}
+	runCommand(t, expectNoError, "remove", "--max-size", fmt.Sprintf("%d", usage.TotalSize-1))
+	listOut = runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+retention-1.*%s$`, digests[1]), true)
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+retention-2.*%s$`, digests[2]), false)
+
+	// A large maximum age keeps everything
+	runCommand(t, expectNoError, "remove", "--older-than", "30d")
+	listOut = runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+retention-1.*%s$`, digests[1]), true)
+	runCommand(t, expectNoError, "remove", "--older-than", "0s")
+	listOut = runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+retention-1.*%s$`, digests[1]), false)
+
+	runCommand(t, expectError, "remove", "--keep-last", "1", "test:retention-1")
+	runCommand(t, expectError, "remove", "--keep-last", "1", "--all")
+	runCommand(t, expectError, "remove", "--keep-last", "0")
+	runCommand(t, expectError, "remove", "--keep-last", "-1")
+	runCommand(t, expectError, "remove", "--older-than", "soon")
+	runCommand(t, expectError, "remove", "--max-size", "lots")
+}
+
 func digestFromPack(t *testing.T, packOutput string) string {
 	digestRegexp := regexp.MustCompile(`Model saved: (sha256:\w+)`)
 	matches := digestRegexp.FindStringSubmatch(packOutput)
@@ -0,0 +1,139 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testing
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveLoad(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	baseKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-save-base
+model:
+  name: base
+  path: base
+`
+	baseModelKitPath := filepath.Join(tmpDir, "base-modelkit")
+	if err := os.MkdirAll(baseModelKitPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	setupKitfileAndKitignore(t, baseModelKitPath, baseKitfile, "")
+	setupFiles(t, baseModelKitPath, []string{"base/weights.bin"})
+	baseDigest := digestFromPack(t, runCommand(t, expectNoError, "pack", baseModelKitPath, "-t", "test:save-base"))
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-save
+model:
+  name: child
+  path: test:save-base
+  parts:
+    - path: extra.txt
+datasets:
+  - name: train
+    path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"extra.txt", "data/train.csv"})
+	childDigest := digestFromPack(t, runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:save-child"))
+
+	archivePath := filepath.Join(tmpDir, "modelkits.tar")
+	saveOut := runCommand(t, expectNoError, "save", "test:save-child", "-o", archivePath)
+	assert.Contains(t, saveOut, "Saved 2 modelkits")
+
+	entries := listTarEntries(t, archivePath)
+	assert.Contains(t, entries, "oci-layout")
+	assert.Contains(t, entries, "index.json")
+
+	// Load the archive into empty storage
+	newContextPath := filepath.Join(tmpDir, ".kitops-load")
+	if err := os.MkdirAll(newContextPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(constants.KitopsHomeEnvVar, newContextPath)
+	loadOut := runCommand(t, expectNoError, "load", archivePath)
+	assert.Contains(t, loadOut, "Loaded 2 modelkits")
+
+	listOut := runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+save-base.*%s$`, baseDigest), true)
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+save-child.*%s$`, childDigest), true)
+
+	runCommand(t, expectNoError, "unpack", "test:save-child", "-d", unpackPath)
+	checkFilesExist(t, unpackPath, []string{"base/weights.bin", "extra.txt", "data/train.csv"})
+
+	// Loading the same archive again is a no-op
+	runCommand(t, expectNoError, "load", archivePath)
+	listOut = runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^test\s+save-child.*%s$`, childDigest), true)
+}
+
+func TestSaveLoadErrors(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	_, _, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	archivePath := filepath.Join(tmpDir, "modelkits.tar")
+	runCommand(t, expectError, "save", "test:does-not-exist", "-o", archivePath)
+	_, err := os.Stat(archivePath)
+	assert.ErrorIs(t, err, os.ErrNotExist, "Failed save should not leave an archive behind")
+	runCommand(t, expectError, "save", "test:does-not-exist")
+
+	runCommand(t, expectError, "load", archivePath)
+	notArchive := filepath.Join(tmpDir, "not-an-archive.tar")
+	if err := os.WriteFile(notArchive, []byte("not a tar file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runCommand(t, expectError, "load", notArchive)
+}
+
+func listTarEntries(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []string
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, hdr.Name)
+	}
+	return entries
+}
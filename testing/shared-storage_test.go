@@ -0,0 +1,118 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testing
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedStorage(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, sharedHome := setupTestDirs(t, tmpDir)
+	_, host := setupTestRegistry(t)
+	sharedStorage := constants.StoragePath(sharedHome)
+
+	kitfile := `
+manifestVersion: 1.0.0
+package:
+  name: %s
+model:
+  path: model
+`
+	// Populate shared storage, and push a modelkit that shares its model layer to the registry
+	t.Setenv(constants.KitopsHomeEnvVar, sharedHome)
+	setupKitfileAndKitignore(t, modelKitPath, fmt.Sprintf(kitfile, "shared"), "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin"})
+	packOut := runCommand(t, expectNoError, "pack", modelKitPath, "-t", "shared/model:v1", "--compression", "none")
+	sharedDigest := digestFromPack(t, packOut)
+	setupKitfileAndKitignore(t, modelKitPath, fmt.Sprintf(kitfile, "remote"), "")
+	remoteRef := host + "/shared/model:remote"
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", remoteRef, "--compression", "none", "--push", "--no-local-copy", "--plain-http")
+	sharedBlobs := listBlobs(t, sharedHome)
+	sharedFiles := snapshotFiles(t, sharedStorage)
+
+	userHome := filepath.Join(tmpDir, "user")
+	t.Setenv(constants.KitopsHomeEnvVar, userHome)
+	t.Setenv(constants.SharedStorageEnvVar, sharedStorage)
+
+	listOut := runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^shared/model\s+v1.*%s$`, sharedDigest), true)
+	runCommand(t, expectNoError, "unpack", "shared/model:v1", "-d", unpackPath)
+	assert.FileExists(t, filepath.Join(unpackPath, "model", "weights.bin"))
+	runCommand(t, expectNoError, "inspect", "shared/model:v1")
+
+	// Tagging a modelkit from shared storage copies only its manifest to the user's storage
+	runCommand(t, expectNoError, "tag", "shared/model:v1", "shared/model:mine")
+	runCommand(t, expectNoError, "tag", "shared/model:v1", "mine/model:latest")
+	listOut = runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^shared/model\s+mine.*%s$`, sharedDigest), true)
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^mine/model\s+latest.*%s$`, sharedDigest), true)
+	assert.Len(t, listBlobs(t, userHome), 1)
+
+	// Packing and pulling reuse blobs from shared storage
+	setupKitfileAndKitignore(t, modelKitPath, fmt.Sprintf(kitfile, "user"), "")
+	packOut = runCommand(t, expectNoError, "pack", modelKitPath, "-t", "user/model:v1", "--compression", "none")
+	assert.Contains(t, packOut, "Already saved model layer")
+	runCommand(t, expectNoError, "pull", remoteRef, "--plain-http")
+	for _, blob := range listBlobs(t, userHome) {
+		if filepath.Base(blob) == sharedDigest[len("sha256:"):] {
+			// Tagged modelkits from shared storage have their manifest copied
+			continue
+		}
+		assert.NotContains(t, sharedBlobs, filepath.Join(sharedStorage, "blobs", "sha256", filepath.Base(blob)), "blob %s should not be copied from shared storage", filepath.Base(blob))
+	}
+	runCommand(t, expectNoError, "unpack", "user/model:v1", "-d", filepath.Join(tmpDir, "user-unpack"))
+	assert.FileExists(t, filepath.Join(tmpDir, "user-unpack", "model", "weights.bin"))
+	runCommand(t, expectNoError, "storage", "verify")
+
+	// Modelkits in shared storage cannot be modified
+	runCommand(t, expectError, "remove", "shared/model:v1")
+	runCommand(t, expectNoError, "remove", "--all", "--force")
+	listOut = runCommand(t, expectNoError, "list")
+	assertContainsLineRegexp(t, listOut, fmt.Sprintf(`^shared/model\s+v1.*%s$`, sharedDigest), true)
+	assertContainsLineRegexp(t, listOut, `^user/model`, false)
+	assert.Equal(t, sharedFiles, snapshotFiles(t, sharedStorage), "shared storage should not be modified")
+}
+
+// snapshotFiles returns the size and modification time of every file under dir.
+func snapshotFiles(t *testing.T, dir string) map[string]string {
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[path] = fmt.Sprintf("%d %s", fi.Size(), fi.ModTime().Format(time.RFC3339Nano))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
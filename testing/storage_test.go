@@ -0,0 +1,256 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageVerify(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, _, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+	_, host := setupTestRegistry(t)
+	storagePath := constants.StoragePath(contextPath)
+
+	localKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-storage-local
+model:
+  path: model
+`
+	setupKitfileAndKitignore(t, modelKitPath, localKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin"})
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:storage-local", "--compression", "none")
+
+	remoteModelKitPath := filepath.Join(tmpDir, "remote-modelkit")
+	if err := os.MkdirAll(remoteModelKitPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	remoteKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-storage-remote
+datasets:
+  - path: data
+`
+	setupKitfileAndKitignore(t, remoteModelKitPath, remoteKitfile, "")
+	setupFiles(t, remoteModelKitPath, []string{"data/remote.csv"})
+	remoteRef := host + "/test/storage:latest"
+	runCommand(t, expectNoError, "pack", remoteModelKitPath, "-t", remoteRef, "--compression", "none", "--push", "--no-local-copy", "--plain-http")
+	runCommand(t, expectNoError, "pull", remoteRef, "--plain-http")
+
+	verifyOut := runCommand(t, expectNoError, "storage", "verify")
+	assert.Contains(t, verifyOut, "no problems found")
+
+	remoteLayer := corruptBlob(t, storagePath, "testing: data/remote.csv")
+	verifyOut = runCommand(t, expectError, "storage", "verify")
+	assert.Contains(t, verifyOut, "Blob sha256:"+remoteLayer+" is corrupt")
+
+	// Quarantining the blob leaves it missing from local storage
+	runCommand(t, expectError, "storage", "verify", "--quarantine")
+	assert.FileExists(t, filepath.Join(constants.QuarantinePath(storagePath), "sha256", remoteLayer))
+	assert.NoFileExists(t, filepath.Join(storagePath, "blobs", "sha256", remoteLayer))
+	verifyOut = runCommand(t, expectError, "storage", "verify")
+	assert.Contains(t, verifyOut, "Blob sha256:"+remoteLayer+" is missing")
+
+	verifyOut = runCommand(t, expectNoError, "storage", "verify", "--repull", "--plain-http")
+	assert.Contains(t, verifyOut, "Re-pulled blob sha256:"+remoteLayer)
+	runCommand(t, expectNoError, "storage", "verify")
+	runCommand(t, expectNoError, "verify", remoteRef)
+
+	// Tag files without a corresponding index are reported
+	strayTags := filepath.Join(storagePath, "b3JwaGFu-tags.json")
+	if err := os.WriteFile(strayTags, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	verifyOut = runCommand(t, expectError, "storage", "verify")
+	assert.Contains(t, verifyOut, "tag file b3JwaGFu-tags.json has no corresponding repository index")
+	if err := os.Remove(strayTags); err != nil {
+		t.Fatal(err)
+	}
+
+	// Modelkits that were packed locally have no source to re-pull from
+	localLayer := corruptBlob(t, storagePath, "testing: model/weights.bin")
+	verifyOut = runCommand(t, expectError, "storage", "verify", "--repull", "--plain-http")
+	assert.Contains(t, verifyOut, "Failed to re-pull blob sha256:"+localLayer)
+	assert.FileExists(t, filepath.Join(constants.QuarantinePath(storagePath), "sha256", localLayer))
+
+	// Repositories whose index cannot be parsed are reported without stopping other checks
+	if err := os.WriteFile(filepath.Join(storagePath, "b3JwaGFu-index.json"), []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	verifyOut = runCommand(t, expectError, "storage", "verify")
+	assert.Contains(t, verifyOut, "failed to read repository orphan")
+	assert.Contains(t, verifyOut, "Blob sha256:"+localLayer+" is missing")
+}
+
+func TestStorageDf(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, _, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	kitfile := `
+manifestVersion: 1.0.0
+package:
+  name: %s
+model:
+  path: model
+`
+	setupKitfileAndKitignore(t, modelKitPath, fmt.Sprintf(kitfile, "test-df-first"), "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin"})
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", "test:df-first", "--compression", "none")
+	runCommand(t, expectNoError, "tag", "test:df-first", "test:df-latest")
+	// Changing the Kitfile results in a new config and manifest that share the model layer
+	setupKitfileAndKitignore(t, modelKitPath, fmt.Sprintf(kitfile, "test-df-second"), "")
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", "other:df-second", "--compression", "none")
+
+	type sizes struct {
+		UniqueSize int64 `json:"uniqueSize"`
+		SharedSize int64 `json:"sharedSize"`
+		TotalSize  int64 `json:"totalSize"`
+	}
+	var usage struct {
+		Repositories []struct {
+			Repo      string `json:"repo"`
+			ModelKits int    `json:"modelkits"`
+			sizes
+		} `json:"repositories"`
+		ModelKits []struct {
+			Repo string   `json:"repo"`
+			Tags []string `json:"tags"`
+			sizes
+		} `json:"modelkits"`
+		TotalSize        int64 `json:"totalSize"`
+		SharedSize       int64 `json:"sharedSize"`
+		UnreferencedSize int64 `json:"unreferencedSize"`
+	}
+	dfOut := runCommand(t, expectNoError, "storage", "df", "--format", "json")
+	if err := json.Unmarshal([]byte(strings.Join(filterNonDebugLines(dfOut), "\n")), &usage); err != nil {
+		t.Fatalf("Failed to parse df output: %s\n%s", err, dfOut)
+	}
+
+	if !assert.Len(t, usage.Repositories, 2) || !assert.Len(t, usage.ModelKits, 2) {
+		return
+	}
+	assert.Equal(t, "other", usage.Repositories[0].Repo)
+	assert.Equal(t, "test", usage.Repositories[1].Repo)
+	assert.Equal(t, 1, usage.Repositories[1].ModelKits)
+	assert.ElementsMatch(t, []string{"df-first", "df-latest"}, usage.ModelKits[1].Tags)
+
+	// Only the model layer is shared; each modelkit has its own manifest and config
+	layerSize := usage.SharedSize
+	assert.Greater(t, layerSize, int64(0))
+	for _, kit := range usage.ModelKits {
+		assert.Equal(t, layerSize, kit.SharedSize)
+		assert.Greater(t, kit.UniqueSize, int64(0))
+		assert.Equal(t, kit.UniqueSize+kit.SharedSize, kit.TotalSize)
+	}
+	for _, repo := range usage.Repositories {
+		assert.Equal(t, layerSize, repo.SharedSize)
+	}
+	// Shared blobs are counted once in the total
+	assert.Equal(t, usage.ModelKits[0].UniqueSize+usage.ModelKits[1].UniqueSize+layerSize, usage.TotalSize)
+	assert.Equal(t, int64(0), usage.UnreferencedSize)
+
+	tableOut := runCommand(t, expectNoError, "storage", "df")
+	assert.Contains(t, tableOut, "df-first,df-latest")
+	assert.NotContains(t, tableOut, "Unreferenced")
+
+	var layerDigest string
+	for _, blob := range listBlobs(t, contextPath) {
+		if content, err := os.ReadFile(blob); err == nil && bytes.Contains(content, []byte("testing: model/weights.bin")) {
+			layerDigest = filepath.Base(blob)
+		}
+	}
+	if layerDigest == "" {
+		t.Fatal("Failed to find model layer in local storage")
+	}
+	blobOut := runCommand(t, expectNoError, "storage", "df", "--blob", layerDigest)
+	assert.Contains(t, blobOut, "is used by 2 modelkits")
+	assert.Contains(t, blobOut, "df-first,df-latest")
+	assert.Contains(t, blobOut, "df-second")
+	runCommand(t, expectError, "storage", "df", "--blob", "sha256:0000")
+
+	// Manifests that are only recorded in the shared index.json are kept by prune, so they are not unreferenced
+	totalSize := usage.TotalSize
+	otherIndexPath := constants.IndexJsonPathForRepo(constants.StoragePath(contextPath), "localhost/other")
+	otherIndex, err := os.ReadFile(otherIndexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(otherIndexPath); err != nil {
+		t.Fatal(err)
+	}
+	dfOut = runCommand(t, expectNoError, "storage", "df", "--format", "json")
+	if err := json.Unmarshal([]byte(strings.Join(filterNonDebugLines(dfOut), "\n")), &usage); err != nil {
+		t.Fatalf("Failed to parse df output: %s\n%s", err, dfOut)
+	}
+	assert.Len(t, usage.ModelKits, 1)
+	assert.Equal(t, totalSize, usage.TotalSize)
+	assert.Equal(t, int64(0), usage.UnreferencedSize)
+	if err := os.WriteFile(otherIndexPath, otherIndex, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing a modelkit makes the remaining copy of the layer unique
+	runCommand(t, expectNoError, "remove", "other:df-second")
+	dfOut = runCommand(t, expectNoError, "storage", "df", "--format", "json")
+	if err := json.Unmarshal([]byte(strings.Join(filterNonDebugLines(dfOut), "\n")), &usage); err != nil {
+		t.Fatalf("Failed to parse df output: %s\n%s", err, dfOut)
+	}
+	assert.Len(t, usage.ModelKits, 1)
+	assert.Equal(t, int64(0), usage.SharedSize)
+}
+
+// corruptBlob modifies the blob in local storage that contains the string original, returning its encoded digest.
+func corruptBlob(t *testing.T, storagePath, original string) string {
+	var corrupted string
+	err := filepath.WalkDir(filepath.Join(storagePath, "blobs"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		blob, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(blob, []byte(original)) {
+			corrupted = filepath.Base(path)
+			modified := bytes.Replace(blob, []byte(original), bytes.ToUpper([]byte(original)), 1)
+			return os.WriteFile(path, modified, 0644)
+		}
+		return nil
+	})
+	if err != nil || corrupted == "" {
+		t.Fatalf("Failed to modify blob in storage: %v", err)
+	}
+	return corrupted
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 The KitOps Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testing
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kitops-ml/kitops/pkg/lib/constants"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyModelKit(t *testing.T) {
+	testPreflight(t)
+	tmpDir := setupTempDir(t)
+	modelKitPath, unpackPath, contextPath := setupTestDirs(t, tmpDir)
+	t.Setenv(constants.KitopsHomeEnvVar, contextPath)
+
+	testKitfile := `
+manifestVersion: 1.0.0
+package:
+  name: test-verify
+model:
+  path: model
+datasets:
+  - path: data
+`
+	setupKitfileAndKitignore(t, modelKitPath, testKitfile, "")
+	setupFiles(t, modelKitPath, []string{"model/weights.bin", "data/train.csv"})
+	runCommand(t, expectNoError, "pack", modelKitPath, "-t", modelKitTag, "--compression", "none")
+
+	verifyOut := runCommand(t, expectNoError, "verify", modelKitTag)
+	assert.Contains(t, verifyOut, "Verified modelkit")
+
+	// Modify the contents of a file within the stored dataset layer without changing the layer's structure
+	original := []byte("testing: data/train.csv")
+	modified := []byte("modified: data/train.cs")
+	var corrupted bool
+	err := filepath.WalkDir(constants.StoragePath(contextPath), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		blob, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(blob, original) {
+			corrupted = true
+			return os.WriteFile(path, bytes.Replace(blob, original, modified, 1), 0644)
+		}
+		return nil
+	})
+	if err != nil || !corrupted {
+		t.Fatalf("Failed to modify dataset layer in storage: %v", err)
+	}
+
+	verifyOut = runCommand(t, expectError, "verify", modelKitTag)
+	assert.Contains(t, verifyOut, "1 of 2 layers failed verification")
+
+	unpackOut := runCommand(t, expectError, "unpack", modelKitTag, "-d", unpackPath, "--filter", "datasets")
+	assert.Contains(t, unpackOut, "layer digest mismatch")
+}